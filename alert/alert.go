@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// TypeWorkerOffline is sent if a worker had no hashrate for longer than the threshold of a subscription.
+	TypeWorkerOffline = "worker.offline"
+	// TypeWorkerOnline is sent if a worker which was reported as offline has a hashrate again.
+	TypeWorkerOnline = "worker.online"
+)
+
+// channels of the notifiers
+const (
+	ChannelWebhook  = "webhook"
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+)
+
+var (
+	// ErrUnknownChannel is returned if no notifier is configured for the channel of a subscription
+	ErrUnknownChannel = errors.New("unknown or disabled alert channel")
+	// ErrInvalidTarget is returned if the target of a subscription can't be used by its notifier
+	ErrInvalidTarget = errors.New("invalid alert target")
+	// ErrInvalidThreshold is returned if the threshold of a subscription is below the min threshold
+	ErrInvalidThreshold = errors.New("invalid alert threshold")
+)
+
+// Alert is a change of the status of a worker.
+type Alert struct {
+	Type     string    `json:"type"`
+	PoolID   string    `json:"poolId"`
+	Miner    string    `json:"miner"`
+	Worker   string    `json:"worker"`
+	LastSeen time.Time `json:"lastSeen"`
+	Created  time.Time `json:"created"`
+}
+
+// Subject returns a short summary of the alert.
+func (a *Alert) Subject() string {
+	if a.Type == TypeWorkerOnline {
+		return fmt.Sprintf("Worker %s is back online", workerName(a.Worker))
+	}
+	return fmt.Sprintf("Worker %s is offline", workerName(a.Worker))
+}
+
+// Text returns a human readable description of the alert.
+func (a *Alert) Text() string {
+	if a.Type == TypeWorkerOnline {
+		return fmt.Sprintf("Worker %s of %s on pool %s is back online.", workerName(a.Worker), a.Miner, a.PoolID)
+	}
+	return fmt.Sprintf("Worker %s of %s on pool %s is offline, it was last seen at %s.",
+		workerName(a.Worker), a.Miner, a.PoolID, a.LastSeen.UTC().Format(time.RFC3339))
+}
+
+// Notifier sends alerts to the target of a subscription, e.g. an email address.
+type Notifier interface {
+	// Validate returns ErrInvalidTarget if the notifier can't send alerts to the target.
+	Validate(target string) error
+	// Send sends the alert to the target.
+	Send(ctx context.Context, target string, a *Alert) error
+}
+
+// workerName returns the name of a worker, the default worker of miningcore has an empty name.
+func workerName(worker string) string {
+	if worker == "" {
+		return "default"
+	}
+	return worker
+}
@@ -0,0 +1,216 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/1oopio/phantomias/webhook"
+	"github.com/goccy/go-json"
+)
+
+const (
+	defaultNotifierTimeout = time.Second * 10
+	defaultTelegramURL     = "https://api.telegram.org"
+	maxTelegramChatIDLen   = 64
+)
+
+// Webhook posts alerts as JSON to the url of a subscription.
+// Like the webhooks of events, it refuses private networks unless they are allowed.
+type Webhook struct {
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewWebhook creates a new webhook notifier.
+func NewWebhook(timeout time.Duration, allowPrivate bool) *Webhook {
+	if timeout <= 0 {
+		timeout = defaultNotifierTimeout
+	}
+	return &Webhook{
+		client:       webhook.NewClient(timeout, allowPrivate, 0),
+		allowPrivate: allowPrivate,
+	}
+}
+
+// Validate checks that the target is a http or https url which doesn't point to a private network.
+func (w *Webhook) Validate(target string) error {
+	if err := webhook.ValidateTarget(target, w.allowPrivate); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTarget, err)
+	}
+	return nil
+}
+
+// Send posts the alert to the url.
+func (w *Webhook) Send(ctx context.Context, target string, a *Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, w.client, target, body)
+}
+
+// SMTP sends alerts as emails through a mail relay, e.g. a local postfix.
+type SMTP struct {
+	addr    string
+	from    string
+	timeout time.Duration
+}
+
+// NewSMTP creates a new email notifier which sends emails through the relay at addr.
+func NewSMTP(addr, from string) *SMTP {
+	return &SMTP{addr: addr, from: from, timeout: defaultNotifierTimeout}
+}
+
+// Validate checks that the target is a single email address.
+func (s *SMTP) Validate(target string) error {
+	addr, err := mail.ParseAddress(target)
+	if err != nil || addr.Address != target {
+		return fmt.Errorf("%w: expected an email address", ErrInvalidTarget)
+	}
+	return nil
+}
+
+// Send sends the alert as a plain text email.
+func (s *SMTP) Send(ctx context.Context, target string, a *Alert) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", target)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", stripNewlines(a.Subject()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", a.Created.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(a.Text())
+	msg.WriteString("\r\n")
+	if err := s.sendMail(ctx, target, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// sendMail sends the message like smtp.SendMail, but the whole session is bound to the context and the timeout.
+func (s *SMTP) sendMail(ctx context.Context, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Telegram sends alerts through the bot api of telegram or a compatible http api.
+// The target of a subscription is the id of the chat.
+type Telegram struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+// NewTelegram creates a new telegram notifier, url defaults to the telegram bot api.
+func NewTelegram(url, token string, timeout time.Duration) *Telegram {
+	if url == "" {
+		url = defaultTelegramURL
+	}
+	if timeout <= 0 {
+		timeout = defaultNotifierTimeout
+	}
+	return &Telegram{
+		client: &http.Client{Timeout: timeout},
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+	}
+}
+
+// Validate checks that the target looks like a chat id or a channel name.
+func (t *Telegram) Validate(target string) error {
+	if target == "" || len(target) > maxTelegramChatIDLen || strings.ContainsAny(target, " \t\r\n/") {
+		return fmt.Errorf("%w: expected a telegram chat id", ErrInvalidTarget)
+	}
+	return nil
+}
+
+// Send sends the alert as a message to the chat.
+func (t *Telegram) Send(ctx context.Context, target string, a *Alert) error {
+	body, err := json.Marshal(map[string]string{
+		"chat_id": target,
+		"text":    a.Text(),
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, t.client, t.url+"/bot"+t.token+"/sendMessage", body)
+}
+
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		// the url isn't logged, it can contain the token of a bot
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to send alert: unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+}
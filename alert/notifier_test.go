@@ -0,0 +1,127 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/webhook"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	var received Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(time.Second, true)
+	assert.NoError(t, wh.Validate(srv.URL))
+	assert.ErrorIs(t, wh.Validate("ftp://example.com"), ErrInvalidTarget)
+	assert.ErrorIs(t, wh.Validate("/relative"), ErrInvalidTarget)
+
+	a := &Alert{Type: TypeWorkerOffline, PoolID: "eth1", Miner: "0x1", Worker: "rig1"}
+	require.NoError(t, wh.Send(context.Background(), srv.URL, a))
+	assert.Equal(t, *a, received)
+	assert.Error(t, wh.Send(context.Background(), srv.URL+"/fail", a))
+}
+
+func TestWebhookPrivateTarget(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(time.Second, false)
+	assert.ErrorIs(t, wh.Validate(srv.URL), ErrInvalidTarget)
+	assert.ErrorIs(t, wh.Validate("http://localhost:8080/hook"), ErrInvalidTarget)
+	assert.NoError(t, wh.Validate("https://example.com/hook"))
+
+	// the dialer refuses loopback targets even if they weren't validated
+	a := &Alert{Type: TypeWorkerOffline, PoolID: "eth1", Miner: "0x1"}
+	assert.ErrorIs(t, wh.Send(context.Background(), srv.URL, a), webhook.ErrPrivateTarget)
+	assert.Zero(t, requests)
+}
+
+func TestWebhookRedirect(t *testing.T) {
+	var redirected bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected = true
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(time.Second, true)
+	a := &Alert{Type: TypeWorkerOffline, PoolID: "eth1", Miner: "0x1"}
+	assert.Error(t, wh.Send(context.Background(), srv.URL, a))
+	assert.False(t, redirected)
+}
+
+func TestTelegram(t *testing.T) {
+	var path string
+	var msg map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &msg)
+	}))
+	defer srv.Close()
+
+	tg := NewTelegram(srv.URL, "123:abc", time.Second)
+	assert.NoError(t, tg.Validate("-1001234"))
+	assert.ErrorIs(t, tg.Validate(""), ErrInvalidTarget)
+	assert.ErrorIs(t, tg.Validate("a b"), ErrInvalidTarget)
+
+	a := &Alert{Type: TypeWorkerOnline, PoolID: "eth1", Miner: "0x1"}
+	require.NoError(t, tg.Send(context.Background(), "-1001234", a))
+	assert.Equal(t, "/bot123:abc/sendMessage", path)
+	assert.Equal(t, "-1001234", msg["chat_id"])
+	assert.Equal(t, "Worker default of 0x1 on pool eth1 is back online.", msg["text"])
+}
+
+func TestSMTPValidate(t *testing.T) {
+	s := NewSMTP("localhost:25", "alerts@example.com")
+	assert.NoError(t, s.Validate("miner@example.com"))
+	assert.ErrorIs(t, s.Validate("Miner <miner@example.com>"), ErrInvalidTarget)
+	assert.ErrorIs(t, s.Validate("miner@example.com\r\nBcc: x@example.com"), ErrInvalidTarget)
+	assert.ErrorIs(t, s.Validate("invalid"), ErrInvalidTarget)
+}
+
+func TestSMTPContext(t *testing.T) {
+	// a relay which accepts the connection but never responds
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	s := NewSMTP(l.Addr().String(), "alerts@example.com")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	start := time.Now()
+	a := &Alert{Type: TypeWorkerOffline, PoolID: "eth1", Miner: "0x1"}
+	assert.Error(t, s.Send(ctx, "miner@example.com", a))
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,320 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+)
+
+const (
+	defaultInterval     = time.Minute
+	defaultLookback     = time.Hour * 24
+	defaultMinThreshold = time.Minute * 10
+	defaultMaxSubs      = 5
+	defaultWorkers      = 4
+	sendTimeout         = time.Second * 30
+)
+
+// DB contains the queries which are used by the watcher.
+type DB interface {
+	GetAlertSubscriptions(ctx context.Context) ([]*database.AlertSubscription, error)
+	GetAlertStates(ctx context.Context) ([]*database.AlertState, error)
+	InsertAlertState(ctx context.Context, state database.AlertState) error
+	DeleteAlertState(ctx context.Context, subscriptionID int64, worker string) error
+	GetWorkersLastSeen(ctx context.Context, poolID, miner string, since time.Time) ([]*database.WorkerLastSeen, error)
+}
+
+// Opts is a function that can be passed to New to configure the watcher
+type Opts func(w *Watcher)
+
+// WithContext sets the context to use for the watcher
+func WithContext(ctx context.Context) Opts {
+	return func(w *Watcher) {
+		w.parentCtx = ctx
+	}
+}
+
+// WithInterval sets the interval in which the workers are checked
+func WithInterval(interval time.Duration) Opts {
+	return func(w *Watcher) {
+		if interval > 0 {
+			w.interval = interval
+		}
+	}
+}
+
+// WithLookback sets the duration after which workers without stats are no longer checked
+func WithLookback(lookback time.Duration) Opts {
+	return func(w *Watcher) {
+		if lookback > 0 {
+			w.lookback = lookback
+		}
+	}
+}
+
+// WithMinThreshold sets the min threshold of a subscription
+func WithMinThreshold(threshold time.Duration) Opts {
+	return func(w *Watcher) {
+		if threshold > 0 {
+			w.minThreshold = threshold
+		}
+	}
+}
+
+// WithMaxSubscriptions sets the max number of subscriptions per miner
+func WithMaxSubscriptions(max int) Opts {
+	return func(w *Watcher) {
+		if max > 0 {
+			w.maxSubs = max
+		}
+	}
+}
+
+// WithWorkers sets the max number of alerts which are sent concurrently
+func WithWorkers(workers int) Opts {
+	return func(w *Watcher) {
+		if workers > 0 {
+			w.workers = workers
+		}
+	}
+}
+
+// WithNotifier adds a notifier which sends the alerts of subscriptions with the channel
+func WithNotifier(channel string, n Notifier) Opts {
+	return func(w *Watcher) {
+		w.notifiers[channel] = n
+	}
+}
+
+// WithClock sets the function which returns the current time, used in tests
+func WithClock(now func() time.Time) Opts {
+	return func(w *Watcher) {
+		w.now = now
+	}
+}
+
+type stateKey struct {
+	subscription int64
+	worker       string
+}
+
+type minerKey struct {
+	pool    string
+	address string
+}
+
+// Watcher detects workers of subscribed miners which stopped submitting shares
+// and sends alerts when they go offline and when they are back online.
+type Watcher struct {
+	parentCtx    context.Context
+	ctx          context.Context
+	cancel       context.CancelFunc
+	db           DB
+	interval     time.Duration
+	lookback     time.Duration
+	minThreshold time.Duration
+	maxSubs      int
+	workers      int
+	notifiers    map[string]Notifier
+	now          func() time.Time
+
+	// serializes the runs
+	runMu sync.Mutex
+}
+
+// New creates a new watcher.
+func New(db DB, opts ...Opts) *Watcher {
+	w := &Watcher{
+		parentCtx:    context.Background(),
+		db:           db,
+		interval:     defaultInterval,
+		lookback:     defaultLookback,
+		minThreshold: defaultMinThreshold,
+		maxSubs:      defaultMaxSubs,
+		workers:      defaultWorkers,
+		notifiers:    make(map[string]Notifier),
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.ctx, w.cancel = context.WithCancel(w.parentCtx)
+	return w
+}
+
+// Start checks the workers at the configured interval until the watcher is closed.
+func (w *Watcher) Start() {
+	log.Printf("[alerts] starting with interval %s and channels %v", w.interval, w.Channels())
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Run()
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the watcher.
+func (w *Watcher) Close() {
+	w.cancel()
+}
+
+// Channels returns the channels of the configured notifiers.
+func (w *Watcher) Channels() []string {
+	channels := make([]string, 0, len(w.notifiers))
+	for c := range w.notifiers {
+		channels = append(channels, c)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// MinThreshold returns the min threshold of a subscription.
+func (w *Watcher) MinThreshold() time.Duration {
+	return w.minThreshold
+}
+
+// MaxSubscriptions returns the max number of subscriptions per miner.
+func (w *Watcher) MaxSubscriptions() int {
+	return w.maxSubs
+}
+
+// Validate checks that alerts can be sent to the target through the channel.
+func (w *Watcher) Validate(channel, target string, threshold time.Duration) error {
+	n, ok := w.notifiers[channel]
+	if !ok {
+		return ErrUnknownChannel
+	}
+	if threshold < w.minThreshold || threshold > w.lookback {
+		return fmt.Errorf("%w: must be between %s and %s", ErrInvalidThreshold, w.minThreshold, w.lookback)
+	}
+	return n.Validate(target)
+}
+
+// Run checks the workers of all subscriptions once.
+func (w *Watcher) Run() {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	subs, err := w.db.GetAlertSubscriptions(w.ctx)
+	if err != nil {
+		log.Printf("[alerts][err] %s", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+	list, err := w.db.GetAlertStates(w.ctx)
+	if err != nil {
+		log.Printf("[alerts][err] %s", err)
+		return
+	}
+	states := make(map[stateKey]*database.AlertState, len(list))
+	for _, s := range list {
+		states[stateKey{s.SubscriptionID, s.Worker}] = s
+	}
+
+	miners := make(map[minerKey][]*database.AlertSubscription)
+	var order []minerKey
+	for _, sub := range subs {
+		k := minerKey{sub.PoolID, sub.Address}
+		if _, ok := miners[k]; !ok {
+			order = append(order, k)
+		}
+		miners[k] = append(miners[k], sub)
+	}
+
+	// alerts are sent concurrently, so unreachable targets don't delay the alerts of other subscriptions
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, w.workers)
+	defer wg.Wait()
+
+	now := w.now()
+	for _, k := range order {
+		if w.ctx.Err() != nil {
+			return
+		}
+		workers, err := w.db.GetWorkersLastSeen(w.ctx, k.pool, k.address, now.Add(-w.lookback))
+		if err != nil {
+			log.Printf("[alerts][err] %s", err)
+			continue
+		}
+		for _, sub := range miners[k] {
+			for _, a := range w.check(sub, workers, states, now) {
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(sub *database.AlertSubscription, a *Alert) {
+					defer func() {
+						<-sem
+						wg.Done()
+					}()
+					w.notify(sub, a)
+				}(sub, a)
+			}
+		}
+	}
+}
+
+// check returns the alerts of the workers whose status changed since the last check.
+func (w *Watcher) check(sub *database.AlertSubscription, workers []*database.WorkerLastSeen, states map[stateKey]*database.AlertState, now time.Time) []*Alert {
+	if _, ok := w.notifiers[sub.Channel]; !ok {
+		return nil
+	}
+	var alerts []*Alert
+	threshold := time.Duration(sub.Threshold) * time.Second
+	for _, worker := range workers {
+		_, alerted := states[stateKey{sub.ID, worker.Worker}]
+		offline := now.Sub(worker.LastSeen) > threshold
+		if offline == alerted {
+			continue
+		}
+
+		a := &Alert{
+			Type:     TypeWorkerOffline,
+			PoolID:   sub.PoolID,
+			Miner:    sub.Address,
+			Worker:   worker.Worker,
+			LastSeen: worker.LastSeen,
+			Created:  now,
+		}
+		if !offline {
+			a.Type = TypeWorkerOnline
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+// notify sends the alert and updates the status of the worker.
+func (w *Watcher) notify(sub *database.AlertSubscription, a *Alert) {
+	ctx, cancel := context.WithTimeout(w.ctx, sendTimeout)
+	err := w.notifiers[sub.Channel].Send(ctx, sub.Target, a)
+	cancel()
+	if err != nil {
+		// the status isn't updated, so the alert is sent again at the next check
+		log.Printf("[alerts][err] failed to send %s alert of subscription %d: %s", a.Type, sub.ID, err)
+		return
+	}
+
+	if a.Type == TypeWorkerOffline {
+		err = w.db.InsertAlertState(w.ctx, database.AlertState{
+			SubscriptionID: sub.ID,
+			Worker:         a.Worker,
+			LastSeen:       a.LastSeen,
+			Notified:       a.Created,
+		})
+	} else {
+		err = w.db.DeleteAlertState(w.ctx, sub.ID, a.Worker)
+	}
+	if err != nil {
+		log.Printf("[alerts][err] %s", err)
+	}
+}
@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDB struct {
+	mu      sync.Mutex
+	subs    []*database.AlertSubscription
+	states  map[stateKey]database.AlertState
+	workers map[minerKey][]*database.WorkerLastSeen
+}
+
+func (f *fakeDB) GetAlertSubscriptions(context.Context) ([]*database.AlertSubscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeDB) GetAlertStates(context.Context) ([]*database.AlertState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	states := make([]*database.AlertState, 0, len(f.states))
+	for _, s := range f.states {
+		s := s
+		states = append(states, &s)
+	}
+	return states, nil
+}
+
+func (f *fakeDB) InsertAlertState(_ context.Context, state database.AlertState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[stateKey{state.SubscriptionID, state.Worker}] = state
+	return nil
+}
+
+func (f *fakeDB) DeleteAlertState(_ context.Context, id int64, worker string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.states, stateKey{id, worker})
+	return nil
+}
+
+func (f *fakeDB) GetWorkersLastSeen(_ context.Context, poolID, miner string, _ time.Time) ([]*database.WorkerLastSeen, error) {
+	return f.workers[minerKey{poolID, miner}], nil
+}
+
+type fakeNotifier struct {
+	mu     sync.Mutex
+	fail   bool
+	alerts []*Alert
+	// sends to the target block until the channel is closed
+	block map[string]chan struct{}
+}
+
+func (f *fakeNotifier) Validate(target string) error {
+	if target == "" {
+		return ErrInvalidTarget
+	}
+	return nil
+}
+
+func (f *fakeNotifier) Send(_ context.Context, target string, a *Alert) error {
+	if c, ok := f.block[target]; ok {
+		<-c
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("notifier down")
+	}
+	f.alerts = append(f.alerts, a)
+	return nil
+}
+
+func (f *fakeNotifier) sent() []*Alert {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Alert(nil), f.alerts...)
+}
+
+func TestWatcher(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	db := &fakeDB{
+		subs: []*database.AlertSubscription{
+			{ID: 1, PoolID: "eth1", Address: "0x1", Channel: "fake", Threshold: 600},
+			{ID: 2, PoolID: "eth1", Address: "0x1", Channel: "fake", Threshold: 3600},
+			{ID: 3, PoolID: "eth1", Address: "0x2", Channel: "disabled", Threshold: 600},
+		},
+		states: make(map[stateKey]database.AlertState),
+		workers: map[minerKey][]*database.WorkerLastSeen{
+			{"eth1", "0x1"}: {
+				{Worker: "rig1", LastSeen: now.Add(-time.Minute)},
+				{Worker: "rig2", LastSeen: now.Add(-time.Minute * 20)},
+			},
+			{"eth1", "0x2"}: {
+				{Worker: "rig1", LastSeen: now.Add(-time.Hour)},
+			},
+		},
+	}
+	n := &fakeNotifier{}
+	w := New(db, WithNotifier("fake", n), WithClock(func() time.Time { return now }))
+	defer w.Close()
+
+	// rig2 is offline for the 10 minute threshold only
+	w.Run()
+	require.Len(t, n.alerts, 1)
+	assert.Equal(t, TypeWorkerOffline, n.alerts[0].Type)
+	assert.Equal(t, "rig2", n.alerts[0].Worker)
+	assert.Contains(t, db.states, stateKey{1, "rig2"})
+
+	// the alert is only sent once
+	w.Run()
+	assert.Len(t, n.alerts, 1)
+
+	// rig2 is back online
+	db.workers[minerKey{"eth1", "0x1"}][1].LastSeen = now
+	w.Run()
+	require.Len(t, n.alerts, 2)
+	assert.Equal(t, TypeWorkerOnline, n.alerts[1].Type)
+	assert.Empty(t, db.states)
+
+	// failed alerts are sent again at the next run
+	db.workers[minerKey{"eth1", "0x1"}][0].LastSeen = now.Add(-time.Hour * 2)
+	n.fail = true
+	w.Run()
+	assert.Empty(t, db.states)
+	n.fail = false
+	w.Run()
+	require.Len(t, n.alerts, 4)
+	assert.Len(t, db.states, 2)
+}
+
+func TestWatcherConcurrentSends(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	db := &fakeDB{
+		subs: []*database.AlertSubscription{
+			{ID: 1, PoolID: "eth1", Address: "0x1", Channel: "fake", Target: "unreachable", Threshold: 600},
+			{ID: 2, PoolID: "eth1", Address: "0x2", Channel: "fake", Target: "reachable", Threshold: 600},
+		},
+		states: make(map[stateKey]database.AlertState),
+		workers: map[minerKey][]*database.WorkerLastSeen{
+			{"eth1", "0x1"}: {{Worker: "rig1", LastSeen: now.Add(-time.Hour)}},
+			{"eth1", "0x2"}: {{Worker: "rig1", LastSeen: now.Add(-time.Hour)}},
+		},
+	}
+	unreachable := make(chan struct{})
+	n := &fakeNotifier{block: map[string]chan struct{}{"unreachable": unreachable}}
+	w := New(db, WithNotifier("fake", n), WithWorkers(2), WithClock(func() time.Time { return now }))
+	defer w.Close()
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+
+	// the unreachable target doesn't delay the other alerts
+	require.Eventually(t, func() bool { return len(n.sent()) == 1 }, time.Second, time.Millisecond*10)
+	assert.Equal(t, "0x2", n.sent()[0].Miner)
+
+	// the run waits for all sends
+	select {
+	case <-done:
+		t.Fatal("run returned before all alerts were sent")
+	case <-time.After(time.Millisecond * 50):
+	}
+	close(unreachable)
+	<-done
+	assert.Len(t, n.sent(), 2)
+	assert.Len(t, db.states, 2)
+}
+
+func TestWatcherValidate(t *testing.T) {
+	w := New(&fakeDB{}, WithNotifier("fake", &fakeNotifier{}), WithMinThreshold(time.Minute*5))
+	defer w.Close()
+
+	assert.Equal(t, []string{"fake"}, w.Channels())
+	assert.NoError(t, w.Validate("fake", "target", time.Minute*5))
+	assert.ErrorIs(t, w.Validate("unknown", "target", time.Minute*5), ErrUnknownChannel)
+	assert.ErrorIs(t, w.Validate("fake", "target", time.Minute), ErrInvalidThreshold)
+	assert.ErrorIs(t, w.Validate("fake", "target", time.Hour*48), ErrInvalidThreshold)
+	assert.ErrorIs(t, w.Validate("fake", "", time.Minute*5), ErrInvalidTarget)
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errAlertsDisabled        = errors.New("alerts are disabled")
+	errTooManySubscriptions  = errors.New("too many alert subscriptions")
+	errInvalidSubscriptionID = errors.New("invalid subscription id")
+)
+
+// @Summary Get the alert channels
+// @Description Get the channels through which alerts can be sent and the limits of subscriptions
+// @Tags Alerts
+// @Produce json
+// @Success 200 {object} api.AlertChannelsRes
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/alerts/channels [get]
+func (s *Server) getAlertChannelsHandler(c *fiber.Ctx) error {
+	if s.alerts == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errAlertsDisabled)
+	}
+	return c.JSON(&AlertChannelsRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: &AlertChannels{
+			Channels:         s.alerts.Channels(),
+			MinThreshold:     int64(s.alerts.MinThreshold() / time.Second),
+			MaxSubscriptions: s.alerts.MaxSubscriptions(),
+		},
+	})
+}
+
+// @Summary Get alert subscriptions
+// @Description Get the subscriptions to the worker offline alerts from a specific miner from a specific pool.
+// @Description The targets are masked for requests without an api key with the admin scope.
+// @Tags Alerts
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Success 200 {object} api.AlertSubscriptionsRes
+// @Failure 400 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/alerts [get]
+func (s *Server) getAlertSubscriptionsHandler(c *fiber.Ctx) error {
+	if s.alerts == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errAlertsDisabled)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	subs, err := s.db.GetMinerAlertSubscriptions(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	key := requestAPIKey(c)
+	private := key != nil && key.HasScope(apikey.ScopeAdmin)
+	res := make([]*AlertSubscription, len(subs))
+	for i, sub := range subs {
+		res[i] = dbAlertSubscriptionToAPI(sub, private)
+	}
+	return c.JSON(&AlertSubscriptionsRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: res,
+	})
+}
+
+// @Summary Subscribe to alerts
+// @Description Subscribe to alerts which are sent if a worker of a specific miner from a specific pool has no hashrate for longer than the threshold.
+// @Description The ownership of the address is proven the same way as for settings updates.
+// @Tags Alerts
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param subscription body api.AlertSubscriptionReq true "Channel, target and threshold in seconds incl. the proof of ownership"
+// @Success 200 {object} api.AlertSubscriptionRes
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/alerts [post]
+func (s *Server) postAlertSubscriptionHandler(c *fiber.Ctx) error {
+	if s.alerts == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errAlertsDisabled)
+	}
+	var req AlertSubscriptionReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	req.Target = strings.TrimSpace(req.Target)
+	threshold := time.Duration(req.Threshold) * time.Second
+	if req.Threshold == 0 {
+		threshold = s.alerts.MinThreshold()
+	}
+	if err := s.alerts.Validate(req.Channel, req.Target, threshold); err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+
+	proof, _, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req.OwnershipProof)
+	if err != nil {
+		return handleAPIError(c, code, err)
+	}
+
+	subs, err := s.db.GetMinerAlertSubscriptions(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	if len(subs) >= s.alerts.MaxSubscriptions() {
+		return handleAPIError(c, fiber.StatusBadRequest, errTooManySubscriptions)
+	}
+
+	sub := database.AlertSubscription{
+		PoolID:    poolCfg.ID,
+		Address:   addr,
+		Channel:   req.Channel,
+		Target:    req.Target,
+		Threshold: int64(threshold / time.Second),
+		Created:   time.Now().UTC(),
+	}
+	sub.ID, err = s.db.CreateAlertSubscription(c.UserContext(), sub)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] alert subscription %d of miner %s on pool %s created by %s with %s proof", sub.ID, addr, poolCfg.ID, requestIdentity(c), proof)
+	return c.JSON(&AlertSubscriptionRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: dbAlertSubscriptionToAPI(&sub, true),
+	})
+}
+
+// @Summary Unsubscribe from alerts
+// @Description Delete a subscription to the worker offline alerts from a specific miner from a specific pool.
+// @Description The ownership of the address is proven the same way as for settings updates, it isn't required for api keys with the admin scope.
+// @Tags Alerts
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param sub_id path int true "ID of the subscription"
+// @Param proof body api.OwnershipProof false "Proof of ownership"
+// @Success 200 {object} api.Meta
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/alerts/{sub_id} [delete]
+func (s *Server) deleteAlertSubscriptionHandler(c *fiber.Ctx) error {
+	if s.alerts == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errAlertsDisabled)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+	id, err := strconv.ParseInt(c.Params("sub_id"), 10, 64)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, errInvalidSubscriptionID)
+	}
+
+	proof := "admin"
+	if key := requestAPIKey(c); key == nil || !key.HasScope(apikey.ScopeAdmin) {
+		var req OwnershipProof
+		if err := c.BodyParser(&req); err != nil {
+			return handleAPIError(c, fiber.StatusBadRequest, err)
+		}
+		method, _, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req)
+		if err != nil {
+			return handleAPIError(c, code, err)
+		}
+		proof = method
+	}
+
+	if err := s.db.DeleteAlertSubscription(c.UserContext(), poolCfg.ID, addr, id); err != nil {
+		if errors.Is(err, database.ErrAlertSubscriptionNotFound) {
+			return handleAPIError(c, fiber.StatusNotFound, err)
+		}
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] alert subscription %d of miner %s on pool %s deleted by %s with %s proof", id, addr, poolCfg.ID, requestIdentity(c), proof)
+	return c.JSON(&Meta{
+		Success: true,
+	})
+}
+
+// dbAlertSubscriptionToAPI converts a subscription, the target is masked unless private is set.
+func dbAlertSubscriptionToAPI(sub *database.AlertSubscription, private bool) *AlertSubscription {
+	target := sub.Target
+	if !private {
+		target = maskTarget(target)
+	}
+	return &AlertSubscription{
+		ID:        sub.ID,
+		Channel:   sub.Channel,
+		Target:    target,
+		Threshold: sub.Threshold,
+		Created:   sub.Created,
+	}
+}
+
+// maskTarget hides most of the target of an alert subscription, e.g. an email address.
+func maskTarget(target string) string {
+	const visible = 3
+	if len(target) <= visible*3 {
+		return "***"
+	}
+	return target[:visible] + "***" + target[len(target)-visible:]
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/alert"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertChannels(t *testing.T) {
+	s := &Server{}
+	app := fiber.New()
+	app.Get("/alerts/channels", s.getAlertChannelsHandler)
+
+	res, _ := testRequest(t, app, "/alerts/channels", nil)
+	assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+
+	s.alerts = alert.New(nil,
+		alert.WithNotifier(alert.ChannelWebhook, alert.NewWebhook(time.Second, true)),
+		alert.WithNotifier(alert.ChannelEmail, alert.NewSMTP("localhost:25", "alerts@example.com")),
+		alert.WithMinThreshold(time.Minute*15),
+		alert.WithMaxSubscriptions(3),
+	)
+	defer s.alerts.Close()
+
+	res, body := testRequest(t, app, "/alerts/channels", nil)
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+	var channels AlertChannelsRes
+	require.NoError(t, json.Unmarshal(body, &channels))
+	assert.Equal(t, &AlertChannels{
+		Channels:         []string{alert.ChannelEmail, alert.ChannelWebhook},
+		MinThreshold:     900,
+		MaxSubscriptions: 3,
+	}, channels.Result)
+}
+
+func TestMaskTarget(t *testing.T) {
+	assert.Equal(t, "min***com", maskTarget("miner@example.com"))
+	assert.Equal(t, "htt***ook", maskTarget("https://example.com/hook"))
+	assert.Equal(t, "***", maskTarget("-100123"))
+	assert.Equal(t, "***", maskTarget(""))
+}
@@ -2,13 +2,22 @@ package api
 
 import (
 	"context"
+	"time"
 
+	"github.com/1oopio/phantomias/alert"
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/cache"
 	"github.com/1oopio/phantomias/config"
 	"github.com/1oopio/phantomias/database"
 	"github.com/1oopio/phantomias/price"
+	"github.com/1oopio/phantomias/ratelimit"
+	"github.com/1oopio/phantomias/scheduler"
 	"github.com/1oopio/phantomias/version"
+	"github.com/1oopio/phantomias/webhook"
+	"github.com/1oopio/phantomias/ws"
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stratumfarm/go-miningcore-client"
 )
 
@@ -24,10 +33,99 @@ type Server struct {
 	price            price.Client
 	metricsCollector fiber.Handler
 	pools            []*config.Pool
+	upstreams        UpstreamStatusProvider
+	cacheStorage     cache.Storage
+	nonceStorage     cache.Storage
+	scheduler        *scheduler.Scheduler
+	rateLimitCfg     *config.RateLimit
+	rateLimitStore   ratelimit.Store
+	apiKeys          *apikey.Manager
+	alerts           *alert.Watcher
+	webhooks         *webhook.Dispatcher
+	shareStatsWindow time.Duration
+}
+
+// Opt is a function that can be passed to New to configure the server.
+type Opt func(*Server)
+
+// UpstreamStatusProvider provides the status of the miningcore websocket upstreams.
+type UpstreamStatusProvider interface {
+	Status() []*ws.Status
+}
+
+// WithUpstreamStatus sets the provider which is used to report the upstream health.
+func WithUpstreamStatus(p UpstreamStatusProvider) Opt {
+	return func(s *Server) {
+		s.upstreams = p
+	}
+}
+
+// WithCacheStorage sets the storage of the api cache.
+// Defaults to an in-memory storage.
+func WithCacheStorage(storage cache.Storage) Opt {
+	return func(s *Server) {
+		s.cacheStorage = storage
+	}
+}
+
+// WithNonceStorage sets the storage of the settings nonces.
+// Defaults to a small in-memory storage, so the nonces and the cached responses can't crowd out each other.
+func WithNonceStorage(storage cache.Storage) Opt {
+	return func(s *Server) {
+		s.nonceStorage = storage
+	}
+}
+
+// WithRateLimitStore sets the store of the rate limit counters.
+// Defaults to an in-memory store.
+func WithRateLimitStore(store ratelimit.Store) Opt {
+	return func(s *Server) {
+		s.rateLimitStore = store
+	}
+}
+
+// WithAPIKeys sets the manager which verifies the api keys of requests.
+// Without a manager, all requests with an api key are rejected.
+func WithAPIKeys(m *apikey.Manager) Opt {
+	return func(s *Server) {
+		s.apiKeys = m
+	}
+}
+
+// WithAlerts sets the watcher which sends the worker offline alerts.
+// Without a watcher, subscriptions to alerts are rejected.
+func WithAlerts(w *alert.Watcher) Opt {
+	return func(s *Server) {
+		s.alerts = w
+	}
+}
+
+// WithWebhooks sets the dispatcher which sends the pool events to webhooks.
+// Without a dispatcher, the webhook routes respond with not found.
+func WithWebhooks(d *webhook.Dispatcher) Opt {
+	return func(s *Server) {
+		s.webhooks = d
+	}
+}
+
+// WithShareStats sets the window of the share counts on the miner and worker endpoints.
+// Without a window, the share counts are omitted.
+func WithShareStats(window time.Duration) Opt {
+	return func(s *Server) {
+		s.shareStatsWindow = window
+	}
+}
+
+// WithScheduler sets the scheduler which precomputes expensive aggregates.
+// Without a scheduler, the aggregates are computed on demand.
+func WithScheduler(sched *scheduler.Scheduler) Opt {
+	return func(s *Server) {
+		s.scheduler = sched
+	}
 }
 
 // New creates a new server.
-func New(ctx context.Context, cfg *config.API, pools []*config.Pool, mc *miningcore.Client, db *database.DB, price price.Client, metricsCollector fiber.Handler) *Server {
+func New(ctx context.Context, cfg *config.API, pools []*config.Pool, mc *miningcore.Client, db *database.DB, price price.Client, metricsCollector fiber.Handler, opts ...Opt) *Server {
 	ctxc, cancel := context.WithCancel(ctx)
 	s := &Server{
 		ctx:    ctxc,
@@ -37,21 +135,39 @@ func New(ctx context.Context, cfg *config.API, pools []*config.Pool, mc *miningc
 			JSONDecoder:             json.Unmarshal,
 			EnableTrustedProxyCheck: cfg.TrustedProxyCheck,
 			TrustedProxies:          cfg.TrustedProxies,
+			ProxyHeader:             cfg.ProxyHeader,
+			EnableIPValidation:      cfg.ProxyHeader != "",
 			DisableStartupMessage:   version.Version != version.Development,
 		}),
 		mc:               mc,
 		db:               db,
 		cfg:              cfg,
 		pools:            pools,
-		wsRelay:          newWSRelay(ctx),
+		wsRelay:          newWSRelay(ctx, cfg.WS),
 		price:            price,
 		metricsCollector: metricsCollector,
+		rateLimitCfg:     rateLimitConfig(cfg.RateLimit),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.cacheStorage == nil {
+		s.cacheStorage = cache.NewMemory(cache.DefaultMaxBytes)
+	}
+	if s.nonceStorage == nil {
+		s.nonceStorage = cache.NewMemory(settingsNonceMaxBytes)
+	}
+	if s.rateLimitStore == nil {
+		s.rateLimitStore = ratelimit.NewMemory()
 	}
 
 	s.api.Use(s.recover())
 	if s.metricsCollector != nil {
 		s.api.Use(s.metricsCollector)
 	}
+	if cfg.Compression != nil && cfg.Compression.Enabled {
+		s.api.Use(s.compress())
+	}
 
 	s.setupRoutes()
 	return s
@@ -78,6 +194,11 @@ func (s *Server) BroadcastChan() chan []byte {
 	return s.wsRelay.broadcast
 }
 
+// Collectors returns the prometheus collectors of the server.
+func (s *Server) Collectors() []prometheus.Collector {
+	return s.wsRelay.collectors()
+}
+
 func (s *Server) API() *fiber.App {
 	return s.api
 }
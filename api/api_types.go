@@ -2,11 +2,14 @@ package api
 
 import (
 	"time"
+
+	"github.com/goccy/go-json"
 )
 
 type Meta struct {
-	PageCount uint `json:"pageCount"`
-	Success   bool `json:"success"`
+	PageCount   uint       `json:"pageCount"`
+	Success     bool       `json:"success"`
+	GeneratedAt *time.Time `json:"generatedAt,omitempty"`
 }
 
 type StatsRes struct {
@@ -51,6 +54,10 @@ type Pool struct {
 	NetworkHashrate   float64          `json:"networkHashrate"`
 	NetworkDifficulty float64          `json:"networkDifficulty"`
 	Prices            map[string]Price `json:"prices"`
+	Error             string           `json:"error,omitempty"`
+
+	// time of the stats, used for the Last-Modified header
+	updated time.Time
 }
 
 type PoolExtendedRes struct {
@@ -89,19 +96,19 @@ type BlocksRes struct {
 }
 
 type Block struct {
-	PoolID                      string    `json:"poolId"`
-	BlockHeight                 int64     `json:"blockHeight"`
-	NetworkDifficulty           float64   `json:"networkDifficulty"`
-	Status                      string    `json:"status"`
-	ConfirmationProgress        float64   `json:"confirmationProgress"`
-	Effort                      float64   `json:"effort"`
-	TransactionConfirmationData string    `json:"transactionConfirmationData"`
-	Reward                      float64   `json:"reward"`
-	InfoLink                    string    `json:"infoLink,omitempty"`
-	Hash                        string    `json:"hash"`
-	Miner                       string    `json:"miner"`
-	Source                      string    `json:"source"`
-	Created                     time.Time `json:"created"`
+	PoolID                      string    `json:"poolId" csv:"poolId"`
+	BlockHeight                 int64     `json:"blockHeight" csv:"blockHeight"`
+	NetworkDifficulty           float64   `json:"networkDifficulty" csv:"networkDifficulty"`
+	Status                      string    `json:"status" csv:"status"`
+	ConfirmationProgress        float64   `json:"confirmationProgress" csv:"confirmationProgress"`
+	Effort                      float64   `json:"effort" csv:"effort"`
+	TransactionConfirmationData string    `json:"transactionConfirmationData" csv:"transactionConfirmationData"`
+	Reward                      float64   `json:"reward" csv:"reward"`
+	InfoLink                    string    `json:"infoLink,omitempty" csv:"infoLink"`
+	Hash                        string    `json:"hash" csv:"hash"`
+	Miner                       string    `json:"miner" csv:"miner"`
+	Source                      string    `json:"source" csv:"source"`
+	Created                     time.Time `json:"created" csv:"created"`
 }
 
 type PaymentsRes struct {
@@ -110,13 +117,13 @@ type PaymentsRes struct {
 }
 
 type Payment struct {
-	Coin                        string    `json:"coin"`
-	Address                     string    `json:"address"`
-	AddressInfoLink             string    `json:"addressInfoLink,omitempty"`
-	Amount                      float64   `json:"amount"`
-	TransactionConfirmationData string    `json:"transactionConfirmationData"`
-	TransactionInfoLink         string    `json:"transactionInfoLink,omitempty"`
-	Created                     time.Time `json:"created"`
+	Coin                        string    `json:"coin" csv:"coin"`
+	Address                     string    `json:"address" csv:"address"`
+	AddressInfoLink             string    `json:"addressInfoLink,omitempty" csv:"addressInfoLink"`
+	Amount                      float64   `json:"amount" csv:"amount"`
+	TransactionConfirmationData string    `json:"transactionConfirmationData" csv:"transactionConfirmationData"`
+	TransactionInfoLink         string    `json:"transactionInfoLink,omitempty" csv:"transactionInfoLink"`
+	Created                     time.Time `json:"created" csv:"created"`
 }
 
 type PoolPerformanceRes struct {
@@ -138,9 +145,9 @@ type MinersRes struct {
 }
 
 type MinerSimple struct {
-	Miner           string  `json:"miner"`
-	Hashrate        float64 `json:"hashrate"`
-	SharesPerSecond float64 `json:"sharesPerSecond"`
+	Miner           string  `json:"miner" csv:"miner"`
+	Hashrate        float64 `json:"hashrate" csv:"hashrate"`
+	SharesPerSecond float64 `json:"sharesPerSecond" csv:"sharesPerSecond"`
 }
 
 type MinerRes struct {
@@ -158,6 +165,8 @@ type Miner struct {
 	Performance     *WorkerPerformanceStatsContainer `json:"performance"`
 	Prices          map[string]Price                 `json:"prices"`
 	Coin            string                           `json:"coin"`
+	Rigs            []*Rig                           `json:"rigs,omitempty"`   // only set if the workers are grouped
+	Shares          *ShareCounts                     `json:"shares,omitempty"` // only set if the shares are counted
 }
 
 type WorkerPerformanceStatsContainer struct {
@@ -166,9 +175,27 @@ type WorkerPerformanceStatsContainer struct {
 }
 
 type WorkerPerformanceStats struct {
-	Hashrate         *float64 `json:"hashrate"`
-	ReportedHashrate *float64 `json:"reportedHashrate"`
-	SharesPerSecond  *float64 `json:"sharesPerSecond"`
+	Hashrate         *float64     `json:"hashrate"`
+	ReportedHashrate *float64     `json:"reportedHashrate"`
+	SharesPerSecond  *float64     `json:"sharesPerSecond"`
+	Labels           []string     `json:"labels,omitempty"`
+	Shares           *ShareCounts `json:"shares,omitempty"` // only set if the shares are counted
+}
+
+// ShareCounts are the numbers of shares by their result within the window of the share stats.
+type ShareCounts struct {
+	Accepted   int64   `json:"accepted"`
+	Rejected   int64   `json:"rejected"`
+	Stale      int64   `json:"stale"`
+	StaleRatio float64 `json:"staleRatio"` // ratio of the stale to all shares
+}
+
+type Rig struct {
+	Name             string   `json:"name"`
+	Workers          []string `json:"workers"`
+	Hashrate         float64  `json:"hashrate"`
+	ReportedHashrate float64  `json:"reportedHashrate"`
+	SharesPerSecond  float64  `json:"sharesPerSecond"`
 }
 
 type PerformanceStats struct {
@@ -213,12 +240,135 @@ type MinerSettingsRes struct {
 }
 
 type MinerSettings struct {
-	PaymentThreshold float64 `json:"paymentThreshold"`
+	PaymentThreshold     float64 `json:"paymentThreshold"`
+	HideFromLeaderboards *bool   `json:"hideFromLeaderboards,omitempty"` // read-only, changed with /settings/leaderboard
+}
+
+// OwnershipProof proves the ownership of a miner address either by an IP address
+// the miner recently submitted shares from or by the signature of the message of a nonce.
+type OwnershipProof struct {
+	IPAddress string `json:"ipAddress"`
+	Nonce     string `json:"nonce,omitempty"`
+	Signature string `json:"signature,omitempty"`
 }
 
 type MinerSettingsReq struct {
-	IPAddress string         `json:"ipAddress"`
-	Settings  *MinerSettings `json:"settings"`
+	OwnershipProof
+	Settings *MinerSettings `json:"settings"`
+}
+
+type SettingsHistoryRes struct {
+	*Meta
+	Result []*SettingsChange `json:"result"`
+}
+
+type SettingsChange struct {
+	OldPaymentThreshold *float64  `json:"oldPaymentThreshold"`
+	NewPaymentThreshold float64   `json:"newPaymentThreshold"`
+	IPAddress           string    `json:"ipAddress,omitempty"`
+	RequestedBy         string    `json:"requestedBy,omitempty"`
+	Proof               string    `json:"proof"`
+	Created             time.Time `json:"created"`
+}
+
+type AlertSubscriptionsRes struct {
+	*Meta
+	Result []*AlertSubscription `json:"result"`
+}
+
+type AlertSubscriptionRes struct {
+	*Meta
+	Result *AlertSubscription `json:"result"`
+}
+
+type AlertSubscription struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	Target    string    `json:"target"`
+	Threshold int64     `json:"threshold"` // seconds
+	Created   time.Time `json:"created"`
+}
+
+type AlertSubscriptionReq struct {
+	OwnershipProof
+	Channel   string `json:"channel"`
+	Target    string `json:"target"`
+	Threshold int64  `json:"threshold"` // seconds, defaults to the min threshold
+}
+
+type AlertChannelsRes struct {
+	*Meta
+	Result *AlertChannels `json:"result"`
+}
+
+type AlertChannels struct {
+	Channels         []string `json:"channels"`
+	MinThreshold     int64    `json:"minThreshold"` // seconds
+	MaxSubscriptions int      `json:"maxSubscriptions"`
+}
+
+type WebhooksRes struct {
+	*Meta
+	Result []*Webhook `json:"result"`
+}
+
+type WebhookRes struct {
+	*Meta
+	Result *Webhook `json:"result"`
+}
+
+type Webhook struct {
+	ID      int64     `json:"id"`
+	PoolID  string    `json:"poolId,omitempty"`
+	Address string    `json:"address,omitempty"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	Secret  string    `json:"secret,omitempty"` // only returned when the webhook is created
+	Created time.Time `json:"created"`
+}
+
+type WebhookReq struct {
+	OwnershipProof
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+type AdminWebhookReq struct {
+	PoolID  string   `json:"poolId"`  // empty for all pools
+	Address string   `json:"address"` // empty for all miners
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+}
+
+type WebhookDeliveriesRes struct {
+	*Meta
+	Result []*WebhookDelivery `json:"result"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhookId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttempt    *time.Time      `json:"nextAttempt,omitempty"` // only set for pending deliveries
+	LastAttempt    *time.Time      `json:"lastAttempt,omitempty"`
+	ResponseStatus *int            `json:"responseStatus,omitempty"`
+	LastError      *string         `json:"lastError,omitempty"`
+	Created        time.Time       `json:"created"`
+}
+
+type MinerSettingsNonceRes struct {
+	*Meta
+	Result *MinerSettingsNonce `json:"result"`
+}
+
+type MinerSettingsNonce struct {
+	Nonce   string    `json:"nonce"`
+	Message string    `json:"message"`
+	Expires time.Time `json:"expires"`
 }
 
 type WorkerPerformanceRes struct {
@@ -227,8 +377,64 @@ type WorkerPerformanceRes struct {
 }
 
 type Worker struct {
-	Hashrate        float64 `json:"hashrate"`
-	SharesPerSecond float64 `json:"sharesPerSecond"`
+	Hashrate        float64      `json:"hashrate"`
+	SharesPerSecond float64      `json:"sharesPerSecond"`
+	Labels          []string     `json:"labels,omitempty"`
+	Shares          *ShareCounts `json:"shares,omitempty"` // only set if the shares are counted
+}
+
+type WorkerLabelsRes struct {
+	*Meta
+	Result []string `json:"result"`
+}
+
+type WorkerLabelsReq struct {
+	OwnershipProof
+	Labels []string `json:"labels"` // replaces all labels of the worker, empty to remove them
+}
+
+type LeaderboardOptOutRes struct {
+	*Meta
+	Result bool `json:"result"`
+}
+
+type LeaderboardOptOutReq struct {
+	OwnershipProof
+	HideFromLeaderboards bool `json:"hideFromLeaderboards"` // hide the miner from the top miners and the leaderboard
+}
+
+type WorkersRes struct {
+	*Meta
+	Result []*WorkerStatus `json:"result"`
+}
+
+type WorkerStatus struct {
+	Name             string    `json:"name" csv:"name"`
+	Online           bool      `json:"online" csv:"online"`
+	LastSeen         time.Time `json:"lastSeen" csv:"lastSeen"`
+	Hashrate         float64   `json:"hashrate" csv:"hashrate"`
+	AverageHashrate  float64   `json:"averageHashrate" csv:"averageHashrate"`
+	ReportedHashrate float64   `json:"reportedHashrate" csv:"reportedHashrate"`
+	SharesPerSecond  float64   `json:"sharesPerSecond" csv:"sharesPerSecond"`
+	UserAgent        string    `json:"userAgent,omitempty" csv:"userAgent"`
+	Rig              string    `json:"rig,omitempty" csv:"rig"` // only set if the workers are grouped
+	Labels           []string  `json:"labels,omitempty" csv:"-"`
+}
+
+type WorkersHealthRes struct {
+	*Meta
+	Result []*WorkerHealth `json:"result"`
+}
+
+type WorkerHealth struct {
+	Name             string  `json:"name" csv:"name"`
+	Status           string  `json:"status" csv:"status"`
+	Flagged          bool    `json:"flagged" csv:"flagged"`
+	Hashrate         float64 `json:"hashrate" csv:"hashrate"`                 // average effective hashrate
+	ReportedHashrate float64 `json:"reportedHashrate" csv:"reportedHashrate"` // average reported hashrate
+	Deviation        float64 `json:"deviation" csv:"deviation"`               // relative deviation of the effective from the reported hashrate
+	Samples          int     `json:"samples" csv:"samples"`                   // samples with an effective and a reported hashrate
+	DeviatingSamples int     `json:"deviatingSamples" csv:"deviatingSamples"` // samples which deviate by more than the threshold
 }
 
 type WorkerRes struct {
@@ -248,3 +454,35 @@ type TopMiner struct {
 	TotalPaid float64    `json:"totalPaid"`
 	Joined    *time.Time `json:"joined"`
 }
+
+type LeaderboardRes struct {
+	*Meta
+	Result []*LeaderboardEntry `json:"result"`
+}
+
+type LeaderboardEntry struct {
+	Rank      int     `json:"rank" csv:"rank"`
+	Miner     string  `json:"miner" csv:"miner"`
+	Hashrate  float64 `json:"hashrate" csv:"hashrate"` // average hashrate within the window
+	Workers   int     `json:"workers" csv:"workers"`   // max workers online at the same time
+	Blocks    int     `json:"blocks" csv:"blocks"`
+	TotalPaid float64 `json:"totalPaid" csv:"totalPaid"`
+	Uptime    float64 `json:"uptime" csv:"uptime"` // share of the window with a hashrate, 0-1
+}
+
+type HealthRes struct {
+	Status    string            `json:"status"`
+	Upstreams []*UpstreamHealth `json:"upstreams,omitempty"`
+}
+
+type UpstreamHealth struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Connected   bool       `json:"connected"`
+	Since       time.Time  `json:"since"`
+	LastMessage *time.Time `json:"lastMessage"`
+	LastError   string     `json:"lastError,omitempty"`
+	Received    uint64     `json:"received"`
+	Duplicates  uint64     `json:"duplicates"`
+	Reconnects  uint64     `json:"reconnects"`
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	headerAPIKey = "X-API-Key"
+	localsAPIKey = "apikey"
+)
+
+var (
+	errAPIKeyRequired = errors.New("api key required")
+	errMissingScope   = errors.New("api key is missing the required scope")
+)
+
+// identify verifies the api key of the request and attaches it to the request.
+// The key is read from the X-API-Key header or from a bearer token.
+// Requests without a key are anonymous, requests with an invalid or revoked key are rejected.
+// Keys which aren't cached are only looked up within the auth rate limit of the client IP.
+func (s *Server) identify() fiber.Handler {
+	lookupAllowed := s.lookupLimiter()
+	return func(c *fiber.Ctx) error {
+		token := apiKeyToken(c)
+		if token == "" {
+			return c.Next()
+		}
+		if s.apiKeys == nil {
+			return handleAPIError(c, fiber.StatusUnauthorized, apikey.ErrInvalidKey)
+		}
+		if !s.apiKeys.Cached(token) {
+			if ok, err := lookupAllowed(c); !ok {
+				return err
+			}
+		}
+		key, err := s.apiKeys.Verify(c.UserContext(), token)
+		if err != nil {
+			if errors.Is(err, apikey.ErrInvalidKey) || errors.Is(err, apikey.ErrRevokedKey) {
+				return handleAPIError(c, fiber.StatusUnauthorized, err)
+			}
+			log.Printf("failed to verify api key: %v", err)
+			return handleAPIError(c, fiber.StatusInternalServerError, fiber.ErrInternalServerError)
+		}
+		c.Locals(localsAPIKey, key)
+		return c.Next()
+	}
+}
+
+// scope checks the api key of the request for the given scope.
+// Anonymous requests are allowed, except for routes which require the admin scope.
+// Requests with a key without the scope are handled as anonymous requests,
+// so a key never has less access than no key. Routes which require the admin scope reject them.
+// Only the given methods are checked, all methods are checked if none is given.
+func (s *Server) scope(scope apikey.Scope, methods ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if len(methods) > 0 && !contains(methods, c.Method()) {
+			return c.Next()
+		}
+		key := requestAPIKey(c)
+		if key == nil {
+			if scope == apikey.ScopeAdmin {
+				return handleAPIError(c, fiber.StatusUnauthorized, errAPIKeyRequired)
+			}
+			return c.Next()
+		}
+		if !key.HasScope(scope) {
+			if scope == apikey.ScopeAdmin {
+				return handleAPIError(c, fiber.StatusForbidden, errMissingScope)
+			}
+			c.Locals(localsAPIKey, nil)
+		}
+		return c.Next()
+	}
+}
+
+// requestAPIKey returns the verified api key of the request or nil if the request is anonymous.
+func requestAPIKey(c *fiber.Ctx) *apikey.Key {
+	key, _ := c.Locals(localsAPIKey).(*apikey.Key)
+	return key
+}
+
+// requestIdentity returns the id of the api key of the request or the IP of anonymous requests.
+func requestIdentity(c *fiber.Ctx) string {
+	if key := requestAPIKey(c); key != nil {
+		return "key:" + key.ID
+	}
+	return "ip:" + c.IP()
+}
+
+// apiKeyToken returns the api key of the request.
+// The token is copied, because header values are only valid until the request is done
+// and the id of the key is kept in the cache of the manager.
+func apiKeyToken(c *fiber.Ctx) string {
+	if token := c.Get(headerAPIKey); token != "" {
+		return strings.Clone(token)
+	}
+	auth := c.Get(fiber.HeaderAuthorization)
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.Clone(strings.TrimSpace(auth[7:]))
+	}
+	return ""
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
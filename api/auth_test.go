@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/ratelimit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keysDB map[string]database.APIKey
+
+func (db keysDB) CreateAPIKey(_ context.Context, key database.APIKey) error {
+	db[key.ID] = key
+	return nil
+}
+
+func (db keysDB) GetAPIKey(_ context.Context, id string) (*database.APIKey, error) {
+	k, ok := db[id]
+	if !ok {
+		return nil, database.ErrAPIKeyNotFound
+	}
+	return &k, nil
+}
+
+func (db keysDB) GetAPIKeys(context.Context) ([]*database.APIKey, error) { return nil, nil }
+func (db keysDB) RevokeAPIKey(context.Context, string) error             { return nil }
+func (db keysDB) TouchAPIKey(context.Context, string, time.Time) error   { return nil }
+
+type countingKeysDB struct {
+	keysDB
+	fetches int
+}
+
+func (db *countingKeysDB) GetAPIKey(ctx context.Context, id string) (*database.APIKey, error) {
+	db.fetches++
+	return db.keysDB.GetAPIKey(ctx, id)
+}
+
+func TestAuth(t *testing.T) {
+	keys := apikey.New(keysDB{})
+	read, _, err := keys.Create(context.Background(), "read", "", []apikey.Scope{apikey.ScopeRead})
+	require.NoError(t, err)
+	settings, _, err := keys.Create(context.Background(), "settings", "", []apikey.Scope{apikey.ScopeMinerSettings})
+	require.NoError(t, err)
+	admin, adminKey, err := keys.Create(context.Background(), "admin", "", []apikey.Scope{apikey.ScopeAdmin})
+	require.NoError(t, err)
+
+	s := &Server{apiKeys: keys}
+	app := fiber.New()
+	app.Use(s.identify())
+	app.Get("/read", s.scope(apikey.ScopeRead), func(c *fiber.Ctx) error {
+		return c.SendString(requestIdentity(c))
+	})
+	app.Get("/settings", s.scope(apikey.ScopeMinerSettings), func(c *fiber.Ctx) error {
+		return c.SendString(requestIdentity(c))
+	})
+	app.Get("/admin", s.scope(apikey.ScopeAdmin), func(c *fiber.Ctx) error {
+		return c.SendString(requestIdentity(c))
+	})
+
+	tests := []struct {
+		path    string
+		headers map[string]string
+		code    int
+	}{
+		{"/read", nil, fiber.StatusOK},
+		{"/read", map[string]string{headerAPIKey: read}, fiber.StatusOK},
+		{"/read", map[string]string{fiber.HeaderAuthorization: "Bearer " + read}, fiber.StatusOK},
+		{"/read", map[string]string{headerAPIKey: "invalid"}, fiber.StatusUnauthorized},
+		{"/read", map[string]string{headerAPIKey: settings}, fiber.StatusOK},
+		{"/settings", nil, fiber.StatusOK},
+		{"/settings", map[string]string{headerAPIKey: read}, fiber.StatusOK},
+		{"/settings", map[string]string{headerAPIKey: settings}, fiber.StatusOK},
+		{"/admin", nil, fiber.StatusUnauthorized},
+		{"/admin", map[string]string{headerAPIKey: read}, fiber.StatusForbidden},
+		{"/admin", map[string]string{headerAPIKey: admin}, fiber.StatusOK},
+		{"/settings", map[string]string{headerAPIKey: admin}, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		res, _ := testRequest(t, app, tt.path, tt.headers)
+		assert.Equal(t, tt.code, res.StatusCode, "%s %v", tt.path, tt.headers)
+	}
+
+	_, body := testRequest(t, app, "/admin", map[string]string{headerAPIKey: admin})
+	assert.Equal(t, "key:"+adminKey.ID, string(body))
+	// keys without the scope of the route are handled as anonymous requests
+	_, body = testRequest(t, app, "/settings", map[string]string{headerAPIKey: read})
+	assert.Equal(t, "ip:0.0.0.0", string(body))
+}
+
+func TestAuthLookupLimit(t *testing.T) {
+	db := &countingKeysDB{keysDB: keysDB{}}
+	keys := apikey.New(db)
+	valid, _, err := keys.Create(context.Background(), "read", "", []apikey.Scope{apikey.ScopeRead})
+	require.NoError(t, err)
+
+	s := &Server{
+		apiKeys: keys,
+		rateLimitCfg: rateLimitConfig(&config.RateLimit{
+			Enabled: true,
+			Tier: config.Tier{
+				Max:    100,
+				Window: time.Minute,
+				Groups: map[string]*config.Limit{rateLimitGroupAuth: {Max: 2, Window: time.Minute}},
+			},
+		}),
+		rateLimitStore: ratelimit.NewMemory(),
+	}
+	app := fiber.New()
+	app.Use(s.identify())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(requestIdentity(c))
+	})
+
+	// the valid key is cached after its lookup and doesn't count afterwards
+	for i := 0; i < 3; i++ {
+		res, _ := testRequest(t, app, "/", map[string]string{headerAPIKey: valid})
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	}
+	unknown := "phk_" + strings.Repeat("a", 16) + "_" + strings.Repeat("b", 64)
+	res, _ := testRequest(t, app, "/", map[string]string{headerAPIKey: unknown})
+	assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	// unknown keys are rejected before they are looked up once the IP exceeded its limit
+	res, _ = testRequest(t, app, "/", map[string]string{headerAPIKey: unknown})
+	assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+	assert.NotEmpty(t, res.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, 2, db.fetches)
+
+	// cached keys and anonymous requests aren't affected
+	res, _ = testRequest(t, app, "/", map[string]string{headerAPIKey: valid})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	res, _ = testRequest(t, app, "/", nil)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+}
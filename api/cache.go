@@ -0,0 +1,102 @@
+package api
+
+import (
+	"log"
+
+	"github.com/1oopio/phantomias/ws"
+	"github.com/goccy/go-json"
+)
+
+// names of the cached routes, used to configure the TTL per route
+const (
+	cacheRouteStats             = "stats"
+	cacheRoutePools             = "pools"
+	cacheRoutePool              = "pool"
+	cacheRouteBlocks            = "blocks"
+	cacheRoutePayments          = "payments"
+	cacheRoutePoolPerformance   = "pool_performance"
+	cacheRouteTopMiners         = "topminers"
+	cacheRouteLeaderboard       = "leaderboard"
+	cacheRouteMinerPerformance  = "miner_performance"
+	cacheRouteWorkerPerformance = "worker_performance"
+)
+
+// event contains the fields of a miningcore notification which are needed to invalidate the cache.
+type event struct {
+	Type   string `json:"type"`
+	PoolID string `json:"poolId"`
+}
+
+// InvalidateCache deletes the cached responses which are outdated by the given miningcore notification.
+// Blocks invalidate the pools, the pool, its blocks and leaderboard and the overall stats, payments additionally
+// invalidate its payments, top miners and the routes of its miners.
+func (s *Server) InvalidateCache(msg []byte) {
+	var ev event
+	if err := json.Unmarshal(msg, &ev); err != nil || ev.PoolID == "" {
+		return
+	}
+	pool := "/api/v1/pools/" + ev.PoolID
+
+	var prefixes []string
+	switch ev.Type {
+	case ws.EventBlockFound, ws.EventBlockUnlocked, ws.EventBlockUnlockProgress:
+		prefixes = []string{
+			"/api/v1/stats?",
+			"/api/v1/pools?",
+			pool + "?",
+			pool + "/blocks?",
+			pool + "/leaderboard?",
+		}
+	case ws.EventPayment:
+		// payments don't name their recipients, so the routes of all miners are invalidated
+		prefixes = []string{
+			"/api/v1/stats?",
+			"/api/v1/pools?",
+			pool + "?",
+			pool + "/payments?",
+			pool + "/topminers?",
+			pool + "/leaderboard?",
+			pool + "/miners/",
+		}
+	default:
+		return
+	}
+
+	// don't block the relay while talking to the cache backend
+	go func() {
+		if ev.Type == ws.EventPayment && s.scheduler != nil {
+			s.scheduler.RefreshPayments(ev.PoolID)
+		}
+		for _, prefix := range prefixes {
+			if err := s.cacheStorage.DeletePrefix(prefix); err != nil {
+				log.Printf("failed to invalidate the cache for %s: %v", prefix, err)
+			}
+		}
+	}()
+}
+
+// invalidateLeaderboards deletes the cached top miners and leaderboards of a pool, e.g. after a miner opted out of them.
+// The top miners of the scheduler are updated with its next refresh.
+func (s *Server) invalidateLeaderboards(poolID string) {
+	pool := "/api/v1/pools/" + poolID
+	for _, prefix := range []string{pool + "/topminers?", pool + "/leaderboard?"} {
+		if err := s.cacheStorage.DeletePrefix(prefix); err != nil {
+			log.Printf("failed to invalidate the cache for %s: %v", prefix, err)
+		}
+	}
+}
+
+// invalidateMiner deletes the cached responses of a miner and its workers, e.g. after the labels of a worker changed.
+// The address is invalidated as requested and as stored, they differ in case for ethereum pools.
+func (s *Server) invalidateMiner(poolID string, addrs ...string) {
+	var prefixes []string
+	for _, addr := range addrs {
+		miner := "/api/v1/pools/" + poolID + "/miners/" + addr
+		prefixes = append(prefixes, miner+"?", miner+"/")
+	}
+	for _, prefix := range prefixes {
+		if err := s.cacheStorage.DeletePrefix(prefix); err != nil {
+			log.Printf("failed to invalidate the cache for %s: %v", prefix, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/cache"
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/ws"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingStorage records the invalidated prefixes.
+type recordingStorage struct {
+	cache.Storage
+	mu       sync.Mutex
+	prefixes []string
+}
+
+func (r *recordingStorage) DeletePrefix(prefix string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefixes = append(r.prefixes, prefix)
+	return nil
+}
+
+func (r *recordingStorage) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.prefixes)
+}
+
+func (r *recordingStorage) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.prefixes
+	r.prefixes = nil
+	sort.Strings(p)
+	return p
+}
+
+func TestInvalidateCache(t *testing.T) {
+	storage := &recordingStorage{Storage: cache.NewMemory(cache.DefaultMaxBytes)}
+	s := &Server{cacheStorage: storage}
+
+	blockPrefixes := []string{
+		"/api/v1/pools/eth1/blocks?",
+		"/api/v1/pools/eth1/leaderboard?",
+		"/api/v1/pools/eth1?",
+		"/api/v1/pools?",
+		"/api/v1/stats?",
+	}
+	for _, typ := range []string{ws.EventBlockFound, ws.EventBlockUnlocked, ws.EventBlockUnlockProgress} {
+		s.InvalidateCache([]byte(`{"type":"` + typ + `","poolId":"eth1","blockHeight":100}`))
+		assert.Eventually(t, func() bool { return storage.count() == len(blockPrefixes) }, time.Second, time.Millisecond, typ)
+		assert.Equal(t, blockPrefixes, storage.take(), typ)
+	}
+
+	s.InvalidateCache([]byte(`{"type":"payment","poolId":"eth1","txIds":["0xabc"]}`))
+	paymentPrefixes := []string{
+		"/api/v1/pools/eth1/leaderboard?",
+		"/api/v1/pools/eth1/miners/",
+		"/api/v1/pools/eth1/payments?",
+		"/api/v1/pools/eth1/topminers?",
+		"/api/v1/pools/eth1?",
+		"/api/v1/pools?",
+		"/api/v1/stats?",
+	}
+	assert.Eventually(t, func() bool { return storage.count() == len(paymentPrefixes) }, time.Second, time.Millisecond)
+	assert.Equal(t, paymentPrefixes, storage.take())
+
+	// notifications which don't change cached responses
+	for _, msg := range []string{
+		`{"type":"` + ws.EventNewChainHeight + `","poolId":"eth1","blockHeight":101}`,
+		`{"type":"` + ws.EventHashrateUpdated + `","poolId":"eth1","miner":"0x1"}`,
+		`{"type":"greeting","message":"Connected to Miningcore notification relay"}`,
+		`{"type":"payment"}`,
+		`not json`,
+	} {
+		s.InvalidateCache([]byte(msg))
+	}
+	time.Sleep(time.Millisecond * 20)
+	assert.Empty(t, storage.take())
+}
+
+func TestInvalidateMiner(t *testing.T) {
+	storage := &recordingStorage{Storage: cache.NewMemory(cache.DefaultMaxBytes)}
+	s := &Server{cacheStorage: storage}
+
+	s.invalidateMiner("eth1", "0xabc", "0xABC")
+	assert.Equal(t, []string{
+		"/api/v1/pools/eth1/miners/0xABC/",
+		"/api/v1/pools/eth1/miners/0xABC?",
+		"/api/v1/pools/eth1/miners/0xabc/",
+		"/api/v1/pools/eth1/miners/0xabc?",
+	}, storage.take())
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	storage := cache.NewMemory(cache.DefaultMaxBytes)
+	defer storage.Close()
+	s := &Server{cfg: &config.API{CacheTTL: time.Minute}, cacheStorage: storage}
+
+	calls := 0
+	app := fiber.New()
+	app.Get("/api/v1/pools/:id", s.cache(cacheRoutePool), func(c *fiber.Ctx) error {
+		calls++
+		if calls == 1 {
+			return c.Status(fiber.StatusInternalServerError).SendString("db down")
+		}
+		return c.SendString("pool")
+	})
+
+	res, body := testRequest(t, app, "/api/v1/pools/eth1", nil)
+	assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, "db down", string(body))
+
+	// the error wasn't cached, the next response is
+	for i := 0; i < 2; i++ {
+		res, body = testRequest(t, app, "/api/v1/pools/eth1", nil)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "pool", string(body))
+	}
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// compress compresses responses with brotli or gzip, depending on the Accept-Encoding header.
+// Brotli is preferred. Responses smaller than minSize are sent uncompressed.
+func (s *Server) compress() fiber.Handler {
+	minSize := s.cfg.Compression.MinSize
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		res := c.Response()
+		if len(res.Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+			return nil
+		}
+		switch res.StatusCode() {
+		case fiber.StatusNoContent, fiber.StatusNotModified, fiber.StatusSwitchingProtocols:
+			return nil
+		}
+
+		body := res.Body()
+		if len(body) == 0 || len(body) < minSize {
+			return nil
+		}
+
+		switch {
+		case c.Request().Header.HasAcceptEncoding(encodingBrotli):
+			res.SetBodyRaw(fasthttp.AppendBrotliBytesLevel(nil, body, fasthttp.CompressBrotliDefaultCompression))
+			res.Header.Set(fiber.HeaderContentEncoding, encodingBrotli)
+		case c.Request().Header.HasAcceptEncoding(encodingGzip):
+			res.SetBodyRaw(fasthttp.AppendGzipBytesLevel(nil, body, fasthttp.CompressDefaultCompression))
+			res.Header.Set(fiber.HeaderContentEncoding, encodingGzip)
+		}
+		c.Vary(fiber.HeaderAcceptEncoding)
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	s := &Server{cfg: &config.API{Compression: &config.Compression{Enabled: true, MinSize: 100}}}
+	app := fiber.New()
+	app.Use(s.compress())
+	large := strings.Repeat("a", 200)
+	app.Get("/large", func(c *fiber.Ctx) error {
+		return c.SendString(large)
+	})
+	app.Get("/small", func(c *fiber.Ctx) error {
+		return c.SendString("a")
+	})
+
+	res, body := testRequest(t, app, "/large", map[string]string{fiber.HeaderAcceptEncoding: "gzip"})
+	assert.Equal(t, "gzip", res.Header.Get(fiber.HeaderContentEncoding))
+	assert.Equal(t, fiber.HeaderAcceptEncoding, res.Header.Get(fiber.HeaderVary))
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(plain))
+
+	res, body = testRequest(t, app, "/large", map[string]string{fiber.HeaderAcceptEncoding: "gzip, deflate, br"})
+	assert.Equal(t, "br", res.Header.Get(fiber.HeaderContentEncoding))
+	assert.Less(t, len(body), len(large))
+
+	res, body = testRequest(t, app, "/large", nil)
+	assert.Empty(t, res.Header.Get(fiber.HeaderContentEncoding))
+	assert.Equal(t, large, string(body))
+
+	res, body = testRequest(t, app, "/small", map[string]string{fiber.HeaderAcceptEncoding: "gzip"})
+	assert.Empty(t, res.Header.Get(fiber.HeaderContentEncoding))
+	assert.Equal(t, "a", string(body))
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var crc32q = crc32.MakeTable(0xD5828281)
+
+// conditional sets an ETag for successful GET responses and answers conditional
+// requests with 304 Not Modified if the client already has the current representation.
+// If-None-Match is checked against the ETag which is computed from the body.
+// If-Modified-Since is only checked if the handler set a Last-Modified header
+// and the request has no If-None-Match header.
+func (s *Server) conditional() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if (c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead) || c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		body := c.Response().Body()
+		if len(body) == 0 {
+			return nil
+		}
+		etag := fmt.Sprintf(`W/"%d-%08x"`, len(body), crc32.Checksum(body, crc32q))
+		c.Set(fiber.HeaderETag, etag)
+
+		if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+			if etagMatches(inm, etag) {
+				return notModified(c)
+			}
+			return nil
+		}
+
+		if ims := c.Get(fiber.HeaderIfModifiedSince); ims != "" {
+			lastModified, err := http.ParseTime(string(c.Response().Header.Peek(fiber.HeaderLastModified)))
+			if err != nil {
+				return nil
+			}
+			since, err := http.ParseTime(ims)
+			if err != nil {
+				return nil
+			}
+			if !lastModified.Truncate(time.Second).After(since) {
+				return notModified(c)
+			}
+		}
+		return nil
+	}
+}
+
+// etagMatches reports whether the If-None-Match header contains the given etag,
+// using the weak comparison as required for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func notModified(c *fiber.Ctx) error {
+	c.Status(fiber.StatusNotModified)
+	c.Context().ResetBody()
+	return nil
+}
+
+// setLastModified sets the Last-Modified header of the response.
+// Zero times are ignored.
+func setLastModified(c *fiber.Ctx, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	c.Set(fiber.HeaderLastModified, t.UTC().Format(http.TimeFormat))
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditional(t *testing.T) {
+	modified := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := &Server{}
+	app := fiber.New()
+	app.Use(s.conditional())
+	app.Get("/pool", func(c *fiber.Ctx) error {
+		setLastModified(c, modified)
+		return c.JSON(fiber.Map{"id": "eth1"})
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "test"})
+	})
+
+	do := func(path string, headers map[string]string) *http.Response {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := do("/pool", nil)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	etag := res.Header.Get(fiber.HeaderETag)
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, modified.Format(http.TimeFormat), res.Header.Get(fiber.HeaderLastModified))
+
+	res = do("/pool", map[string]string{fiber.HeaderIfNoneMatch: etag})
+	assert.Equal(t, fiber.StatusNotModified, res.StatusCode)
+	assert.Equal(t, int64(0), res.ContentLength)
+
+	res = do("/pool", map[string]string{fiber.HeaderIfNoneMatch: `"other", ` + etag[2:]})
+	assert.Equal(t, fiber.StatusNotModified, res.StatusCode)
+
+	res = do("/pool", map[string]string{fiber.HeaderIfNoneMatch: `"other"`})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	res = do("/pool", map[string]string{fiber.HeaderIfModifiedSince: modified.Format(http.TimeFormat)})
+	assert.Equal(t, fiber.StatusNotModified, res.StatusCode)
+
+	res = do("/pool", map[string]string{fiber.HeaderIfModifiedSince: modified.Add(-time.Second).Format(http.TimeFormat)})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	// If-None-Match takes precedence over If-Modified-Since
+	res = do("/pool", map[string]string{
+		fiber.HeaderIfNoneMatch:     `"other"`,
+		fiber.HeaderIfModifiedSince: modified.Format(http.TimeFormat),
+	})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	res = do("/error", map[string]string{fiber.HeaderIfNoneMatch: "*"})
+	assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	assert.Empty(t, res.Header.Get(fiber.HeaderETag))
+}
@@ -65,11 +65,11 @@ func (s *Server) getCSVDownloadHandler(c *fiber.Ctx) error {
 		var statsEntity []*database.PerformanceStatsEntity
 		var err error
 
+		interval := database.IntervalTenMinutes
 		if end.Sub(start) > duration.Week {
-			statsEntity, err = s.db.GetMinerPerformanceBetweenDaily(c.UserContext(), poolCfg.ID, addr, start, end)
-		} else {
-			statsEntity, err = s.db.GetMinerPerformanceBetweenTenMinutely(c.UserContext(), poolCfg.ID, addr, start, end)
+			interval = database.IntervalDay
 		}
+		statsEntity, err = s.db.GetMinerPerformanceBetween(c.UserContext(), poolCfg.ID, addr, interval, start, end)
 		if err != nil {
 			return utils.SendAPIError(c, fiber.StatusInternalServerError, err)
 		}
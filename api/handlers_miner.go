@@ -12,7 +12,7 @@ import (
 // @Summary Get a list of all miners
 // @Description Get a list of all miners from a specific pool
 // @Tags Miners
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson
 // @Param pool_id path string true "ID of the pool"
 // @Param page query int false "Page (default=0)"
 // @Param pageSize query int false "PageSize (default=15)"
@@ -48,7 +48,10 @@ func (s *Server) getMinersHandler(c *fiber.Ctx) error {
 		},
 		Result: dbMinersToAPIMiners(minersByHashrate),
 	}
-	return c.JSON(res)
+	if poolCfg.MaskAddresses {
+		maskMiners(res.Result)
+	}
+	return sendList(c, res, res.Meta, res.Result)
 }
 
 func dbMinersToAPIMiners(miners []database.MinerPerformanceStats) []MinerSimple {
@@ -65,6 +68,8 @@ func dbMinersToAPIMiners(miners []database.MinerPerformanceStats) []MinerSimple
 // @Produce json
 // @Param pool_id path string true "ID of the pool"
 // @Param miner_addr path string true "Address of the miner"
+// @Param group query string false "Group the workers into rigs by the part of their name before this separator, e.g. ."
+// @Param groupRegex query string false "Group the workers into rigs by the first group or the match of this regex"
 // @Success 200 {object} api.MinerRes
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/pools/{pool_id}/miners/{miner_addr} [get]
@@ -77,6 +82,10 @@ func (s *Server) getMinerHandler(c *fiber.Ctx) error {
 	if addr == "" {
 		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
 	}
+	grouping, err := getWorkerGroupingQuery(c)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
 
 	stats, err := s.db.GetMinerStats(c.UserContext(), poolCfg.ID, addr)
 	if err != nil {
@@ -93,6 +102,30 @@ func (s *Server) getMinerHandler(c *fiber.Ctx) error {
 		miner.LastPayment = &stats.LastPayment.Created
 		miner.LastPaymentLink = getTXLink(poolCfg.TxLink, stats.LastPayment.TransactionConfirmationData)
 	}
+	if miner.Performance != nil {
+		labels, err := s.db.GetWorkerLabels(c.UserContext(), poolCfg.ID, addr)
+		if err != nil {
+			return handleAPIError(c, fiber.StatusInternalServerError, err)
+		}
+		for name, w := range miner.Performance.Workers {
+			w.Labels = labels[name]
+		}
+		if grouping != nil {
+			miner.Rigs = grouping.groupWorkers(miner.Performance.Workers)
+		}
+	}
+	shares, err := s.getShareCounts(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	if shares != nil {
+		miner.Shares = sumShareCounts(shares)
+		if miner.Performance != nil {
+			for name, w := range miner.Performance.Workers {
+				w.Shares = shareCountsOf(shares, name)
+			}
+		}
+	}
 	miner.Prices = s.getPrices(poolCfg.Name)
 	miner.Coin = poolCfg.Coin
 	return c.JSON(&MinerRes{
@@ -300,6 +333,9 @@ func dbEarningsToAPI(earnings []*database.AmountByDate) []*DailyEarning {
 // @Param pool_id path string true "ID of the pool"
 // @Param miner_addr path string true "Address of the miner"
 // @Param perfMode query string Daily "Specify the sample range (default=day"
+// @Param start query string false "Start of the range in RFC3339, overwrites the perfMode"
+// @Param end query string false "End of the range in RFC3339, overwrites the perfMode"
+// @Param interval query string false "Interval of the samples (10m, 1h, 1d, 1w), chosen by the length of the range by default"
 // @Success 200 {object} api.MinerPerformanceRes
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/performance [get]
@@ -312,9 +348,13 @@ func (s *Server) getMinerPerformanceHandler(c *fiber.Ctx) error {
 	if addr == "" {
 		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
 	}
-	mode := getPerformanceModeQuery(c)
+	start, end := getPerformanceRange(getPerformanceModeQuery(c))
+	start, end, interval, err := s.getPerformanceQuery(c, start, end, "")
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
 
-	stats, err := s.getMinerPerformanceInternal(c.UserContext(), mode, poolCfg, addr)
+	stats, err := s.getMinerPerformanceInternal(c.UserContext(), start, end, interval, poolCfg, addr)
 	if err != nil {
 		return handleAPIError(c, fiber.StatusInternalServerError, err)
 	}
@@ -336,11 +376,40 @@ func (s *Server) getMinerPerformanceHandler(c *fiber.Ctx) error {
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/settings [get]
 func (s *Server) getMinerSettingsHandler(c *fiber.Ctx) error {
+	if s.nativeSettings() {
+		poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+		if poolCfg == nil {
+			return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+		}
+		addr := getMinerAddressParam(c, poolCfg)
+		if addr == "" {
+			return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+		}
+		settings, code, err := s.getMinerSettingsNative(c.UserContext(), poolCfg.ID, addr)
+		if err != nil {
+			return handleAPIError(c, code, err)
+		}
+		if settings.HideFromLeaderboards, err = s.getLeaderboardOptOut(c.UserContext(), poolCfg.ID, addr); err != nil {
+			return handleAPIError(c, fiber.StatusInternalServerError, err)
+		}
+		return c.JSON(&MinerSettingsRes{
+			Meta: &Meta{
+				Success: true,
+			},
+			Result: settings,
+		})
+	}
+
 	var settings MinerSettings
 	code, err := s.mc.UnmarshalMinerSettings(c.UserContext(), c.Params("id"), c.Params("miner_addr"), &settings)
 	if err != nil {
 		return handleAPIError(c, code, err)
 	}
+	if poolCfg := getPoolCfgByID(c.Params("id"), s.pools); poolCfg != nil {
+		if settings.HideFromLeaderboards, err = s.getLeaderboardOptOut(c.UserContext(), poolCfg.ID, getMinerAddressParam(c, poolCfg)); err != nil {
+			return handleAPIError(c, fiber.StatusInternalServerError, err)
+		}
+	}
 	return c.Status(code).JSON(&MinerSettingsRes{
 		Meta: &Meta{
 			Success: true,
@@ -350,29 +419,76 @@ func (s *Server) getMinerSettingsHandler(c *fiber.Ctx) error {
 }
 
 // @Summary Update settings
-// @Description Update the settings from a specific miner from a specific pool
+// @Description Update the settings from a specific miner from a specific pool.
+// @Description The ownership of the address is proven either by the IP of the highest worker
+// @Description or by the signature of the message of a nonce, see /settings/nonce.
 // @Tags Miners
 // @Produce json
 // @Param pool_id path string true "ID of the pool"
 // @Param miner_addr path string true "Address of the miner"
-// @Param settings body api.MinerSettingsReq true "Updated settings incl. the IP of the highest worker"
+// @Param settings body api.MinerSettingsReq true "Updated settings incl. the IP of the highest worker or the nonce and its signature"
 // @Success 200 {object} api.MinerSettingsRes
 // @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
 // @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/settings [post]
 func (s *Server) postMinerSettingsHandler(c *fiber.Ctx) error {
 	var req MinerSettingsReq
 	if err := c.BodyParser(&req); err != nil {
 		return handleAPIError(c, fiber.StatusBadRequest, err)
 	}
-	var settings MinerSettings
-	code, err := s.mc.UnmarshalPostMinerSettings(c.UserContext(), c.Params("id"), c.Params("miner_addr"), &req, &settings)
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	// miningcore verifies the IP address itself, so only signatures have to be verified by the proxy
+	proof := ownershipProofIP
+	if req.Signature != "" || s.nativeSettings() {
+		method, ip, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req.OwnershipProof)
+		if err != nil {
+			return handleAPIError(c, code, err)
+		}
+		req.IPAddress = ip
+		proof = method
+	}
+
+	old, err := s.previousPaymentThreshold(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	var (
+		settings *MinerSettings
+		code     int
+	)
+	if s.nativeSettings() {
+		settings, code, err = s.updateMinerSettingsNative(c.UserContext(), poolCfg, addr, &req)
+	} else {
+		settings = &MinerSettings{}
+		mcReq := &MinerSettingsReq{OwnershipProof: OwnershipProof{IPAddress: req.IPAddress}}
+		if req.Settings != nil {
+			// miningcore doesn't know the settings of phantomias
+			mcReq.Settings = &MinerSettings{PaymentThreshold: req.Settings.PaymentThreshold}
+		}
+		code, err = s.mc.UnmarshalPostMinerSettings(c.UserContext(), c.Params("id"), c.Params("miner_addr"), mcReq, settings)
+	}
 	if err != nil {
 		return handleAPIError(c, code, err)
 	}
+	s.recordSettingsChange(c, poolCfg.ID, addr, old, settings, proof)
+
+	if settings.HideFromLeaderboards, err = s.getLeaderboardOptOut(c.UserContext(), poolCfg.ID, addr); err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
 	return c.Status(code).JSON(&MinerSettingsRes{
 		Meta: &Meta{
 			Success: true,
 		},
-		Result: &settings,
+		Result: settings,
 	})
 }
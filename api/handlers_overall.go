@@ -1,6 +1,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/1oopio/phantomias/utils"
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,13 +15,27 @@ import (
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/stats [get]
 func (s *Server) getOverallPoolStatsHandler(c *fiber.Ctx) error {
+	if s.scheduler != nil {
+		if snapshot := s.scheduler.OverallStats(); snapshot != nil {
+			return c.JSON(&StatsRes{
+				Meta: &Meta{
+					Success:     true,
+					GeneratedAt: &snapshot.GeneratedAt,
+				},
+				Result: Stats(snapshot.Value),
+			})
+		}
+	}
+
+	generatedAt := time.Now()
 	stats, err := s.db.GetOverallPoolStats(c.UserContext())
 	if err != nil {
 		return utils.SendAPIError(c, fiber.StatusInternalServerError, err)
 	}
 	res := &StatsRes{
 		Meta: &Meta{
-			Success: true,
+			Success:     true,
+			GeneratedAt: &generatedAt,
 		},
 		Result: Stats(stats),
 	}
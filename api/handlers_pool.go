@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -22,17 +24,38 @@ import (
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/pools [get]
 func (s *Server) getPoolsHandler(c *fiber.Ctx) error {
-	result := make([]*Pool, 0)
+	enabled := make([]*config.Pool, 0, len(s.pools))
 	for _, p := range s.pools {
-		if !p.Enabled {
-			continue
+		if p.Enabled {
+			enabled = append(enabled, p)
 		}
-		pool, err := s.gatherPoolStats(c.UserContext(), p)
-		if err != nil {
-			return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	result := make([]*Pool, len(enabled))
+	fns := make([]func(ctx context.Context) error, len(enabled))
+	for i, p := range enabled {
+		i, p := i, p
+		fns[i] = func(ctx context.Context) error {
+			pool, err := s.gatherPoolStats(ctx, p)
+			if err != nil && ctx.Err() != nil {
+				return err
+			}
+			result[i] = pool
+			return nil
+		}
+	}
+	if err := gather(c.UserContext(), s.maxParallelQueries(), fns...); err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	// the pools are refreshed whenever miningcore writes new stats
+	var lastModified time.Time
+	for _, p := range result {
+		if p.updated.After(lastModified) {
+			lastModified = p.updated
 		}
-		result = append(result, pool)
 	}
+	setLastModified(c, lastModified)
 
 	return c.JSON(&PoolsRes{
 		Meta: &Meta{
@@ -42,28 +65,38 @@ func (s *Server) getPoolsHandler(c *fiber.Ctx) error {
 	})
 }
 
+// gatherPoolStats returns the pool with its latest stats.
+// If the stats can't be loaded, the pool is still returned with an error marker.
 func (s *Server) gatherPoolStats(ctx context.Context, p *config.Pool) (*Pool, error) {
-	var pool Pool
+	pool := &Pool{
+		ID:        p.ID,
+		Algorithm: p.Algorithm,
+		Name:      p.Name,
+		Coin:      p.Coin,
+		Fee:       p.Fee,
+		FeeType:   p.FeeType,
+		Prices:    s.getPrices(p.Name),
+	}
+
 	stats, err := s.db.GetLastPoolStats(ctx, p.ID)
 	if err != nil {
-		return nil, err
-	}
-	pool.ID = stats.PoolID
-	pool.Algorithm = p.Algorithm
-	pool.Name = p.Name
-	pool.Coin = p.Coin
-	pool.Fee = p.Fee
-	pool.FeeType = p.FeeType
+		if errors.Is(err, sql.ErrNoRows) {
+			pool.Error = utils.ErrNoStatsFound.Error()
+		} else {
+			log.Printf("error getting pool stats of %s: %v", p.ID, err)
+			pool.Error = utils.ErrStatsUnavailable.Error()
+		}
+		return pool, err
+	}
 	pool.Miners = stats.ConnectedMiners
 	pool.Workers = stats.ConnectedWorkers
 	pool.Hashrate = stats.PoolHashrate
 	pool.BlockHeight = stats.BlockHeight
 	pool.NetworkHashrate = stats.NetworkHashrate
 	pool.NetworkDifficulty = stats.NetworkDifficulty
+	pool.updated = stats.Created
 
-	pool.Prices = s.getPrices(p.Name)
-
-	return &pool, nil
+	return pool, nil
 }
 
 func (s Server) getPrices(name string) (priceRes map[string]Price) {
@@ -94,49 +127,65 @@ func (s *Server) getPoolHandler(c *fiber.Ctx) error {
 		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
 	}
 
-	poolStats, err := s.gatherPoolStats(c.UserContext(), poolCfg)
-	if err != nil {
-		return handleAPIError(c, fiber.StatusInternalServerError, err)
-	}
-
 	poolExtended := PoolExtended{
 		Type:      poolCfg.Type,
-		Pool:      poolStats,
 		Address:   poolCfg.Address,
 		MinPayout: poolCfg.MinPayout,
 	}
 
-	totalPaid, err := s.db.GetTotalPoolPayments(c.UserContext(), poolCfg.ID)
-	if err != nil {
-		log.Printf("error getting total pool payments: %v", err)
-	}
-	poolExtended.TotalPayments = totalPaid.InexactFloat64()
-
-	totalBlocks, err := s.db.GetPoolBlockCount(c.UserContext(), poolCfg.ID)
-	if err != nil {
-		log.Printf("error getting total pool blocks: %v", err)
-		// return handleAPIError(c, fiber.StatusInternalServerError, err)
-	}
-	poolExtended.TotalBlocksFound = totalBlocks
-
-	lastPoolBlockTime, err := s.db.GetLastPoolBlockTime(c.UserContext(), poolCfg.ID)
-	if err != nil {
-		log.Printf("error getting last pool block time: %v", err)
-		//return handleAPIError(c, fiber.StatusInternalServerError, err)
-	}
-	poolExtended.LastBlockFoundTime = lastPoolBlockTime
-
-	avgEffort, err := s.db.GetPoolEffort(c.UserContext(), poolCfg.ID, effortRange)
-	if err != nil {
-		return handleAPIError(c, fiber.StatusInternalServerError, err)
-	}
-	poolExtended.AverageEffort = avgEffort
-
-	currentEffort, err := s.db.GetEffortBetweenCreated(c.UserContext(), poolCfg.ID, poolCfg.ShareMultiplier, lastPoolBlockTime, time.Now())
+	// every query writes to its own fields of poolExtended
+	err := gather(c.UserContext(), s.maxParallelQueries(),
+		func(ctx context.Context) error {
+			pool, err := s.gatherPoolStats(ctx, poolCfg)
+			if err != nil && ctx.Err() != nil {
+				return err
+			}
+			poolExtended.Pool = pool
+			return nil
+		},
+		func(ctx context.Context) error {
+			totalPaid, err := s.db.GetTotalPoolPayments(ctx, poolCfg.ID)
+			if err != nil {
+				log.Printf("error getting total pool payments: %v", err)
+			}
+			poolExtended.TotalPayments = totalPaid.InexactFloat64()
+			return nil
+		},
+		func(ctx context.Context) error {
+			totalBlocks, err := s.db.GetPoolBlockCount(ctx, poolCfg.ID)
+			if err != nil {
+				log.Printf("error getting total pool blocks: %v", err)
+			}
+			poolExtended.TotalBlocksFound = totalBlocks
+			return nil
+		},
+		func(ctx context.Context) error {
+			avgEffort, err := s.db.GetPoolEffort(ctx, poolCfg.ID, effortRange)
+			if err != nil {
+				return err
+			}
+			poolExtended.AverageEffort = avgEffort
+			return nil
+		},
+		func(ctx context.Context) error {
+			// the current effort depends on the time of the last block
+			lastPoolBlockTime, err := s.db.GetLastPoolBlockTime(ctx, poolCfg.ID)
+			if err != nil {
+				log.Printf("error getting last pool block time: %v", err)
+			}
+			poolExtended.LastBlockFoundTime = lastPoolBlockTime
+
+			currentEffort, err := s.db.GetEffortBetweenCreated(ctx, poolCfg.ID, poolCfg.ShareMultiplier, lastPoolBlockTime, time.Now())
+			if err != nil {
+				return err
+			}
+			poolExtended.Effort = utils.ValueOrZero(currentEffort)
+			return nil
+		},
+	)
 	if err != nil {
 		return handleAPIError(c, fiber.StatusInternalServerError, err)
 	}
-	poolExtended.Effort = utils.ValueOrZero(currentEffort)
 
 	res := &PoolExtendedRes{
 		Meta: &Meta{
@@ -146,6 +195,7 @@ func (s *Server) getPoolHandler(c *fiber.Ctx) error {
 	}
 	res.Result.Ports = cfgPortsToAPIPoolPorts(poolCfg.Ports)
 	res.Result.Prices = s.getPrices(res.Result.Name)
+	setLastModified(c, poolExtended.updated)
 	return c.JSON(res)
 }
 
@@ -174,7 +224,7 @@ type BlocksParams struct {
 // @Summary Get a list of blocks
 // @Description Get a list of blocks from a specific pool
 // @Tags Pools
-// @Produce  json
+// @Produce  json,text/csv,application/x-ndjson
 // @Param pool_id path string true "ID of the pool"
 // @Param page query int false "Page (default=0)"
 // @Param pageSize query int false "PageSize (default=15)"
@@ -216,7 +266,10 @@ func (s *Server) getBlocksHandler(c *fiber.Ctx) error {
 		},
 		Result: dbBlocksToAPIBlocks(pool, blocks),
 	}
-	return c.JSON(res)
+	if pool.MaskAddresses {
+		maskBlocks(res.Result)
+	}
+	return sendList(c, res, res.Meta, res.Result)
 }
 
 func dbBlocksToAPIBlocks(p *config.Pool, b []*database.Block) []*Block {
@@ -248,7 +301,7 @@ func dbBlockToAPIBlock(p *config.Pool, b *database.Block) *Block {
 // @Summary Get a list of payments
 // @Description Get a list of payments from a specific pool
 // @Tags Pools
-// @Produce  json
+// @Produce  json,text/csv,application/x-ndjson
 // @Param pool_id path string true "ID of the pool"
 // @Param page query int false "Page (default=0)"
 // @Param pageSize query int false "PageSize (default=15)"
@@ -281,7 +334,10 @@ func (s *Server) getPaymentsHandler(c *fiber.Ctx) error {
 		},
 		Result: dbPaymentsToAPIPayments(pool, payments),
 	}
-	return c.JSON(res)
+	if pool.MaskAddresses {
+		maskPayments(res.Result)
+	}
+	return sendList(c, res, res.Meta, res.Result)
 }
 
 func dbPaymentsToAPIPayments(p *config.Pool, pmts []*database.Payment) []*Payment {
@@ -311,12 +367,18 @@ func dbPaymentToAPIPayment(p *config.Pool, pmt *database.Payment) *Payment {
 // @Param pool_id path string true "ID of the pool"
 // @Param i query string false "sample interval (default=Hour)"
 // @Param r query string false "sample range (default=Day)"
+// @Param start query string false "Start of the range in RFC3339, overwrites the sample range"
+// @Param end query string false "End of the range in RFC3339, overwrites the sample range"
+// @Param interval query string false "Interval of the samples (10m, 1h, 1d, 1w), overwrites the sample interval"
 // @Success 200 {object} api.PoolPerformanceRes
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/pools/{pool_id}/performance [get]
 func (s *Server) getPoolPerformanceHandler(c *fiber.Ctx) error {
 	performanceRange := c.Query("r", string(database.RangeDay))
-	performanceInterval := c.Query("i", string(database.IntervalHour))
+	performanceInterval := database.IntervalHour
+	if database.SampleInterval(c.Query("i")) == database.IntervalDay {
+		performanceInterval = database.IntervalDay
+	}
 
 	pool := getPoolCfgByID(c.Params("id"), s.pools)
 	if pool == nil {
@@ -324,31 +386,54 @@ func (s *Server) getPoolPerformanceHandler(c *fiber.Ctx) error {
 	}
 
 	end := time.Now()
-	var start time.Time
-
-	switch database.SampleRange(performanceRange) {
-	case database.RangeHour:
-		start = end.Add(-1 * time.Hour)
-	case database.RangeDay:
-		start = end.Add(-24 * time.Hour)
-	case database.RangeMonth:
-		start = end.Add(-30 * 24 * time.Hour)
-	default:
+	start, err := database.SampleRange(performanceRange).Start(end)
+	if err != nil {
 		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidRange)
 	}
 
-	stats, err := s.db.GetPoolPerformanceBetween(c.UserContext(), pool.ID, database.SampleInterval(performanceInterval), start, end)
+	// the precomputed samples only cover the predefined ranges
+	custom := c.Query("start") != "" || c.Query("end") != "" || c.Query("interval") != ""
+	if s.scheduler != nil && !custom {
+		snapshot := s.scheduler.PoolPerformance(pool.ID, database.SampleRange(performanceRange), performanceInterval)
+		if snapshot != nil {
+			start, _ := database.SampleRange(performanceRange).Start(snapshot.GeneratedAt)
+			stats := fillPoolPerformanceGaps(snapshot.Value, start, snapshot.GeneratedAt, performanceInterval)
+			return c.JSON(&PoolPerformanceRes{
+				Meta: &Meta{
+					Success:     true,
+					GeneratedAt: &snapshot.GeneratedAt,
+				},
+				Result: dbPoolPerformanceToAPIPerformance(stats),
+			})
+		}
+	}
+
+	start, end, interval, err := s.getPerformanceQuery(c, start, end, performanceInterval)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	stats, err := s.db.GetPoolPerformanceBetween(c.UserContext(), pool.ID, interval, start, end)
 	if err != nil {
 		return handleAPIError(c, fiber.StatusInternalServerError, err)
 	}
+	now := time.Now()
 	return c.JSON(&PoolPerformanceRes{
 		Meta: &Meta{
-			Success: true,
+			Success:     true,
+			GeneratedAt: &now,
 		},
-		Result: dbPoolPerformanceToAPIPerformance(stats),
+		Result: dbPoolPerformanceToAPIPerformance(fillPoolPerformanceGaps(stats, start, end, interval)),
 	})
 }
 
+func fillPoolPerformanceGaps(stats []*database.AggregatedPoolStats, start, end time.Time, interval database.SampleInterval) []*database.AggregatedPoolStats {
+	return fillPerformanceGaps(stats,
+		func(s *database.AggregatedPoolStats) time.Time { return s.Created },
+		func(t time.Time) *database.AggregatedPoolStats { return &database.AggregatedPoolStats{Created: t} },
+		start, end, interval,
+	)
+}
+
 func dbPoolPerformanceToAPIPerformance(stats []*database.AggregatedPoolStats) []*PoolPerformance {
 	perfStats := make([]*PoolPerformance, len(stats))
 	for i, stat := range stats {
@@ -375,24 +460,41 @@ func (s *Server) getTopMinersHandler(c *fiber.Ctx) error {
 		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
 	}
 
-	from := time.Now().Add(-time.Duration(topMinersRange) * time.Hour)
+	if s.scheduler != nil {
+		if snapshot := s.scheduler.TopMiners(pool.ID, topMinersRange); snapshot != nil {
+			return c.JSON(&TopMinersRes{
+				Meta: &Meta{
+					Success:     true,
+					GeneratedAt: &snapshot.GeneratedAt,
+				},
+				Result: dbTopMinersToAPITopMiner(pool, snapshot.Value),
+			})
+		}
+	}
+
+	generatedAt := time.Now()
+	from := generatedAt.Add(-time.Duration(topMinersRange) * time.Hour)
 	stats, err := s.db.GetTopMinerStats(c.UserContext(), pool.ID, from, 0, 15)
 	if err != nil {
 		return handleAPIError(c, fiber.StatusInternalServerError, err)
 	}
 	return c.JSON(&TopMinersRes{
 		Meta: &Meta{
-			Success: true,
+			Success:     true,
+			GeneratedAt: &generatedAt,
 		},
-		Result: dbTopMinersToAPITopMiner(stats),
+		Result: dbTopMinersToAPITopMiner(pool, stats),
 	})
 }
 
-func dbTopMinersToAPITopMiner(stats []*database.TopMinerStats) []*TopMiner {
+func dbTopMinersToAPITopMiner(p *config.Pool, stats []*database.TopMinerStats) []*TopMiner {
 	topMiners := make([]*TopMiner, len(stats))
 	for i, stat := range stats {
 		s := TopMiner(*stat)
 		topMiners[i] = &s
 	}
+	if p.MaskAddresses {
+		maskTopMiners(topMiners)
+	}
 	return topMiners
 }
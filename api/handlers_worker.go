@@ -1,11 +1,18 @@
 package api
 
 import (
+	"time"
+
 	"github.com/1oopio/phantomias/database"
 	"github.com/1oopio/phantomias/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	defaultWorkersWindow    = time.Hour * 24
+	defaultWorkersMaxWindow = time.Hour * 24 * 30
+)
+
 // @Summary Get a worker
 // @Description Get a specific worker from a specific miner from a specific pool
 // @Tags Workers
@@ -34,11 +41,24 @@ func (s *Server) getWorkerHandler(c *fiber.Ctx) error {
 	if err != nil {
 		return handleAPIError(c, 0, err)
 	}
+	labels, err := s.db.GetWorkerLabels(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	shares, err := s.getShareCounts(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	res := dbWorkerToAPIWorker(&stats)
+	res.Labels = labels[worker]
+	if shares != nil {
+		res.Shares = shareCountsOf(shares, worker)
+	}
 	return c.JSON(&WorkerRes{
 		Meta: &Meta{
 			Success: true,
 		},
-		Result: dbWorkerToAPIWorker(&stats),
+		Result: res,
 	})
 }
 
@@ -57,6 +77,9 @@ func dbWorkerToAPIWorker(w *database.WorkerStats) *Worker {
 // @Param miner_addr path string true "Address of the miner"
 // @Param worker_name path string true "Name of the worker"
 // @Param perfMode query string Daily "Specify the sample range (default=day"
+// @Param start query string false "Start of the range in RFC3339, overwrites the perfMode"
+// @Param end query string false "End of the range in RFC3339, overwrites the perfMode"
+// @Param interval query string false "Interval of the samples (10m, 1h, 1d, 1w), chosen by the length of the range by default"
 // @Success 200 {object} api.WorkerPerformanceRes
 // @Failure 400 {object} utils.APIError
 // @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}/performance [get]
@@ -73,9 +96,13 @@ func (s *Server) getWorkerPerformanceHandler(c *fiber.Ctx) error {
 	if worker == "" {
 		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidWorkerName)
 	}
-	mode := getPerformanceModeQuery(c)
+	start, end := getPerformanceRange(getPerformanceModeQuery(c))
+	start, end, interval, err := s.getPerformanceQuery(c, start, end, "")
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
 
-	stats, err := s.getWorkerPerformanceInternal(c.UserContext(), mode, poolCfg, addr, worker)
+	stats, err := s.getWorkerPerformanceInternal(c.UserContext(), start, end, interval, poolCfg, addr, worker)
 	if err != nil {
 		return handleAPIError(c, fiber.StatusInternalServerError, err)
 	}
@@ -86,3 +113,101 @@ func (s *Server) getWorkerPerformanceHandler(c *fiber.Ctx) error {
 		Result: stats,
 	})
 }
+
+// @Summary Get the workers of a miner
+// @Description Get all workers which were seen within a window from a specific miner from a specific pool.
+// @Description A worker is online if it submitted shares or had a hashrate recently.
+// @Tags Workers
+// @Produce json,text/csv,application/x-ndjson
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param window query string false "Window in which the workers were seen, e.g. 1h or 168h (default=24h)"
+// @Param group query string false "Assign the workers to rigs by the part of their name before this separator, e.g. ."
+// @Param groupRegex query string false "Assign the workers to rigs by the first group or the match of this regex"
+// @Success 200 {object} api.WorkersRes
+// @Failure 400 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/workers [get]
+func (s *Server) getWorkersHandler(c *fiber.Ctx) error {
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+	window, err := s.getWorkersWindowQuery(c)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	grouping, err := getWorkerGroupingQuery(c)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+
+	now := time.Now()
+	workers, err := s.db.GetWorkersActivity(c.UserContext(), poolCfg.ID, addr, now.Add(-window))
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	labels, err := s.db.GetWorkerLabels(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	res := &WorkersRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: dbWorkersToAPIWorkers(workers, now),
+	}
+	for _, w := range res.Result {
+		w.Labels = labels[w.Name]
+		if grouping != nil {
+			w.Rig = grouping.rig(w.Name)
+		}
+	}
+	return sendList(c, res, res.Meta, res.Result)
+}
+
+// getWorkersWindowQuery returns the window of the worker list, limited to the configured max window.
+func (s *Server) getWorkersWindowQuery(c *fiber.Ctx) (time.Duration, error) {
+	window, maxWindow := defaultWorkersWindow, defaultWorkersMaxWindow
+	if s.cfg != nil && s.cfg.Workers != nil {
+		if s.cfg.Workers.Window > 0 {
+			window = s.cfg.Workers.Window
+		}
+		if s.cfg.Workers.MaxWindow > 0 {
+			maxWindow = s.cfg.Workers.MaxWindow
+		}
+	}
+	if q := c.Query("window"); q != "" {
+		d, err := time.ParseDuration(q)
+		if err != nil || d <= 0 {
+			return 0, utils.ErrInvalidRange
+		}
+		window = d
+	}
+	if window > maxWindow {
+		window = maxWindow
+	}
+	return window, nil
+}
+
+func dbWorkersToAPIWorkers(workers []*database.WorkerActivity, now time.Time) []*WorkerStatus {
+	res := make([]*WorkerStatus, len(workers))
+	for i, w := range workers {
+		res[i] = &WorkerStatus{
+			Name:             w.Worker,
+			Hashrate:         utils.ValueOrZero(w.Hashrate),
+			AverageHashrate:  utils.ValueOrZero(w.AverageHashrate),
+			ReportedHashrate: utils.ValueOrZero(w.ReportedHashrate),
+			SharesPerSecond:  utils.ValueOrZero(w.SharesPerSecond),
+			UserAgent:        utils.ValueOrZero(w.UserAgent),
+		}
+		if w.LastSeen != nil {
+			res[i].LastSeen = *w.LastSeen
+			res[i].Online = now.Sub(*w.LastSeen) <= database.MinerStatsMaxAge
+		}
+	}
+	return res
+}
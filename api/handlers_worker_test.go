@@ -0,0 +1,64 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkersToAPIWorkers(t *testing.T) {
+	now := time.Now()
+	recent, old := now.Add(-time.Minute), now.Add(-time.Hour)
+	hashrate, ua := 100.0, "lolMiner 1.52"
+	workers := dbWorkersToAPIWorkers([]*database.WorkerActivity{
+		{Worker: "rig1", Hashrate: &hashrate, AverageHashrate: &hashrate, LastSeen: &recent, UserAgent: &ua},
+		{Worker: "rig2", LastSeen: &old},
+		{Worker: "rig3"},
+	}, now)
+
+	require.Len(t, workers, 3)
+	assert.Equal(t, "rig1", workers[0].Name)
+	assert.True(t, workers[0].Online)
+	assert.Equal(t, 100.0, workers[0].Hashrate)
+	assert.Equal(t, ua, workers[0].UserAgent)
+	assert.False(t, workers[1].Online)
+	assert.Equal(t, old, workers[1].LastSeen)
+	assert.False(t, workers[2].Online)
+	assert.True(t, workers[2].LastSeen.IsZero())
+}
+
+func TestWorkersWindowQuery(t *testing.T) {
+	s := &Server{cfg: &config.API{Workers: &config.Workers{Window: time.Hour, MaxWindow: time.Hour * 48}}}
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		window, err := s.getWorkersWindowQuery(c)
+		if err != nil {
+			return handleAPIError(c, fiber.StatusBadRequest, err)
+		}
+		return c.SendString(window.String())
+	})
+
+	tests := []struct {
+		query string
+		code  int
+		body  string
+	}{
+		{"", fiber.StatusOK, "1h0m0s"},
+		{"?window=6h", fiber.StatusOK, "6h0m0s"},
+		{"?window=720h", fiber.StatusOK, "48h0m0s"},
+		{"?window=-1h", fiber.StatusBadRequest, ""},
+		{"?window=abc", fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		res, body := testRequest(t, app, "/"+tt.query, nil)
+		assert.Equal(t, tt.code, res.StatusCode, tt.query)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, string(body), tt.query)
+		}
+	}
+}
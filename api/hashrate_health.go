@@ -0,0 +1,178 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// states of the hashrate of a worker
+const (
+	hashrateHealthy        = "healthy"
+	hashrateUnderperformed = "underperforming" // e.g. stale shares, unstable overclocks or dev fee leakage
+	hashrateOverperformed  = "overperforming"  // e.g. wrong reported hashrate
+	hashrateUnreported     = "unreported"
+	hashrateInsufficient   = "insufficientData"
+)
+
+const (
+	defaultHealthThreshold = 10.0 // percent
+	// min share of the samples which have to deviate to flag a worker
+	healthPersistence = 0.5
+	// min samples with an effective and a reported hashrate to flag a worker
+	minHealthSamples = 3
+)
+
+var errInvalidThreshold = errors.New("threshold has to be a percentage between 0 and 100")
+
+// @Summary Get the hashrate health of the workers of a miner
+// @Description Compare the effective and the reported hashrate of all workers of a specific miner from a specific pool within a window.
+// @Description Workers are flagged if most of their samples deviate by more than the threshold, flagged workers with the largest deviation come first.
+// @Tags Workers
+// @Produce json,text/csv,application/x-ndjson
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param window query string false "Window which is analyzed, e.g. 6h or 168h (default=24h)"
+// @Param threshold query number false "Max deviation of the effective from the reported hashrate in percent (default=10)"
+// @Success 200 {object} api.WorkersHealthRes
+// @Failure 400 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/health [get]
+func (s *Server) getWorkersHealthHandler(c *fiber.Ctx) error {
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+	window, err := s.getWorkersWindowQuery(c)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	threshold, err := getHealthThresholdQuery(c)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+
+	end := time.Now()
+	samples, err := s.db.GetWorkersPerformanceBetween(c.UserContext(), poolCfg.ID, addr, autoPerformanceInterval(window), end.Add(-window), end)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	res := &WorkersHealthRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: analyzeWorkersHealth(samples, threshold/100),
+	}
+	return sendList(c, res, res.Meta, res.Result)
+}
+
+func getHealthThresholdQuery(c *fiber.Ctx) (float64, error) {
+	v := c.Query("threshold")
+	if v == "" {
+		return defaultHealthThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(v, 64)
+	if err != nil || threshold <= 0 || threshold > 100 {
+		return 0, errInvalidThreshold
+	}
+	return threshold, nil
+}
+
+// analyzeWorkersHealth compares the effective and the reported hashrate of the workers.
+// The threshold is the max relative deviation, e.g. 0.1 for 10%.
+func analyzeWorkersHealth(samples []*database.WorkerPerformanceSample, threshold float64) []*WorkerHealth {
+	type stats struct {
+		hashrate, reported      float64 // sums of all samples
+		hashrates, reports      int
+		comparedHs, comparedRhs float64 // sums of the samples with both hashrates
+		compared, below, above  int
+	}
+	byWorker := make(map[string]*stats)
+	for _, s := range samples {
+		st, ok := byWorker[s.Worker]
+		if !ok {
+			st = &stats{}
+			byWorker[s.Worker] = st
+		}
+		if s.Hashrate != nil {
+			st.hashrate += *s.Hashrate
+			st.hashrates++
+		}
+		if s.ReportedHashrate != nil {
+			st.reported += *s.ReportedHashrate
+			st.reports++
+		}
+		if s.Hashrate == nil || s.ReportedHashrate == nil || *s.ReportedHashrate <= 0 {
+			continue
+		}
+		st.comparedHs += *s.Hashrate
+		st.comparedRhs += *s.ReportedHashrate
+		st.compared++
+		switch deviation := *s.Hashrate / *s.ReportedHashrate - 1; {
+		case deviation < -threshold:
+			st.below++
+		case deviation > threshold:
+			st.above++
+		}
+	}
+
+	res := make([]*WorkerHealth, 0, len(byWorker))
+	for worker, st := range byWorker {
+		h := &WorkerHealth{
+			Name:    worker,
+			Samples: st.compared,
+			Status:  hashrateHealthy,
+		}
+		if st.hashrates > 0 {
+			h.Hashrate = st.hashrate / float64(st.hashrates)
+		}
+		if st.reports > 0 {
+			h.ReportedHashrate = st.reported / float64(st.reports)
+		}
+		switch {
+		case st.reports == 0:
+			h.Status = hashrateUnreported
+		case st.compared < minHealthSamples:
+			h.Status = hashrateInsufficient
+		}
+		if st.compared > 0 {
+			h.Deviation = st.comparedHs/st.comparedRhs - 1
+		}
+		if st.compared >= minHealthSamples {
+			persistent := float64(st.compared) * healthPersistence
+			switch {
+			case h.Deviation < -threshold && float64(st.below) >= persistent:
+				h.Status = hashrateUnderperformed
+				h.DeviatingSamples = st.below
+				h.Flagged = true
+			case h.Deviation > threshold && float64(st.above) >= persistent:
+				h.Status = hashrateOverperformed
+				h.DeviatingSamples = st.above
+				h.Flagged = true
+			default:
+				h.DeviatingSamples = st.below + st.above
+			}
+		}
+		res = append(res, h)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Flagged != res[j].Flagged {
+			return res[i].Flagged
+		}
+		if di, dj := math.Abs(res[i].Deviation), math.Abs(res[j].Deviation); di != dj {
+			return di > dj
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeWorkersHealth(t *testing.T) {
+	now := time.Now()
+	var samples []*database.WorkerPerformanceSample
+	add := func(worker string, hashrates ...float64) {
+		for i := 0; i+1 < len(hashrates); i += 2 {
+			s := &database.WorkerPerformanceSample{Worker: worker, Created: now.Add(time.Duration(i) * time.Hour)}
+			if hashrates[i] >= 0 {
+				s.Hashrate = &hashrates[i]
+			}
+			if hashrates[i+1] >= 0 {
+				s.ReportedHashrate = &hashrates[i+1]
+			}
+			samples = append(samples, s)
+		}
+	}
+	add("healthy", 95, 100, 105, 100, 100, 100, 98, 100)
+	add("stale", 70, 100, 80, 100, 75, 100, 100, 100)
+	add("spike", 100, 100, 100, 100, 100, 100, 300, 100) // one outlier isn't persistent
+	add("over", 150, 100, 160, 100, 140, 100)
+	add("noreport", 100, -1, 100, -1)
+	add("new", 50, 100)
+
+	res := analyzeWorkersHealth(samples, 0.1)
+	require.Len(t, res, 6)
+	byName := make(map[string]*WorkerHealth)
+	names := make([]string, len(res))
+	for i, h := range res {
+		byName[h.Name] = h
+		names[i] = h.Name
+	}
+	assert.Equal(t, []string{"over", "stale", "new", "spike", "healthy", "noreport"}, names)
+
+	assert.Equal(t, hashrateOverperformed, byName["over"].Status)
+	assert.True(t, byName["over"].Flagged)
+	assert.InDelta(t, 0.5, byName["over"].Deviation, 0.0001)
+
+	assert.Equal(t, hashrateUnderperformed, byName["stale"].Status)
+	assert.True(t, byName["stale"].Flagged)
+	assert.Equal(t, 4, byName["stale"].Samples)
+	assert.Equal(t, 3, byName["stale"].DeviatingSamples)
+	assert.InDelta(t, -0.1875, byName["stale"].Deviation, 0.0001)
+
+	assert.Equal(t, hashrateHealthy, byName["spike"].Status)
+	assert.False(t, byName["spike"].Flagged)
+	assert.Equal(t, hashrateHealthy, byName["healthy"].Status)
+	assert.Equal(t, hashrateUnreported, byName["noreport"].Status)
+	assert.Equal(t, 100.0, byName["noreport"].Hashrate)
+	assert.Equal(t, hashrateInsufficient, byName["new"].Status)
+	assert.False(t, byName["new"].Flagged)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/1oopio/phantomias/ws"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	healthStatusOK       = "ok"
+	healthStatusDegraded = "degraded"
+)
+
+// @Summary Health check
+// @Description Get the health of the api and its miningcore websocket upstreams
+// @Tags Health
+// @Produce json
+// @Success 200 {object} api.HealthRes
+// @Router /health [get]
+func (s *Server) healthHandler(c *fiber.Ctx) error {
+	res := &HealthRes{
+		Status: healthStatusOK,
+	}
+	if s.upstreams != nil {
+		status := s.upstreams.Status()
+		res.Upstreams = wsStatusToAPIUpstreamHealth(status)
+		for _, u := range status {
+			if !u.Connected {
+				res.Status = healthStatusDegraded
+			}
+		}
+	}
+	return c.JSON(res)
+}
+
+func wsStatusToAPIUpstreamHealth(status []*ws.Status) []*UpstreamHealth {
+	res := make([]*UpstreamHealth, len(status))
+	for i, s := range status {
+		res[i] = &UpstreamHealth{
+			Name:        s.Name,
+			State:       s.State.String(),
+			Connected:   s.Connected,
+			Since:       s.Since,
+			LastMessage: s.LastMessage,
+			LastError:   s.LastError,
+			Received:    s.Received,
+			Duplicates:  s.Duplicates,
+			Reconnects:  s.Reconnects,
+		}
+	}
+	return res
+}
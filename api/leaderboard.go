@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultLeaderboardWindow = "24h"
+	leaderboardMaxPageSize   = 100
+)
+
+// leaderboardWindows are the windows of the leaderboard by their query value.
+var leaderboardWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": time.Hour * 24,
+	"7d":  time.Hour * 24 * 7,
+	"30d": time.Hour * 24 * 30,
+}
+
+var errInvalidLeaderboardCriterion = errors.New("invalid ranking criterion, use hashrate, blocks, paid or uptime")
+
+// @Summary Get the leaderboard of a pool
+// @Description Get the miners of a specific pool ranked by their average hashrate, found blocks, payments or uptime within a window.
+// @Tags Pools
+// @Produce json,text/csv,application/x-ndjson
+// @Param pool_id path string true "ID of the pool"
+// @Param by query string false "Ranking criterion, hashrate, blocks, paid or uptime (default=hashrate)"
+// @Param window query string false "Window of the ranking, 1h, 24h, 7d or 30d (default=24h)"
+// @Param mask query bool false "Mask the addresses of the miners, always masked if configured for the pool"
+// @Param page query int false "Page (default=0)"
+// @Param pageSize query int false "Page size, max 100 (default=15)"
+// @Success 200 {object} api.LeaderboardRes
+// @Failure 400 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/leaderboard [get]
+func (s *Server) getLeaderboardHandler(c *fiber.Ctx) error {
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	by, err := getLeaderboardCriterionQuery(c)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	window, ok := leaderboardWindows[c.Query("window", defaultLeaderboardWindow)]
+	if !ok {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidRange)
+	}
+	page, pageSize := getPageQueries(c)
+	if pageSize > leaderboardMaxPageSize {
+		pageSize = leaderboardMaxPageSize
+	}
+
+	generatedAt := time.Now()
+	from := generatedAt.Add(-window)
+	pageCount, err := s.db.GetLeaderboardCount(c.UserContext(), poolCfg.ID, by, from)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	pageCount = uint(math.Floor(float64(pageCount) / float64(pageSize)))
+
+	entries, err := s.db.GetLeaderboard(c.UserContext(), poolCfg.ID, by, from, page, pageSize)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	res := &LeaderboardRes{
+		Meta: &Meta{
+			Success:     true,
+			PageCount:   pageCount,
+			GeneratedAt: &generatedAt,
+		},
+		Result: dbLeaderboardToAPILeaderboard(entries, page*pageSize, poolCfg.MaskAddresses || c.Query("mask") == "true"),
+	}
+	return sendList(c, res, res.Meta, res.Result)
+}
+
+func getLeaderboardCriterionQuery(c *fiber.Ctx) (database.LeaderboardCriterion, error) {
+	switch by := database.LeaderboardCriterion(c.Query("by", string(database.LeaderboardHashrate))); by {
+	case database.LeaderboardHashrate, database.LeaderboardBlocks, database.LeaderboardTotalPaid, database.LeaderboardUptime:
+		return by, nil
+	default:
+		return "", errInvalidLeaderboardCriterion
+	}
+}
+
+// dbLeaderboardToAPILeaderboard converts the entries of a leaderboard page which starts at the given offset.
+func dbLeaderboardToAPILeaderboard(entries []*database.LeaderboardEntry, offset int, mask bool) []*LeaderboardEntry {
+	res := make([]*LeaderboardEntry, len(entries))
+	for i, e := range entries {
+		miner := e.Miner
+		if mask {
+			miner = maskAddress(miner)
+		}
+		res[i] = &LeaderboardEntry{
+			Rank:      offset + i + 1,
+			Miner:     miner,
+			Hashrate:  e.Hashrate,
+			Workers:   e.Workers,
+			Blocks:    e.Blocks,
+			TotalPaid: e.TotalPaid,
+			Uptime:    e.Uptime,
+		}
+	}
+	return res
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboardCriterionQuery(t *testing.T) {
+	var (
+		by  database.LeaderboardCriterion
+		err error
+	)
+	app := fiber.New()
+	app.Get("/leaderboard", func(c *fiber.Ctx) error {
+		by, err = getLeaderboardCriterionQuery(c)
+		return nil
+	})
+
+	testRequest(t, app, "/leaderboard", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, database.LeaderboardHashrate, by)
+
+	testRequest(t, app, "/leaderboard?by=paid", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, database.LeaderboardTotalPaid, by)
+
+	testRequest(t, app, "/leaderboard?by=hashrate;DROP", nil)
+	assert.ErrorIs(t, err, errInvalidLeaderboardCriterion)
+}
+
+func TestDBLeaderboardToAPILeaderboard(t *testing.T) {
+	entries := []*database.LeaderboardEntry{
+		{Miner: "0x017b9ab8ee1d0a1f3c4d2b6e6a7f8b2c3d4e696c", Hashrate: 2, Workers: 3, Blocks: 1, TotalPaid: 0.5, Uptime: 1},
+		{Miner: "0x5d0a3c4d2b6e6a7f8b2c3d4e017b9ab8ee1d0a1f", Hashrate: 1, Uptime: 0.5},
+	}
+	res := dbLeaderboardToAPILeaderboard(entries, 30, true)
+	assert.Equal(t, []*LeaderboardEntry{
+		{Rank: 31, Miner: "0x017b…696c", Hashrate: 2, Workers: 3, Blocks: 1, TotalPaid: 0.5, Uptime: 1},
+		{Rank: 32, Miner: "0x5d0a…0a1f", Hashrate: 1, Uptime: 0.5},
+	}, res)
+
+	res = dbLeaderboardToAPILeaderboard(entries, 0, false)
+	assert.Equal(t, 1, res[0].Rank)
+	assert.Equal(t, entries[0].Miner, res[0].Miner)
+}
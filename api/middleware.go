@@ -3,12 +3,10 @@ package api
 import (
 	_ "embed"
 	"html/template"
-	"time"
 	"unsafe"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cache"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
 )
@@ -19,32 +17,41 @@ func (s *Server) recover() fiber.Handler {
 	})
 }
 
-func (s *Server) ratelimiter() fiber.Handler {
-	return limiter.New(limiter.Config{
-		Next: func(c *fiber.Ctx) bool {
-			return c.IP() == "127.0.0.1"
-		},
-		Max:               1000,
-		Expiration:        time.Second * 10,
-		LimiterMiddleware: limiter.SlidingWindow{},
-	})
-}
-
-func (s *Server) cache() fiber.Handler {
+// cache returns the cache middleware for the given route.
+// The TTL of the route is taken from the config, a TTL of 0 disables the cache.
+func (s *Server) cache(route string) fiber.Handler {
+	ttl := s.cfg.RouteCacheTTL(route)
+	if ttl <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
 	cfg := cache.ConfigDefault
+	// error responses, e.g. a transient database error, aren't stored
 	cfg.Next = func(c *fiber.Ctx) bool {
-		return c.Query("refresh") == "true"
+		return c.Query("refresh") == "true" || c.Response().StatusCode() != fiber.StatusOK
 	}
-	cfg.Expiration = s.cfg.CacheTTL
+	cfg.Expiration = ttl
 	cfg.CacheControl = true
-	cfg.MaxBytes = 1000000000
-	cfg.KeyGenerator = func(c *fiber.Ctx) string {
-		q := c.Context().QueryArgs().QueryString()
-		return c.Path() + *(*string)(unsafe.Pointer(&q))
-	}
+	// keep the Last-Modified header of the handlers
+	cfg.StoreResponseHeaders = true
+	cfg.Storage = s.cacheStorage
+	cfg.KeyGenerator = cacheKey
 	return cache.New(cfg)
 }
 
+// cacheKey returns the path and the query of the request.
+// The path is terminated by a "?" so keys can be invalidated by path prefixes.
+// Lists which are requested in another format than JSON are cached separately.
+func cacheKey(c *fiber.Ctx) string {
+	q := c.Context().QueryArgs().QueryString()
+	key := c.Path() + "?" + *(*string)(unsafe.Pointer(&q))
+	if format := listFormat(c); format != fiber.MIMEApplicationJSON {
+		key += "|" + format
+	}
+	return key
+}
+
 //go:embed swagger/main.css
 var customSwaggerStyle string
 
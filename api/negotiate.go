@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/gocarina/gocsv"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	mimeTextCSV           = "text/csv"
+	mimeApplicationNDJSON = "application/x-ndjson"
+
+	// headerPageCount contains the page count of list responses without meta data.
+	headerPageCount = "X-Page-Count"
+)
+
+// listFormat returns the content type of a list response which is accepted by the client.
+// It returns an empty string if none of the supported content types is accepted.
+func listFormat(c *fiber.Ctx) string {
+	return c.Accepts(fiber.MIMEApplicationJSON, mimeTextCSV, mimeApplicationNDJSON)
+}
+
+// sendList sends a list response in the format requested by the Accept header.
+// JSON responses contain the whole res, CSV and NDJSON responses only the items of the list.
+// Their page count is sent in the X-Page-Count header.
+func sendList[T any](c *fiber.Ctx, res any, meta *Meta, items []T) error {
+	c.Vary(fiber.HeaderAccept)
+
+	format := listFormat(c)
+	if format != fiber.MIMEApplicationJSON && format != "" {
+		c.Set(headerPageCount, strconv.FormatUint(uint64(meta.PageCount), 10))
+	}
+
+	switch format {
+	case fiber.MIMEApplicationJSON:
+		return c.JSON(res)
+
+	case mimeTextCSV:
+		var buf bytes.Buffer
+		if err := gocsv.Marshal(items, &buf); err != nil {
+			return handleAPIError(c, fiber.StatusInternalServerError, err)
+		}
+		c.Set(fiber.HeaderContentType, mimeTextCSV)
+		return c.Send(buf.Bytes())
+
+	case mimeApplicationNDJSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return handleAPIError(c, fiber.StatusInternalServerError, err)
+			}
+		}
+		c.Set(fiber.HeaderContentType, mimeApplicationNDJSON)
+		return c.Send(buf.Bytes())
+
+	default:
+		return handleAPIError(c, fiber.StatusNotAcceptable, fiber.ErrNotAcceptable)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRequest(t *testing.T, app *fiber.App, path string, headers map[string]string) (*http.Response, []byte) {
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, body
+}
+
+func TestSendList(t *testing.T) {
+	app := fiber.New()
+	app.Get("/miners", func(c *fiber.Ctx) error {
+		res := &MinersRes{
+			Meta:   &Meta{Success: true, PageCount: 3},
+			Result: []MinerSimple{{Miner: "0x1", Hashrate: 1}, {Miner: "0x2", Hashrate: 2}},
+		}
+		return sendList(c, res, res.Meta, res.Result)
+	})
+
+	res, body := testRequest(t, app, "/miners", nil)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, fiber.MIMEApplicationJSON, res.Header.Get(fiber.HeaderContentType))
+	assert.Contains(t, string(body), `"pageCount":3`)
+	assert.Empty(t, res.Header.Get(headerPageCount))
+
+	res, body = testRequest(t, app, "/miners", map[string]string{fiber.HeaderAccept: "text/csv"})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, mimeTextCSV, res.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, "3", res.Header.Get(headerPageCount))
+	assert.Equal(t, "miner,hashrate,sharesPerSecond\n0x1,1,0\n0x2,2,0\n", string(body))
+
+	res, body = testRequest(t, app, "/miners", map[string]string{fiber.HeaderAccept: "application/x-ndjson"})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, mimeApplicationNDJSON, res.Header.Get(fiber.HeaderContentType))
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, `{"miner":"0x2","hashrate":2,"sharesPerSecond":0}`, lines[1])
+
+	res, _ = testRequest(t, app, "/miners", map[string]string{fiber.HeaderAccept: "image/png"})
+	assert.Equal(t, fiber.StatusNotAcceptable, res.StatusCode)
+}
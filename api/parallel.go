@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultMaxParallelQueries is the number of concurrent queries per request if none is configured.
+const defaultMaxParallelQueries = 8
+
+// gather runs the given functions concurrently, at most limit at a time.
+// The first error cancels the context of the remaining functions and is returned once all started functions are done.
+// Functions which haven't been started when the context is cancelled are skipped.
+func gather(ctx context.Context, limit int, fns ...func(ctx context.Context) error) error {
+	ctxc, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if limit <= 0 || limit > len(fns) {
+		limit = len(fns)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, limit)
+	)
+	setErr := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+loop:
+	for _, fn := range fns {
+		select {
+		case sem <- struct{}{}:
+		case <-ctxc.Done():
+			break loop
+		}
+		if ctxc.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(fn func(ctx context.Context) error) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(ctxc); err != nil {
+				setErr(err)
+			}
+		}(fn)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (s *Server) maxParallelQueries() int {
+	if s.cfg.MaxParallelQueries > 0 {
+		return s.cfg.MaxParallelQueries
+	}
+	return defaultMaxParallelQueries
+}
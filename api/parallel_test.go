@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatherLimit(t *testing.T) {
+	var running, max int32
+	fns := make([]func(ctx context.Context) error, 10)
+	results := make([]int, len(fns))
+	for i := range fns {
+		i := i
+		fns[i] = func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond * 10)
+			atomic.AddInt32(&running, -1)
+			results[i] = i
+			return nil
+		}
+	}
+
+	assert.NoError(t, gather(context.Background(), 3, fns...))
+	assert.LessOrEqual(t, atomic.LoadInt32(&max), int32(3))
+	for i, r := range results {
+		assert.Equal(t, i, r)
+	}
+}
+
+func TestGatherError(t *testing.T) {
+	errTest := errors.New("test")
+	var started int32
+	err := gather(context.Background(), 1,
+		func(ctx context.Context) error {
+			atomic.AddInt32(&started, 1)
+			return errTest
+		},
+		func(ctx context.Context) error {
+			atomic.AddInt32(&started, 1)
+			return nil
+		},
+	)
+	assert.ErrorIs(t, err, errTest)
+	// the second function is skipped because the first one failed
+	assert.Equal(t, int32(1), atomic.LoadInt32(&started))
+}
+
+func TestGatherCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := gather(ctx, 0, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
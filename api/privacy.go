@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1oopio/phantomias/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maskAddress hides the middle of an address, e.g. 0x017b…696c.
+// Addresses with a 0x prefix keep 4 characters after the prefix.
+func maskAddress(addr string) string {
+	const visible = 4
+	prefix := ""
+	if strings.HasPrefix(addr, "0x") {
+		prefix, addr = "0x", addr[2:]
+	}
+	if utf8.RuneCountInString(addr) <= visible*2 {
+		return prefix + addr
+	}
+	runes := []rune(addr)
+	return prefix + string(runes[:visible]) + "…" + string(runes[len(runes)-visible:])
+}
+
+// The following functions mask the addresses in the public lists of pools which are configured with mask_addresses.
+// The miner specific routes always return the full address.
+
+func maskMiners(miners []MinerSimple) {
+	for i := range miners {
+		miners[i].Miner = maskAddress(miners[i].Miner)
+	}
+}
+
+func maskTopMiners(miners []*TopMiner) {
+	for _, m := range miners {
+		m.Miner = maskAddress(m.Miner)
+	}
+}
+
+func maskBlocks(blocks []*Block) {
+	for _, b := range blocks {
+		b.Miner = maskAddress(b.Miner)
+	}
+}
+
+// maskPayments masks the addresses of payments and removes the links to them.
+// The transactions are removed as well, they would reveal the full address.
+func maskPayments(payments []*Payment) {
+	for _, p := range payments {
+		p.Address = maskAddress(p.Address)
+		p.AddressInfoLink = ""
+		p.TransactionConfirmationData = ""
+		p.TransactionInfoLink = ""
+	}
+}
+
+// getLeaderboardOptOut returns whether the miner opted out of the leaderboards of a pool.
+func (s *Server) getLeaderboardOptOut(ctx context.Context, poolID, addr string) (*bool, error) {
+	optOut, err := s.db.GetLeaderboardOptOut(ctx, poolID, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &optOut, nil
+}
+
+// @Summary Opt out of leaderboards
+// @Description Hide a specific miner from a specific pool from the top miners and the leaderboard, or show it again.
+// @Description The ownership of the address is proven the same way as for settings updates. The payment settings aren't changed.
+// @Tags Miners
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param optout body api.LeaderboardOptOutReq true "Opt-out incl. the proof of ownership"
+// @Success 200 {object} api.LeaderboardOptOutRes
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/settings/leaderboard [put]
+func (s *Server) putLeaderboardOptOutHandler(c *fiber.Ctx) error {
+	var req LeaderboardOptOutReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	proof, _, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req.OwnershipProof)
+	if err != nil {
+		return handleAPIError(c, code, err)
+	}
+
+	if err := s.db.SetLeaderboardOptOut(c.UserContext(), poolCfg.ID, addr, req.HideFromLeaderboards, time.Now()); err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] leaderboard opt-out of miner %s on pool %s set to %t by %s with %s proof", addr, poolCfg.ID, req.HideFromLeaderboards, requestIdentity(c), proof)
+	s.invalidateLeaderboards(poolCfg.ID)
+	return c.JSON(&LeaderboardOptOutRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: req.HideFromLeaderboards,
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1oopio/phantomias/cache"
+	"github.com/1oopio/phantomias/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskAddress(t *testing.T) {
+	assert.Equal(t, "0x017b…696c", maskAddress("0x017b9ab8ee1d0a1f3c4d2b6e6a7f8b2c3d4e696c"))
+	assert.Equal(t, "9fDe…Vxr5", maskAddress("9fDeWbq4nQNHg5CDWoHJBJ9C2X6UVfaAfZ8JJCFAEGZW1aNVxr5"))
+	assert.Equal(t, "rig01", maskAddress("rig01"))
+	assert.Equal(t, "0x1234", maskAddress("0x1234"))
+}
+
+func TestMaskLists(t *testing.T) {
+	addr := "0x017b9ab8ee1d0a1f3c4d2b6e6a7f8b2c3d4e696c"
+
+	payments := []*Payment{{Address: addr, AddressInfoLink: "https://etherscan.io/address/" + addr, TransactionConfirmationData: "0xabc", TransactionInfoLink: "https://etherscan.io/tx/0xabc"}}
+	maskPayments(payments)
+	assert.Equal(t, []*Payment{{Address: "0x017b…696c"}}, payments)
+
+	blocks := []*Block{{Miner: addr, BlockHeight: 1}}
+	maskBlocks(blocks)
+	assert.Equal(t, "0x017b…696c", blocks[0].Miner)
+
+	miners := []MinerSimple{{Miner: addr, Hashrate: 1}}
+	maskMiners(miners)
+	assert.Equal(t, []MinerSimple{{Miner: "0x017b…696c", Hashrate: 1}}, miners)
+
+	topMiners := []*TopMiner{{Miner: addr}}
+	maskTopMiners(topMiners)
+	assert.Equal(t, "0x017b…696c", topMiners[0].Miner)
+}
+
+func TestPutLeaderboardOptOutProof(t *testing.T) {
+	storage := cache.NewMemory(0)
+	defer storage.Close()
+	s := &Server{
+		pools:        []*config.Pool{{ID: "eth1", Name: "Ethereum", Type: "ethereum"}},
+		nonceStorage: storage,
+	}
+	app := fiber.New()
+	app.Put("/pools/:id/miners/:miner_addr/settings/leaderboard", s.putLeaderboardOptOutHandler)
+
+	// the opt-out is only written after the ownership is proven
+	for body, code := range map[string]int{
+		`{"hideFromLeaderboards":true,"ipAddress":"invalid"}`:                 fiber.StatusBadRequest,
+		`{"hideFromLeaderboards":true,"nonce":"unknown","signature":"0xabc"}`: fiber.StatusUnauthorized,
+		`{"hideFromLeaderboards":`:                                            fiber.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(fiber.MethodPut, "/pools/eth1/miners/0xabc/settings/leaderboard", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, code, res.StatusCode, body)
+	}
+
+	req := httptest.NewRequest(fiber.MethodPut, "/pools/eth2/miners/0xabc/settings/leaderboard", strings.NewReader(`{"hideFromLeaderboards":true}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+}
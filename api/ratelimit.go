@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// route groups with their own rate limit counters
+const (
+	rateLimitGroupDefault = "default"
+	rateLimitGroupCSV     = "csv"
+	rateLimitGroupWS      = "ws"
+	rateLimitGroupSwagger = "swagger"
+	// lookups of api keys which aren't cached, counted by IP
+	rateLimitGroupAuth = "auth"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRateLimitPolicy    = "RateLimit-Policy"
+)
+
+var errRateLimited = errors.New("too many requests")
+
+// defaultRateLimit is used if no rate limit is configured.
+// Its groups are also added to configs which don't limit them.
+var defaultRateLimit = config.RateLimit{
+	Enabled: true,
+	Exempt:  []string{"127.0.0.1"},
+	Tier: config.Tier{
+		Max:    1000,
+		Window: time.Second * 10,
+		Groups: map[string]*config.Limit{
+			rateLimitGroupCSV:  {Max: 30, Window: time.Minute},
+			rateLimitGroupAuth: {Max: 60, Window: time.Minute},
+		},
+	},
+}
+
+// rateLimitConfig returns the given config with the default group limits.
+func rateLimitConfig(cfg *config.RateLimit) *config.RateLimit {
+	if cfg == nil {
+		c := defaultRateLimit
+		return &c
+	}
+	c := *cfg
+	c.Groups = make(map[string]*config.Limit, len(cfg.Groups)+len(defaultRateLimit.Groups))
+	for group, l := range defaultRateLimit.Groups {
+		c.Groups[group] = l
+	}
+	for group, l := range cfg.Groups {
+		c.Groups[group] = l
+	}
+	return &c
+}
+
+// ratelimiter limits the requests of a client to the given route group.
+// Clients are identified by their api key or their IP, see identify. Requests are counted in sliding windows
+// unless fixed windows are configured, the state of the window is sent in the RateLimit-* headers.
+// If the counters can't be updated, requests are let through.
+func (s *Server) ratelimiter(group string) fiber.Handler {
+	cfg := s.rateLimitCfg
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	exempt := exemptIPs(cfg)
+
+	return func(c *fiber.Ctx) error {
+		if _, ok := exempt[c.IP()]; ok {
+			return c.Next()
+		}
+		id, tier := rateLimitIdentity(c)
+		limit := cfg.Limit(tier, group)
+		if limit.Max <= 0 || limit.Window <= 0 {
+			return c.Next()
+		}
+
+		res, err := s.rateLimitStore.Hit(c.UserContext(), group+":"+id, limit.Window)
+		if err != nil {
+			log.Printf("failed to count request of %s: %v", id, err)
+			return c.Next()
+		}
+		hits := res.Count(cfg.Strategy, limit.Window)
+
+		resetSec := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+		remaining := limit.Max - hits
+		if remaining < 0 {
+			remaining = 0
+		}
+		setHeaders := func() {
+			c.Set(headerRateLimitLimit, strconv.Itoa(limit.Max))
+			c.Set(headerRateLimitRemaining, strconv.Itoa(remaining))
+			c.Set(headerRateLimitReset, resetSec)
+			c.Set(headerRateLimitPolicy, strconv.Itoa(limit.Max)+";w="+strconv.Itoa(int(limit.Window.Seconds())))
+		}
+
+		if hits > limit.Max {
+			setHeaders()
+			c.Set(fiber.HeaderRetryAfter, resetSec)
+			return handleAPIError(c, fiber.StatusTooManyRequests, errRateLimited)
+		}
+		// the headers are set after the handlers, so the response cache
+		// neither stores them nor serves the state of another client
+		err = c.Next()
+		setHeaders()
+		return err
+	}
+}
+
+// lookupLimiter limits the api key lookups of a client by its IP.
+// Keys which aren't cached are loaded from the database, so unknown keys are counted before they are verified.
+// It returns false and responds with 429 if the client exceeded its limit.
+func (s *Server) lookupLimiter() func(c *fiber.Ctx) (bool, error) {
+	cfg := s.rateLimitCfg
+	if cfg == nil || !cfg.Enabled {
+		return func(*fiber.Ctx) (bool, error) {
+			return true, nil
+		}
+	}
+	exempt := exemptIPs(cfg)
+	limit := cfg.Limit("", rateLimitGroupAuth)
+
+	return func(c *fiber.Ctx) (bool, error) {
+		if _, ok := exempt[c.IP()]; ok || limit.Max <= 0 || limit.Window <= 0 {
+			return true, nil
+		}
+		res, err := s.rateLimitStore.Hit(c.UserContext(), rateLimitGroupAuth+":ip:"+c.IP(), limit.Window)
+		if err != nil {
+			log.Printf("failed to count api key lookup of %s: %v", c.IP(), err)
+			return true, nil
+		}
+		if res.Count(cfg.Strategy, limit.Window) > limit.Max {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+			return false, handleAPIError(c, fiber.StatusTooManyRequests, errRateLimited)
+		}
+		return true, nil
+	}
+}
+
+func exemptIPs(cfg *config.RateLimit) map[string]struct{} {
+	exempt := make(map[string]struct{}, len(cfg.Exempt))
+	for _, ip := range cfg.Exempt {
+		exempt[ip] = struct{}{}
+	}
+	return exempt
+}
+
+// rateLimitIdentity returns the id and the tier of the client.
+// Clients with an api key are identified by their key, all others by their IP.
+func rateLimitIdentity(c *fiber.Ctx) (string, string) {
+	if key := requestAPIKey(c); key != nil {
+		return requestIdentity(c), key.Tier
+	}
+	return requestIdentity(c), ""
+}
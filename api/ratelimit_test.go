@@ -0,0 +1,115 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/cache"
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/ratelimit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	cfg := rateLimitConfig(&config.RateLimit{
+		Enabled: true,
+		Tier:    config.Tier{Max: 2, Window: time.Minute},
+		Tiers: map[string]*config.Tier{
+			"pro": {Max: 5, Window: time.Minute},
+		},
+	})
+	s := &Server{
+		rateLimitCfg:   cfg,
+		rateLimitStore: ratelimit.NewMemory(),
+	}
+	app := fiber.New()
+	// stands in for identify
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get(headerAPIKey) == "secret" {
+			c.Locals(localsAPIKey, &apikey.Key{ID: "1", Tier: "pro", Scopes: []apikey.Scope{apikey.ScopeRead}})
+		}
+		return c.Next()
+	})
+	app.Get("/", s.ratelimiter(rateLimitGroupDefault), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	res, _ := testRequest(t, app, "/", nil)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, "2", res.Header.Get(headerRateLimitLimit))
+	assert.Equal(t, "1", res.Header.Get(headerRateLimitRemaining))
+	assert.NotEmpty(t, res.Header.Get(headerRateLimitReset))
+	assert.Equal(t, "2;w=60", res.Header.Get(headerRateLimitPolicy))
+
+	testRequest(t, app, "/", nil)
+	res, _ = testRequest(t, app, "/", nil)
+	assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "0", res.Header.Get(headerRateLimitRemaining))
+	assert.Equal(t, res.Header.Get(headerRateLimitReset), res.Header.Get(fiber.HeaderRetryAfter))
+
+	// clients with an api key have their own counter and the limits of their tier
+	res, _ = testRequest(t, app, "/", map[string]string{headerAPIKey: "secret"})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, "5", res.Header.Get(headerRateLimitLimit))
+	assert.Equal(t, "4", res.Header.Get(headerRateLimitRemaining))
+}
+
+func TestRateLimiterCached(t *testing.T) {
+	storage := cache.NewMemory(cache.DefaultMaxBytes)
+	defer storage.Close()
+	s := &Server{
+		cfg:            &config.API{CacheTTL: time.Minute},
+		cacheStorage:   storage,
+		rateLimitCfg:   rateLimitConfig(&config.RateLimit{Enabled: true, Tier: config.Tier{Max: 5, Window: time.Minute}}),
+		rateLimitStore: ratelimit.NewMemory(),
+	}
+	app := fiber.New()
+	// stands in for identify
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get(headerAPIKey) == "secret" {
+			c.Locals(localsAPIKey, &apikey.Key{ID: "1", Scopes: []apikey.Scope{apikey.ScopeRead}})
+		}
+		return c.Next()
+	})
+	calls := 0
+	app.Get("/api/v1/pools", s.ratelimiter(rateLimitGroupDefault), s.cache(cacheRoutePools), func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderLastModified, "Mon, 02 Jan 2006 15:04:05 GMT")
+		return c.SendString("pools")
+	})
+
+	for i := 0; i < 3; i++ {
+		res, _ := testRequest(t, app, "/api/v1/pools", nil)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, strconv.Itoa(4-i), res.Header.Get(headerRateLimitRemaining))
+	}
+	// cache hits carry the rate limit state of the requesting client
+	res, body := testRequest(t, app, "/api/v1/pools", map[string]string{headerAPIKey: "secret"})
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, "pools", string(body))
+	assert.Equal(t, "4", res.Header.Get(headerRateLimitRemaining))
+	assert.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", res.Header.Get(fiber.HeaderLastModified))
+	res, _ = testRequest(t, app, "/api/v1/pools", nil)
+	assert.Equal(t, "1", res.Header.Get(headerRateLimitRemaining))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	cfg := rateLimitConfig(nil)
+	assert.Equal(t, config.Limit{Max: 30, Window: time.Minute}, cfg.Limit("", rateLimitGroupCSV))
+
+	cfg = rateLimitConfig(&config.RateLimit{
+		Tier: config.Tier{
+			Max:    10,
+			Window: time.Second,
+			Groups: map[string]*config.Limit{rateLimitGroupCSV: {Max: 1, Window: time.Hour}},
+		},
+	})
+	assert.Equal(t, config.Limit{Max: 1, Window: time.Hour}, cfg.Limit("", rateLimitGroupCSV))
+	assert.Equal(t, config.Limit{Max: 10, Window: time.Second}, cfg.Limit("", rateLimitGroupWS))
+	// the defaults don't modify each other
+	assert.Equal(t, config.Limit{Max: 30, Window: time.Minute}, defaultRateLimit.Limit("", rateLimitGroupCSV))
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/1oopio/phantomias/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	maxGroupSeparatorLength = 8
+	maxGroupRegexLength     = 128
+	maxWorkerLabels         = 10
+)
+
+var labelRegex = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,32}$`)
+
+var (
+	errInvalidGrouping = errors.New("invalid worker grouping, use either a separator or a regex")
+	errInvalidLabel    = errors.New("labels can contain up to 32 letters, digits and _.:-")
+	errTooManyLabels   = fmt.Errorf("workers can have up to %d labels", maxWorkerLabels)
+)
+
+// workerGrouping assigns workers to rigs, either by the part of the name before a separator,
+// e.g. rig01 for rig01.gpu3, or by a regex whose first group or whole match is the name of the rig.
+// Workers which don't match are a rig on their own.
+type workerGrouping struct {
+	separator string
+	regex     *regexp.Regexp
+}
+
+// getWorkerGroupingQuery returns the grouping of the request or nil if the workers aren't grouped.
+func getWorkerGroupingQuery(c *fiber.Ctx) (*workerGrouping, error) {
+	separator, pattern := c.Query("group"), c.Query("groupRegex")
+	switch {
+	case separator == "" && pattern == "":
+		return nil, nil
+	case separator != "" && pattern != "":
+		return nil, errInvalidGrouping
+	case len(separator) > maxGroupSeparatorLength || len(pattern) > maxGroupRegexLength:
+		return nil, errInvalidGrouping
+	case separator != "":
+		return &workerGrouping{separator: strings.Clone(separator)}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidGrouping, err)
+	}
+	return &workerGrouping{regex: re}, nil
+}
+
+// rig returns the name of the rig of a worker.
+func (g *workerGrouping) rig(worker string) string {
+	if g.regex == nil {
+		if rig, _, ok := strings.Cut(worker, g.separator); ok && rig != "" {
+			return rig
+		}
+		return worker
+	}
+	m := g.regex.FindStringSubmatch(worker)
+	switch {
+	case len(m) > 1 && m[1] != "":
+		return m[1]
+	case len(m) > 0 && m[0] != "":
+		return m[0]
+	default:
+		return worker
+	}
+}
+
+// groupWorkers aggregates the stats of the workers by rig, sorted by the name of the rig.
+func (g *workerGrouping) groupWorkers(workers map[string]*WorkerPerformanceStats) []*Rig {
+	byName := make(map[string]*Rig)
+	for name, w := range workers {
+		rigName := g.rig(name)
+		rig, ok := byName[rigName]
+		if !ok {
+			rig = &Rig{Name: rigName}
+			byName[rigName] = rig
+		}
+		rig.Workers = append(rig.Workers, name)
+		rig.Hashrate += utils.ValueOrZero(w.Hashrate)
+		rig.ReportedHashrate += utils.ValueOrZero(w.ReportedHashrate)
+		rig.SharesPerSecond += utils.ValueOrZero(w.SharesPerSecond)
+	}
+	rigs := make([]*Rig, 0, len(byName))
+	for _, rig := range byName {
+		sort.Strings(rig.Workers)
+		rigs = append(rigs, rig)
+	}
+	sort.Slice(rigs, func(i, j int) bool {
+		return rigs[i].Name < rigs[j].Name
+	})
+	return rigs
+}
+
+// @Summary Set the labels of a worker
+// @Description Replace the labels of a specific worker from a specific miner from a specific pool.
+// @Description The labels are returned with the miner and the workers. The ownership of the address is proven the same way as for settings updates.
+// @Tags Workers
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param worker_name path string true "Name of the worker"
+// @Param labels body api.WorkerLabelsReq true "Labels incl. the proof of ownership"
+// @Success 200 {object} api.WorkerLabelsRes
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}/labels [put]
+func (s *Server) putWorkerLabelsHandler(c *fiber.Ctx) error {
+	var req WorkerLabelsReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+	worker := strings.Clone(getWorkerNameParam(c))
+	if worker == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidWorkerName)
+	}
+	labels, err := normalizeLabels(req.Labels)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+
+	proof, _, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req.OwnershipProof)
+	if err != nil {
+		return handleAPIError(c, code, err)
+	}
+
+	if err := s.db.SetWorkerLabels(c.UserContext(), poolCfg.ID, addr, worker, labels, time.Now().UTC()); err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] labels of worker %s of miner %s on pool %s set by %s with %s proof", worker, addr, poolCfg.ID, requestIdentity(c), proof)
+	addrs := []string{addr}
+	if raw := c.Params("miner_addr"); raw != addr {
+		addrs = append(addrs, raw)
+	}
+	s.invalidateMiner(poolCfg.ID, addrs...)
+	return c.JSON(&WorkerLabelsRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: labels,
+	})
+}
+
+// normalizeLabels validates the labels and removes duplicates.
+func normalizeLabels(labels []string) ([]string, error) {
+	res := make([]string, 0, len(labels))
+	seen := make(map[string]struct{}, len(labels))
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if !labelRegex.MatchString(l) {
+			return nil, errInvalidLabel
+		}
+		if _, ok := seen[l]; ok {
+			continue
+		}
+		seen[l] = struct{}{}
+		res = append(res, l)
+	}
+	if len(res) > maxWorkerLabels {
+		return nil, errTooManyLabels
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerGrouping(t *testing.T) {
+	var (
+		grouping *workerGrouping
+		err      error
+	)
+	app := fiber.New()
+	app.Get("/workers", func(c *fiber.Ctx) error {
+		grouping, err = getWorkerGroupingQuery(c)
+		return nil
+	})
+
+	testRequest(t, app, "/workers", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, grouping)
+
+	testRequest(t, app, "/workers?group=.", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "rig01", grouping.rig("rig01.gpu3"))
+	assert.Equal(t, "rig01", grouping.rig("rig01.gpu3.fan"))
+	assert.Equal(t, "default", grouping.rig("default"))
+	assert.Equal(t, ".gpu1", grouping.rig(".gpu1"))
+
+	testRequest(t, app, "/workers?groupRegex=^(rig[0-9]%2B)", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "rig01", grouping.rig("rig01gpu3"))
+	assert.Equal(t, "default", grouping.rig("default"))
+
+	testRequest(t, app, "/workers?groupRegex=[a-z]%2B", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "rig", grouping.rig("rig01_gpu3"))
+
+	testRequest(t, app, "/workers?group=.&groupRegex=rig", nil)
+	assert.ErrorIs(t, err, errInvalidGrouping)
+	testRequest(t, app, "/workers?groupRegex=(rig", nil)
+	assert.ErrorIs(t, err, errInvalidGrouping)
+}
+
+func TestGroupWorkers(t *testing.T) {
+	one, two := 1.0, 2.0
+	g := &workerGrouping{separator: "."}
+	rigs := g.groupWorkers(map[string]*WorkerPerformanceStats{
+		"rig02.gpu0": {Hashrate: &one, ReportedHashrate: &two},
+		"rig01.gpu1": {Hashrate: &two, SharesPerSecond: &one},
+		"rig01.gpu0": {Hashrate: &one, SharesPerSecond: &one},
+		"laptop":     {},
+	})
+	assert.Equal(t, []*Rig{
+		{Name: "laptop", Workers: []string{"laptop"}},
+		{Name: "rig01", Workers: []string{"rig01.gpu0", "rig01.gpu1"}, Hashrate: 3, SharesPerSecond: 2},
+		{Name: "rig02", Workers: []string{"rig02.gpu0"}, Hashrate: 1, ReportedHashrate: 2},
+	}, rigs)
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	labels, err := normalizeLabels([]string{"garage", " 3090 ", "garage", "psu:1200w"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"garage", "3090", "psu:1200w"}, labels)
+
+	labels, err = normalizeLabels(nil)
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+
+	_, err = normalizeLabels([]string{"a,b"})
+	assert.ErrorIs(t, err, errInvalidLabel)
+	_, err = normalizeLabels([]string{""})
+	assert.ErrorIs(t, err, errInvalidLabel)
+	_, err = normalizeLabels([]string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"})
+	assert.ErrorIs(t, err, errTooManyLabels)
+}
@@ -3,8 +3,8 @@ package api
 import (
 	"time"
 
+	"github.com/1oopio/phantomias/apikey"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/timeout"
 	"github.com/gofiber/websocket/v2"
 )
@@ -17,31 +17,30 @@ var (
 )
 
 func (s *Server) setupRoutes() {
-	cache := s.cache()
-	ratelimiter := s.ratelimiter()
-
 	// health check
-	s.api.Get("/health", func(c *fiber.Ctx) error {
-		return c.SendStatus(fiber.StatusOK)
-	})
+	s.api.Get("/health", s.healthHandler)
 
 	// pool api
-	s.apiRoutes(cache, ratelimiter)
+	s.apiRoutes(s.identify(), s.ratelimiter(rateLimitGroupDefault))
 	// websockets
-	s.wsRoute(ratelimiter)
+	s.wsRoute(s.identify(), s.ratelimiter(rateLimitGroupWS))
 	// swagger
-	s.swaggerRoute(ratelimiter)
+	s.swaggerRoute(s.ratelimiter(rateLimitGroupSwagger))
 	// teapot :D
-	s.teaPot(ratelimiter)
+	s.teaPot(s.ratelimiter(rateLimitGroupDefault))
 }
 
-func (s *Server) apiRoutes(cache, ratelimiter fiber.Handler) {
+func (s *Server) apiRoutes(middleware ...fiber.Handler) {
 	api := s.api.Group("/api")
-	v1 := api.Group("/v1", ratelimiter)
+	v1 := api.Group("/v1", append(middleware,
+		// api keys need the read scope for all GET routes
+		s.scope(apikey.ScopeRead, fiber.MethodGet, fiber.MethodHead),
+		s.conditional(),
+	)...)
 
 	// overall
 	v1.Get("/stats",
-		cache,
+		s.cache(cacheRouteStats),
 		timeout.New(s.getOverallPoolStatsHandler, shortTimeout),
 	)
 	v1.Get("/search",
@@ -50,26 +49,33 @@ func (s *Server) apiRoutes(cache, ratelimiter fiber.Handler) {
 
 	// pools
 	v1.Get("/pools",
-		cache,
+		s.cache(cacheRoutePools),
 		timeout.New(s.getPoolsHandler, shortTimeout),
 	)
 	v1.Get("/pools/:id",
+		s.cache(cacheRoutePool),
 		timeout.New(s.getPoolHandler, shortTimeout),
 	)
 	v1.Get("pools/:id/blocks",
+		s.cache(cacheRouteBlocks),
 		timeout.New(s.getBlocksHandler, shortTimeout),
 	)
 	v1.Get("pools/:id/payments",
+		s.cache(cacheRoutePayments),
 		timeout.New(s.getPaymentsHandler, shortTimeout),
 	)
 	v1.Get("pools/:id/performance",
-		cache,
+		s.cache(cacheRoutePoolPerformance),
 		timeout.New(s.getPoolPerformanceHandler, longTimeout),
 	)
 	v1.Get("pools/:id/topminers",
-		cache,
+		s.cache(cacheRouteTopMiners),
 		timeout.New(s.getTopMinersHandler, shortTimeout),
 	)
+	v1.Get("pools/:id/leaderboard",
+		s.cache(cacheRouteLeaderboard),
+		timeout.New(s.getLeaderboardHandler, longTimeout),
+	)
 
 	// miners
 	v1.Get("pools/:id/miners",
@@ -88,36 +94,98 @@ func (s *Server) apiRoutes(cache, ratelimiter fiber.Handler) {
 		timeout.New(s.getMinerDailyEarningsHandler, shortTimeout),
 	)
 	v1.Get("pools/:id/miners/:miner_addr/performance",
-		cache,
+		s.cache(cacheRouteMinerPerformance),
 		timeout.New(s.getMinerPerformanceHandler, longTimeout),
 	)
 	v1.Get("pools/:id/miners/:miner_addr/settings",
 		timeout.New(s.getMinerSettingsHandler, shortTimeout),
 	)
+	v1.Get("pools/:id/miners/:miner_addr/settings/history",
+		timeout.New(s.getMinerSettingsHistoryHandler, shortTimeout),
+	)
+	v1.Get("pools/:id/miners/:miner_addr/settings/nonce",
+		timeout.New(s.getMinerSettingsNonceHandler, shortTimeout),
+	)
 	v1.Post("pools/:id/miners/:miner_addr/settings",
+		s.scope(apikey.ScopeMinerSettings),
 		timeout.New(s.postMinerSettingsHandler, shortTimeout),
 	)
+	v1.Put("pools/:id/miners/:miner_addr/settings/leaderboard",
+		s.scope(apikey.ScopeMinerSettings),
+		timeout.New(s.putLeaderboardOptOutHandler, shortTimeout),
+	)
+
+	// alerts
+	v1.Get("alerts/channels",
+		timeout.New(s.getAlertChannelsHandler, shortTimeout),
+	)
+	v1.Get("pools/:id/miners/:miner_addr/alerts",
+		timeout.New(s.getAlertSubscriptionsHandler, shortTimeout),
+	)
+	v1.Post("pools/:id/miners/:miner_addr/alerts",
+		s.scope(apikey.ScopeMinerSettings),
+		timeout.New(s.postAlertSubscriptionHandler, shortTimeout),
+	)
+	v1.Delete("pools/:id/miners/:miner_addr/alerts/:sub_id",
+		s.scope(apikey.ScopeMinerSettings),
+		timeout.New(s.deleteAlertSubscriptionHandler, shortTimeout),
+	)
+
+	// webhooks
+	v1.Get("pools/:id/miners/:miner_addr/webhooks",
+		timeout.New(s.getMinerWebhooksHandler, shortTimeout),
+	)
+	v1.Post("pools/:id/miners/:miner_addr/webhooks",
+		s.scope(apikey.ScopeMinerSettings),
+		timeout.New(s.postMinerWebhookHandler, shortTimeout),
+	)
+	v1.Delete("pools/:id/miners/:miner_addr/webhooks/:webhook_id",
+		s.scope(apikey.ScopeMinerSettings),
+		timeout.New(s.deleteMinerWebhookHandler, shortTimeout),
+	)
+	webhooks := v1.Group("webhooks", s.scope(apikey.ScopeAdmin))
+	webhooks.Get("",
+		timeout.New(s.getWebhooksHandler, shortTimeout),
+	)
+	webhooks.Post("",
+		timeout.New(s.postWebhookHandler, shortTimeout),
+	)
+	webhooks.Get("deadletters",
+		timeout.New(s.getWebhookDeadLettersHandler, shortTimeout),
+	)
+	webhooks.Post("deliveries/:delivery_id/retry",
+		timeout.New(s.postWebhookDeliveryRetryHandler, shortTimeout),
+	)
+	webhooks.Delete(":webhook_id",
+		timeout.New(s.deleteWebhookHandler, shortTimeout),
+	)
+	webhooks.Get(":webhook_id/deliveries",
+		timeout.New(s.getWebhookDeliveriesHandler, shortTimeout),
+	)
 
 	// workers
+	v1.Get("pools/:id/miners/:miner_addr/workers",
+		timeout.New(s.getWorkersHandler, shortTimeout),
+	)
+	v1.Get("pools/:id/miners/:miner_addr/health",
+		timeout.New(s.getWorkersHealthHandler, shortTimeout),
+	)
 	v1.Get("pools/:id/miners/:miner_addr/workers/:worker_name/performance",
-		cache,
+		s.cache(cacheRouteWorkerPerformance),
 		timeout.New(s.getWorkerPerformanceHandler, shortTimeout),
 	)
 	v1.Get("pools/:id/miners/:miner_addr/workers/:worker_name",
 		timeout.New(s.getWorkerHandler, longTimeout),
 	)
+	v1.Put("pools/:id/miners/:miner_addr/workers/:worker_name/labels",
+		s.scope(apikey.ScopeMinerSettings),
+		timeout.New(s.putWorkerLabelsHandler, shortTimeout),
+	)
 
 	// CSV
 	v1.Get("pools/:id/miners/:miner_addr/csv",
 		// use a stricter rate limit for CSV downloads
-		limiter.New(limiter.Config{
-			Next: func(c *fiber.Ctx) bool {
-				return c.IP() == "127.0.0.1"
-			},
-			Max:               30,
-			Expiration:        time.Second * 60,
-			LimiterMiddleware: limiter.FixedWindow{},
-		}),
+		s.ratelimiter(rateLimitGroupCSV),
 		timeout.New(s.getCSVDownloadHandler, extremeTimeout),
 	)
 }
@@ -125,15 +193,26 @@ func (s *Server) apiRoutes(cache, ratelimiter fiber.Handler) {
 func (s *Server) wsRoute(middleware ...fiber.Handler) {
 	// require a connection upgrade to websocket
 	s.api.Use("/v1/ws", func(c *fiber.Ctx) error {
-		if websocket.IsWebSocketUpgrade(c) {
-			c.Locals("allowed", true)
-			return c.Next()
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		if !s.wsRelay.originAllowed(c.Get(fiber.HeaderOrigin)) {
+			s.wsRelay.reject(errWSOriginNotAllowed)
+			return handleAPIError(c, fiber.StatusForbidden, errWSOriginNotAllowed)
+		}
+		// reject early if the limits are already reached, the slot is reserved after the upgrade
+		if err := s.wsRelay.allowed(c.IP()); err != nil {
+			s.wsRelay.reject(err)
+			return handleAPIError(c, fiber.StatusTooManyRequests, err)
 		}
-		return fiber.ErrUpgradeRequired
+		c.Locals("allowed", true)
+		c.Locals("ip", c.IP())
+		return c.Next()
 	})
 
 	s.api.Get("/v1/ws", append(middleware, websocket.New(s.wsHandler, websocket.Config{
 		HandshakeTimeout: wsHandshakeTimeout,
+		Origins:          s.wsRelay.cfg.AllowedOrigins,
 	}))...)
 }
 
@@ -0,0 +1,344 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/cache"
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/signature"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	settingsNonceTTL    = time.Minute * 10
+	settingsNonceBytes  = 16
+	settingsNoncePrefix = "settings-nonce:"
+	// about 30000 nonces fit into the default nonce storage
+	settingsNonceMaxBytes = 10000000
+)
+
+// methods to prove the ownership of a miner address
+const (
+	ownershipProofIP        = "ip"
+	ownershipProofSignature = "signature"
+)
+
+var (
+	errInvalidNonce       = errors.New("invalid or expired nonce")
+	errTooManyNonces      = errors.New("too many pending nonces, try again later")
+	errNoRecentShares     = errors.New("no recent shares of the miner found")
+	errNoSettings         = errors.New("no settings found")
+	errInvalidSettings    = errors.New("invalid or missing settings")
+	errInvalidIPAddress   = errors.New("invalid or missing ip address")
+	errIPAddressNotRecent = errors.New("ip address not recently used for mining")
+)
+
+// settingsNonce is a nonce which has been issued to update the settings of a miner.
+type settingsNonce struct {
+	PoolID  string    `json:"poolId"`
+	Address string    `json:"address"`
+	Message string    `json:"message"`
+	Expires time.Time `json:"expires"`
+}
+
+// @Summary Get a nonce to update settings
+// @Description Get a nonce to prove the ownership of a miner address. The message has to be signed with the key of the address and sent along with the updated settings.
+// @Tags Miners
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Success 200 {object} api.MinerSettingsNonceRes
+// @Failure 400 {object} utils.APIError
+// @Failure 503 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/settings/nonce [get]
+func (s *Server) getMinerSettingsNonceHandler(c *fiber.Ctx) error {
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+	if !signature.Supported(addr) {
+		return handleAPIError(c, fiber.StatusBadRequest, signature.ErrUnsupportedAddress)
+	}
+
+	b := make([]byte, settingsNonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	nonce := hex.EncodeToString(b)
+	expires := time.Now().Add(settingsNonceTTL).UTC().Truncate(time.Second)
+	entry := settingsNonce{
+		PoolID:  poolCfg.ID,
+		Address: addr,
+		Message: settingsMessage(poolCfg, addr, nonce, expires),
+		Expires: expires,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	if err := s.nonceStorage.Set(settingsNoncePrefix+nonce, data, settingsNonceTTL); err != nil {
+		if errors.Is(err, cache.ErrFull) {
+			return handleAPIError(c, fiber.StatusServiceUnavailable, errTooManyNonces)
+		}
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(&MinerSettingsNonceRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: &MinerSettingsNonce{
+			Nonce:   nonce,
+			Message: entry.Message,
+			Expires: expires,
+		},
+	})
+}
+
+// settingsMessage returns the message which has to be signed to update the settings.
+func settingsMessage(p *config.Pool, addr, nonce string, expires time.Time) string {
+	return fmt.Sprintf("Update the settings of %s on the %s pool %s.\n\nNonce: %s\nExpires: %s",
+		addr, p.Name, p.ID, nonce, expires.Format(time.RFC3339))
+}
+
+// verifySettingsSignature consumes the nonce and verifies that its message was signed by the miner.
+func (s *Server) verifySettingsSignature(poolID, addr, nonce, sig string) error {
+	// a nonce can only be used once, so it is taken atomically to prevent concurrent replays
+	data, err := s.nonceStorage.Take(settingsNoncePrefix + nonce)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errInvalidNonce
+	}
+
+	var entry settingsNonce
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return errInvalidNonce
+	}
+	if entry.PoolID != poolID || entry.Address != addr || time.Now().After(entry.Expires) {
+		return errInvalidNonce
+	}
+	return signature.Verify(addr, entry.Message, sig)
+}
+
+// recentIPAddress returns the IP address which the miner most recently submitted shares from.
+// Miningcore only accepts settings updates with such an IP address.
+func (s *Server) recentIPAddress(ctx context.Context, poolID, addr string) (string, error) {
+	ips, err := s.db.GetRecentyUsedIPAddresses(ctx, poolID, addr)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", errNoRecentShares
+	}
+	return ips[0], nil
+}
+
+// verifyOwnership checks that the request was made by the owner of the miner address.
+// The owner either signed the message of a nonce or knows an IP address the miner recently submitted shares from.
+// It returns the method of the proof and an IP address of the miner.
+func (s *Server) verifyOwnership(ctx context.Context, poolCfg *config.Pool, addr string, proof *OwnershipProof) (string, string, int, error) {
+	if proof.Signature != "" {
+		if err := s.verifySettingsSignature(poolCfg.ID, addr, proof.Nonce, proof.Signature); err != nil {
+			if errors.Is(err, errInvalidNonce) || errors.Is(err, signature.ErrInvalidSignature) || errors.Is(err, signature.ErrUnsupportedAddress) {
+				return "", "", fiber.StatusUnauthorized, err
+			}
+			return "", "", fiber.StatusInternalServerError, err
+		}
+		ip, err := s.recentIPAddress(ctx, poolCfg.ID, addr)
+		if err != nil {
+			if errors.Is(err, errNoRecentShares) {
+				return "", "", fiber.StatusBadRequest, err
+			}
+			return "", "", fiber.StatusInternalServerError, err
+		}
+		return ownershipProofSignature, ip, fiber.StatusOK, nil
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(proof.IPAddress))
+	if ip == nil {
+		return "", "", fiber.StatusBadRequest, errInvalidIPAddress
+	}
+	ips, err := s.db.GetRecentyUsedIPAddresses(ctx, poolCfg.ID, addr)
+	if err != nil {
+		return "", "", fiber.StatusInternalServerError, err
+	}
+	if !containsIP(ips, ip) {
+		return "", "", fiber.StatusForbidden, errIPAddressNotRecent
+	}
+	return ownershipProofIP, proof.IPAddress, fiber.StatusOK, nil
+}
+
+// nativeSettings returns true if the miner settings are read and written directly in the database.
+func (s *Server) nativeSettings() bool {
+	return s.cfg.Settings != nil && s.cfg.Settings.Mode == config.SettingsModeNative
+}
+
+// getMinerSettingsNative reads the settings of the miner from the database.
+func (s *Server) getMinerSettingsNative(ctx context.Context, poolID, addr string) (*MinerSettings, int, error) {
+	settings, err := s.db.GetSettings(ctx, poolID, addr)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fiber.StatusNotFound, errNoSettings
+		}
+		return nil, fiber.StatusInternalServerError, err
+	}
+	return &MinerSettings{
+		PaymentThreshold: settings.PaymentThreshold.InexactFloat64(),
+	}, fiber.StatusOK, nil
+}
+
+// updateMinerSettingsNative validates the settings the same way as miningcore does and writes them to the database.
+// The ownership of the address has to be verified before.
+func (s *Server) updateMinerSettingsNative(ctx context.Context, poolCfg *config.Pool, addr string, req *MinerSettingsReq) (*MinerSettings, int, error) {
+	if req.Settings == nil {
+		return nil, fiber.StatusBadRequest, errInvalidSettings
+	}
+	if err := validatePaymentThreshold(poolCfg, req.Settings.PaymentThreshold); err != nil {
+		return nil, fiber.StatusBadRequest, err
+	}
+
+	err := s.db.UpdateSettings(ctx, database.MinerSettings{
+		PoolID:           poolCfg.ID,
+		Address:          addr,
+		PaymentThreshold: decimal.NewFromFloat(req.Settings.PaymentThreshold),
+	})
+	if err != nil {
+		return nil, fiber.StatusInternalServerError, err
+	}
+	return s.getMinerSettingsNative(ctx, poolCfg.ID, addr)
+}
+
+// validatePaymentThreshold checks that the threshold isn't below the minimum payout of the pool.
+func validatePaymentThreshold(poolCfg *config.Pool, threshold float64) error {
+	if threshold < poolCfg.MinPayout {
+		return fmt.Errorf("minimum payment threshold is %v", poolCfg.MinPayout)
+	}
+	return nil
+}
+
+// containsIP returns true if the list of IP addresses contains the ip.
+func containsIP(ips []string, ip net.IP) bool {
+	for _, v := range ips {
+		if parsed := net.ParseIP(v); parsed != nil && parsed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// previousPaymentThreshold returns the payment threshold of the miner before an update.
+func (s *Server) previousPaymentThreshold(ctx context.Context, poolID, addr string) (decimal.NullDecimal, error) {
+	settings, err := s.db.GetSettings(ctx, poolID, addr)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.NullDecimal{}, nil
+		}
+		return decimal.NullDecimal{}, err
+	}
+	return decimal.NewNullDecimal(settings.PaymentThreshold), nil
+}
+
+// recordSettingsChange writes a settings update to the audit log.
+// The update has already been applied, so errors are only logged.
+func (s *Server) recordSettingsChange(c *fiber.Ctx, poolID, addr string, old decimal.NullDecimal, settings *MinerSettings, proof string) {
+	err := s.db.InsertSettingsChange(c.UserContext(), database.SettingsChange{
+		PoolID:              poolID,
+		Address:             addr,
+		OldPaymentThreshold: old,
+		NewPaymentThreshold: decimal.NewFromFloat(settings.PaymentThreshold),
+		IPAddress:           c.IP(),
+		RequestedBy:         requestIdentity(c),
+		Proof:               proof,
+		Created:             time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("failed to record settings change of miner %s on pool %s: %v", addr, poolID, err)
+	}
+}
+
+// @Summary Get settings history
+// @Description Get the audit log of the settings changes from a specific miner from a specific pool.
+// @Description The IP address and identity of the requester are only included for api keys with the admin scope.
+// @Tags Miners
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param page query int false "Page (default=0)"
+// @Param pageSize query int false "PageSize (default=15)"
+// @Success 200 {object} api.SettingsHistoryRes
+// @Failure 400 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/settings/history [get]
+func (s *Server) getMinerSettingsHistoryHandler(c *fiber.Ctx) error {
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	pageCount, err := s.db.GetSettingsChangesCount(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	page, pageSize := getPageQueries(c)
+	pageCount = uint(math.Floor(float64(pageCount) / float64(pageSize)))
+
+	changes, err := s.db.PageSettingsChanges(c.UserContext(), poolCfg.ID, addr, page, pageSize)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	key := requestAPIKey(c)
+	return c.JSON(&SettingsHistoryRes{
+		Meta: &Meta{
+			Success:   true,
+			PageCount: pageCount,
+		},
+		Result: dbSettingsChangesToAPI(changes, key != nil && key.HasScope(apikey.ScopeAdmin)),
+	})
+}
+
+// dbSettingsChangesToAPI converts the audit log entries, the requester is only included if private is set.
+func dbSettingsChangesToAPI(changes []*database.SettingsChange, private bool) []*SettingsChange {
+	res := make([]*SettingsChange, len(changes))
+	for i, ch := range changes {
+		res[i] = &SettingsChange{
+			NewPaymentThreshold: ch.NewPaymentThreshold.InexactFloat64(),
+			Proof:               ch.Proof,
+			Created:             ch.Created,
+		}
+		if ch.OldPaymentThreshold.Valid {
+			old := ch.OldPaymentThreshold.Decimal.InexactFloat64()
+			res[i].OldPaymentThreshold = &old
+		}
+		if private {
+			res[i].IPAddress = ch.IPAddress
+			res[i].RequestedBy = ch.RequestedBy
+		}
+	}
+	return res
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/hex"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/cache"
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/signature"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestSettingsSignature(t *testing.T) {
+	key, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+	h := sha3.NewLegacyKeccak256()
+	h.Write(key.PubKey().SerializeUncompressed()[1:])
+	addr := "0x" + hex.EncodeToString(h.Sum(nil)[12:])
+	sign := func(msg string) string {
+		compact := ecdsa.SignCompact(key, signature.EthereumMessageHash(msg), false)
+		return hex.EncodeToString(append(compact[1:], compact[0]))
+	}
+
+	storage := cache.NewMemory(0)
+	defer storage.Close()
+	s := &Server{
+		pools:        []*config.Pool{{ID: "eth1", Name: "Ethereum", Type: "ethereum"}},
+		nonceStorage: storage,
+	}
+	app := fiber.New()
+	app.Get("/pools/:id/miners/:miner_addr/settings/nonce", s.getMinerSettingsNonceHandler)
+
+	res, body := testRequest(t, app, "/pools/eth1/miners/"+addr+"/settings/nonce", nil)
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+	var nonceRes MinerSettingsNonceRes
+	require.NoError(t, json.Unmarshal(body, &nonceRes))
+	nonce := nonceRes.Result
+	assert.Contains(t, nonce.Message, nonce.Nonce)
+	assert.Contains(t, nonce.Message, addr)
+
+	// the nonce is bound to the pool and the address
+	assert.ErrorIs(t, s.verifySettingsSignature("eth2", addr, nonce.Nonce, sign(nonce.Message)), errInvalidNonce)
+
+	res, body = testRequest(t, app, "/pools/eth1/miners/"+addr+"/settings/nonce", nil)
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+	require.NoError(t, json.Unmarshal(body, &nonceRes))
+	nonce = nonceRes.Result
+
+	assert.ErrorIs(t, s.verifySettingsSignature("eth1", addr, nonce.Nonce, sign("other message")), signature.ErrInvalidSignature)
+	// the nonce can only be used once
+	assert.ErrorIs(t, s.verifySettingsSignature("eth1", addr, nonce.Nonce, sign(nonce.Message)), errInvalidNonce)
+
+	res, body = testRequest(t, app, "/pools/eth1/miners/"+addr+"/settings/nonce", nil)
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+	require.NoError(t, json.Unmarshal(body, &nonceRes))
+	nonce = nonceRes.Result
+	assert.NoError(t, s.verifySettingsSignature("eth1", addr, nonce.Nonce, sign(nonce.Message)))
+
+	res, _ = testRequest(t, app, "/pools/eth1/miners/dero1abc/settings/nonce", nil)
+	assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+	// parallel submissions with the same nonce can't both succeed
+	res, body = testRequest(t, app, "/pools/eth1/miners/"+addr+"/settings/nonce", nil)
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+	require.NoError(t, json.Unmarshal(body, &nonceRes))
+	nonce = nonceRes.Result
+	sig := sign(nonce.Message)
+
+	const submissions = 2
+	var (
+		wg        sync.WaitGroup
+		succeeded int32
+		start     = make(chan struct{})
+	)
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if s.verifySettingsSignature("eth1", addr, nonce.Nonce, sig) == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	assert.Equal(t, int32(1), succeeded)
+}
+
+func TestSettingsNonceFull(t *testing.T) {
+	storage := cache.NewMemory(1)
+	defer storage.Close()
+	s := &Server{
+		pools:        []*config.Pool{{ID: "eth1", Name: "Ethereum", Type: "ethereum"}},
+		nonceStorage: storage,
+	}
+	app := fiber.New()
+	app.Get("/pools/:id/miners/:miner_addr/settings/nonce", s.getMinerSettingsNonceHandler)
+
+	// nonces which can't be stored are never issued
+	res, _ := testRequest(t, app, "/pools/eth1/miners/0x"+strings.Repeat("a", 40)+"/settings/nonce", nil)
+	assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, 0, storage.Size())
+}
+
+func TestNativeSettingsValidation(t *testing.T) {
+	pool := &config.Pool{ID: "eth1", MinPayout: 0.1}
+	assert.NoError(t, validatePaymentThreshold(pool, 0.1))
+	assert.NoError(t, validatePaymentThreshold(pool, 5))
+	assert.EqualError(t, validatePaymentThreshold(pool, 0.05), "minimum payment threshold is 0.1")
+
+	ips := []string{"10.0.0.1", "::ffff:192.168.1.5", "invalid"}
+	assert.True(t, containsIP(ips, net.ParseIP("10.0.0.1")))
+	assert.True(t, containsIP(ips, net.ParseIP("192.168.1.5")))
+	assert.False(t, containsIP(ips, net.ParseIP("10.0.0.2")))
+	assert.False(t, containsIP(nil, net.ParseIP("10.0.0.1")))
+}
+
+func TestSettingsChangesToAPI(t *testing.T) {
+	now := time.Now()
+	changes := []*database.SettingsChange{
+		{
+			OldPaymentThreshold: decimal.NewNullDecimal(decimal.NewFromFloat(0.5)),
+			NewPaymentThreshold: decimal.NewFromFloat(1),
+			IPAddress:           "10.0.0.1",
+			RequestedBy:         "key:abc",
+			Proof:               ownershipProofSignature,
+			Created:             now,
+		},
+		{
+			NewPaymentThreshold: decimal.NewFromFloat(0.5),
+			IPAddress:           "10.0.0.2",
+			RequestedBy:         "ip:10.0.0.2",
+			Proof:               ownershipProofIP,
+			Created:             now.Add(-time.Hour),
+		},
+	}
+
+	public := dbSettingsChangesToAPI(changes, false)
+	require.Len(t, public, 2)
+	require.NotNil(t, public[0].OldPaymentThreshold)
+	assert.Equal(t, 0.5, *public[0].OldPaymentThreshold)
+	assert.Equal(t, 1.0, public[0].NewPaymentThreshold)
+	assert.Equal(t, ownershipProofSignature, public[0].Proof)
+	assert.Empty(t, public[0].IPAddress)
+	assert.Empty(t, public[0].RequestedBy)
+	assert.Nil(t, public[1].OldPaymentThreshold)
+
+	private := dbSettingsChangesToAPI(changes, true)
+	assert.Equal(t, "10.0.0.1", private[0].IPAddress)
+	assert.Equal(t, "key:abc", private[0].RequestedBy)
+}
+
+func TestNativeSettingsEmptyAddress(t *testing.T) {
+	s := &Server{
+		cfg:   &config.API{Settings: &config.Settings{Mode: config.SettingsModeNative}},
+		pools: []*config.Pool{{ID: "eth1", Type: "ethereum"}},
+	}
+	app := fiber.New()
+	app.Get("/pools/:id/miners/:miner_addr?/settings", s.getMinerSettingsHandler)
+	app.Post("/pools/:id/miners/:miner_addr?/settings", s.postMinerSettingsHandler)
+
+	res, body := testRequest(t, app, "/pools/eth1/miners//settings", nil)
+	assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	assert.Contains(t, string(body), utils.ErrInvalidMinerAddress.Error())
+
+	req := httptest.NewRequest(fiber.MethodPost, "/pools/eth1/miners//settings", strings.NewReader(`{"ipAddress":"10.0.0.1"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+)
+
+// getShareCounts returns the share counts of the workers of a miner within the window of the share stats.
+// It returns nil if the shares aren't counted.
+func (s *Server) getShareCounts(ctx context.Context, poolID, miner string) (map[string]*ShareCounts, error) {
+	if s.shareStatsWindow <= 0 {
+		return nil, nil
+	}
+	since := time.Now().UTC().Add(-s.shareStatsWindow).Truncate(time.Hour)
+	stats, err := s.db.GetWorkersShareStats(ctx, poolID, miner, since)
+	if err != nil {
+		return nil, err
+	}
+	return dbShareStatsToAPIShareCounts(stats), nil
+}
+
+func dbShareStatsToAPIShareCounts(stats []*database.ShareStats) map[string]*ShareCounts {
+	counts := make(map[string]*ShareCounts, len(stats))
+	for _, st := range stats {
+		counts[st.Worker] = newShareCounts(st.Accepted, st.Rejected, st.Stale)
+	}
+	return counts
+}
+
+// shareCountsOf returns the share counts of a worker, workers without counted shares have zero counts.
+func shareCountsOf(counts map[string]*ShareCounts, worker string) *ShareCounts {
+	if c, ok := counts[worker]; ok {
+		return c
+	}
+	return &ShareCounts{}
+}
+
+// sumShareCounts sums up the share counts of all workers.
+func sumShareCounts(counts map[string]*ShareCounts) *ShareCounts {
+	var accepted, rejected, stale int64
+	for _, c := range counts {
+		accepted += c.Accepted
+		rejected += c.Rejected
+		stale += c.Stale
+	}
+	return newShareCounts(accepted, rejected, stale)
+}
+
+func newShareCounts(accepted, rejected, stale int64) *ShareCounts {
+	c := &ShareCounts{
+		Accepted: accepted,
+		Rejected: rejected,
+		Stale:    stale,
+	}
+	if total := accepted + rejected + stale; total > 0 {
+		c.StaleRatio = float64(stale) / float64(total)
+	}
+	return c
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"strings"
 	"time"
@@ -131,7 +132,7 @@ func getPerformanceRange(mode database.SampleRange) (start, end time.Time) {
 
 	case database.RangeMonth:
 		end = end.Add(-time.Second)
-		start = start.Add(-duration.Month)
+		start = end.Add(-duration.Month)
 		return
 
 	default:
@@ -145,13 +146,137 @@ func getPerformanceRange(mode database.SampleRange) (start, end time.Time) {
 	}
 }
 
-func (s *Server) getMinerPerformanceInternal(ctx context.Context, mode database.SampleRange, poolCfg *config.Pool, addr string) ([]*PerformanceStats, error) {
-	start, end := getPerformanceRange(mode)
-	stats, err := s.db.GetMinerPerformanceBetweenTenMinutely(ctx, poolCfg.ID, addr, start, end)
+// max ranges which are aggregated in 10 minute and hourly buckets if no interval is requested
+const (
+	performanceTenMinutesMaxRange = time.Hour * 48
+	performanceHourlyMaxRange     = time.Hour * 24 * 14
+	defaultPerformanceMaxPoints   = 1000
+)
+
+// performanceIntervals are the supported intervals, ordered by their length.
+var performanceIntervals = []database.SampleInterval{
+	database.IntervalTenMinutes,
+	database.IntervalHour,
+	database.IntervalDay,
+	database.IntervalWeek,
+}
+
+var errTooManyPerformancePoints = errors.New("too many samples in the range, use a larger interval")
+
+// getPerformanceQuery returns the range and the interval of a performance request.
+// The given range and interval are the defaults, they can be overwritten with RFC3339 start and end times and an interval.
+// Without a default or a requested interval, it is chosen by the length of the range.
+// Intervals which aren't requested explicitly are increased until the range fits into the max number of samples.
+func (s *Server) getPerformanceQuery(c *fiber.Ctx, start, end time.Time, interval database.SampleInterval) (time.Time, time.Time, database.SampleInterval, error) {
+	var err error
+	if v := c.Query("end"); v != "" {
+		span := end.Sub(start)
+		if end, err = time.Parse(time.RFC3339, v); err != nil {
+			return start, end, interval, utils.ErrInvalidRange
+		}
+		start = end.Add(-span)
+	}
+	if v := c.Query("start"); v != "" {
+		if start, err = time.Parse(time.RFC3339, v); err != nil {
+			return start, end, interval, utils.ErrInvalidRange
+		}
+	}
+	if !start.Before(end) {
+		return start, end, interval, utils.ErrInvalidRange
+	}
+
+	maxPoints := s.performanceMaxPoints()
+	if v := c.Query("interval"); v != "" {
+		if interval, err = parsePerformanceInterval(v); err != nil {
+			return start, end, interval, err
+		}
+		if performancePoints(start, end, interval) > maxPoints {
+			return start, end, interval, errTooManyPerformancePoints
+		}
+		return start, end, interval, nil
+	}
+
+	if interval == "" {
+		interval = autoPerformanceInterval(end.Sub(start))
+	}
+	for _, i := range performanceIntervals {
+		if i.Duration() < interval.Duration() {
+			continue
+		}
+		if performancePoints(start, end, i) <= maxPoints {
+			return start, end, i, nil
+		}
+	}
+	return start, end, interval, errTooManyPerformancePoints
+}
+
+func (s *Server) performanceMaxPoints() int {
+	if s.cfg != nil && s.cfg.PerformanceMaxPoints > 0 {
+		return s.cfg.PerformanceMaxPoints
+	}
+	return defaultPerformanceMaxPoints
+}
+
+func parsePerformanceInterval(v string) (database.SampleInterval, error) {
+	switch v {
+	case "10m":
+		return database.IntervalTenMinutes, nil
+	case "1h":
+		return database.IntervalHour, nil
+	case "1d":
+		return database.IntervalDay, nil
+	case "1w":
+		return database.IntervalWeek, nil
+	default:
+		return "", utils.ErrInvalidInterval
+	}
+}
+
+// autoPerformanceInterval returns the interval for a range, so that long ranges don't return thousands of samples.
+func autoPerformanceInterval(span time.Duration) database.SampleInterval {
+	switch {
+	case span <= performanceTenMinutesMaxRange:
+		return database.IntervalTenMinutes
+	case span <= performanceHourlyMaxRange:
+		return database.IntervalHour
+	default:
+		return database.IntervalDay
+	}
+}
+
+// performancePoints returns the number of buckets of the interval which overlap with the range.
+func performancePoints(start, end time.Time, interval database.SampleInterval) int {
+	step := interval.Duration()
+	return int(end.Sub(start.Truncate(step))/step) + 1
+}
+
+// fillPerformanceGaps adds empty samples for the buckets of the range without any samples.
+// The samples have to be ordered by their creation time, the buckets are aligned to UTC like in the database.
+func fillPerformanceGaps[T any](stats []T, created func(T) time.Time, empty func(time.Time) T, start, end time.Time, interval database.SampleInterval) []T {
+	step := interval.Duration()
+	res := make([]T, 0, len(stats))
+	i := 0
+	for t := start.Truncate(step).UTC(); !t.After(end); t = t.Add(step) {
+		next := t.Add(step)
+		found := false
+		for i < len(stats) && created(stats[i]).Before(next) {
+			res = append(res, stats[i])
+			i++
+			found = true
+		}
+		if !found {
+			res = append(res, empty(t))
+		}
+	}
+	return append(res, stats[i:]...)
+}
+
+func (s *Server) getMinerPerformanceInternal(ctx context.Context, start, end time.Time, interval database.SampleInterval, poolCfg *config.Pool, addr string) ([]*PerformanceStats, error) {
+	stats, err := s.db.GetMinerPerformanceBetween(ctx, poolCfg.ID, addr, interval, start, end)
 	if err != nil {
 		return nil, err
 	}
-	return dbPerformanceToAPIPerformance(stats), nil
+	return dbPerformanceToAPIPerformance(fillPerformanceStatsGaps(stats, start, end, interval)), nil
 }
 
 func dbPerformanceToAPIPerformance(stats []*database.PerformanceStatsEntity) []*PerformanceStats {
@@ -168,21 +293,20 @@ func dbPerformanceToAPIPerformance(stats []*database.PerformanceStatsEntity) []*
 	return res
 }
 
-func (s *Server) getWorkerPerformanceInternal(ctx context.Context, mode database.SampleRange, poolCfg *config.Pool, addr, worker string) ([]*PerformanceStats, error) {
-	start, end := getPerformanceRange(mode)
-	stats, err := s.db.GetWorkerPerformanceBetweenTenMinutely(ctx, poolCfg.ID, addr, worker, start, end)
+func (s *Server) getWorkerPerformanceInternal(ctx context.Context, start, end time.Time, interval database.SampleInterval, poolCfg *config.Pool, addr, worker string) ([]*PerformanceStats, error) {
+	stats, err := s.db.GetWorkerPerformanceBetween(ctx, poolCfg.ID, addr, worker, interval, start, end)
 	if err != nil {
 		return nil, err
 	}
-	res := make([]*PerformanceStats, len(stats))
-	for i, s := range stats {
-		res[i] = &PerformanceStats{
-			Created:          s.Created,
-			Hashrate:         utils.ValueOrZero(s.Hashrate),
-			ReportedHashrate: utils.ValueOrZero(s.ReportedHashrate),
-			SharesPerSecond:  utils.ValueOrZero(s.SharesPerSecond),
-			WorkersOnline:    s.WorkersOnline,
-		}
-	}
-	return res, nil
+	return dbPerformanceToAPIPerformance(fillPerformanceStatsGaps(stats, start, end, interval)), nil
+}
+
+func fillPerformanceStatsGaps(stats []*database.PerformanceStatsEntity, start, end time.Time, interval database.SampleInterval) []*database.PerformanceStatsEntity {
+	return fillPerformanceGaps(stats,
+		func(s *database.PerformanceStatsEntity) time.Time { return s.Created },
+		func(t time.Time) *database.PerformanceStatsEntity {
+			return &database.PerformanceStatsEntity{Created: t}
+		},
+		start, end, interval,
+	)
 }
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPerformanceQuery(t *testing.T) {
+	type result struct {
+		start, end time.Time
+		interval   database.SampleInterval
+		err        error
+	}
+	var got result
+	s := &Server{}
+	app := fiber.New()
+	app.Get("/performance", func(c *fiber.Ctx) error {
+		start, end := getPerformanceRange(getPerformanceModeQuery(c))
+		got.start, got.end, got.interval, got.err = s.getPerformanceQuery(c, start, end, "")
+		return nil
+	})
+
+	testRequest(t, app, "/performance", nil)
+	assert.NoError(t, got.err)
+	assert.Equal(t, time.Hour*24, got.end.Sub(got.start))
+	assert.Equal(t, database.IntervalTenMinutes, got.interval)
+
+	testRequest(t, app, "/performance?start=2022-09-01T00:00:00Z&end=2022-09-08T00:00:00Z", nil)
+	assert.NoError(t, got.err)
+	assert.Equal(t, time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC), got.start)
+	assert.Equal(t, time.Date(2022, 9, 8, 0, 0, 0, 0, time.UTC), got.end)
+	assert.Equal(t, database.IntervalHour, got.interval)
+
+	testRequest(t, app, "/performance?start=2022-08-01T00:00:00Z&end=2022-09-01T00:00:00Z", nil)
+	assert.Equal(t, database.IntervalDay, got.interval)
+
+	testRequest(t, app, "/performance?start=2022-08-01T00:00:00Z&end=2022-09-01T00:00:00Z&interval=1h", nil)
+	assert.NoError(t, got.err)
+	assert.Equal(t, database.IntervalHour, got.interval)
+
+	// only the end moves the default range
+	testRequest(t, app, "/performance?end=2022-09-01T12:00:00%2B02:00", nil)
+	assert.NoError(t, got.err)
+	assert.Equal(t, time.Hour*24, got.end.Sub(got.start))
+
+	testRequest(t, app, "/performance?perfMode=month", nil)
+	assert.NoError(t, got.err)
+	assert.Equal(t, database.IntervalDay, got.interval)
+	assert.InDelta(t, (time.Hour * 24 * 30).Seconds(), got.end.Sub(got.start).Seconds(), (time.Hour * 24).Seconds())
+
+	// the interval is increased to stay below the max number of samples
+	testRequest(t, app, "/performance?start=2000-01-01T00:00:00Z&end=2010-01-01T00:00:00Z", nil)
+	assert.NoError(t, got.err)
+	assert.Equal(t, database.IntervalWeek, got.interval)
+	testRequest(t, app, "/performance?start=2022-08-01T00:00:00Z&end=2022-09-01T00:00:00Z&interval=10m", nil)
+	assert.ErrorIs(t, got.err, errTooManyPerformancePoints)
+	testRequest(t, app, "/performance?start=1900-01-01T00:00:00Z&end=2022-09-01T00:00:00Z", nil)
+	assert.ErrorIs(t, got.err, errTooManyPerformancePoints)
+
+	testRequest(t, app, "/performance?interval=5m", nil)
+	assert.ErrorIs(t, got.err, utils.ErrInvalidInterval)
+	testRequest(t, app, "/performance?start=yesterday", nil)
+	assert.ErrorIs(t, got.err, utils.ErrInvalidRange)
+	testRequest(t, app, "/performance?start=2022-09-08T00:00:00Z&end=2022-09-01T00:00:00Z", nil)
+	assert.ErrorIs(t, got.err, utils.ErrInvalidRange)
+}
+
+func TestFillPerformanceGaps(t *testing.T) {
+	start := time.Date(2022, 9, 1, 10, 5, 0, 0, time.UTC)
+	end := time.Date(2022, 9, 1, 14, 30, 0, 0, time.UTC)
+	hashrate := 1.0
+	stats := []*database.PerformanceStatsEntity{
+		{Created: time.Date(2022, 9, 1, 11, 0, 0, 0, time.UTC), Hashrate: &hashrate},
+		{Created: time.Date(2022, 9, 1, 13, 0, 0, 0, time.UTC), Hashrate: &hashrate},
+	}
+
+	filled := fillPerformanceStatsGaps(stats, start, end, database.IntervalHour)
+	created := make([]int, len(filled))
+	for i, s := range filled {
+		created[i] = s.Created.Hour()
+	}
+	assert.Equal(t, []int{10, 11, 12, 13, 14}, created)
+	assert.Nil(t, filled[0].Hashrate)
+	assert.Equal(t, stats[0], filled[1])
+	assert.Equal(t, stats[1], filled[3])
+
+	// weeks start on monday like in postgres
+	filled = fillPerformanceStatsGaps(nil, start, end, database.IntervalWeek)
+	assert.Len(t, filled, 1)
+	assert.Equal(t, time.Monday, filled[0].Created.Weekday())
+	assert.Equal(t, time.Date(2022, 8, 29, 0, 0, 0, 0, time.UTC), filled[0].Created)
+}
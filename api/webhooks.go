@@ -0,0 +1,465 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/1oopio/phantomias/webhook"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errWebhooksDisabled      = errors.New("webhooks are disabled")
+	errTooManyWebhooks       = errors.New("too many webhooks")
+	errInvalidWebhookID      = errors.New("invalid webhook id")
+	errInvalidDeliveryID     = errors.New("invalid delivery id")
+	errInvalidDeliveryStatus = errors.New("invalid delivery status")
+)
+
+// @Summary Get webhooks
+// @Description Get the webhooks of a specific miner from a specific pool.
+// @Description The urls are masked for requests without an api key with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Success 200 {object} api.WebhooksRes
+// @Failure 400 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/webhooks [get]
+func (s *Server) getMinerWebhooksHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	webhooks, err := s.db.GetMinerWebhooks(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	key := requestAPIKey(c)
+	private := key != nil && key.HasScope(apikey.ScopeAdmin)
+	res := make([]*Webhook, len(webhooks))
+	for i, wh := range webhooks {
+		res[i] = dbWebhookToAPI(wh, private)
+	}
+	return c.JSON(&WebhooksRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: res,
+	})
+}
+
+// @Summary Create a webhook
+// @Description Create a webhook which receives the block and payment events of a specific miner from a specific pool.
+// @Description The ownership of the address is proven the same way as for settings updates.
+// @Description The secret of the signatures is only returned in this response.
+// @Tags Webhooks
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param webhook body api.WebhookReq true "Url and events incl. the proof of ownership"
+// @Success 200 {object} api.WebhookRes
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/webhooks [post]
+func (s *Server) postMinerWebhookHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	var req WebhookReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+
+	wh, err := s.newWebhook(req.URL, req.Events)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+
+	proof, _, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req.OwnershipProof)
+	if err != nil {
+		return handleAPIError(c, code, err)
+	}
+
+	webhooks, err := s.db.GetMinerWebhooks(c.UserContext(), poolCfg.ID, addr)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	if len(webhooks) >= s.webhooks.MaxPerMiner() {
+		return handleAPIError(c, fiber.StatusBadRequest, errTooManyWebhooks)
+	}
+
+	wh.PoolID = poolCfg.ID
+	wh.Address = addr
+	wh.ID, err = s.db.CreateWebhook(c.UserContext(), *wh)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] webhook %d of miner %s on pool %s created by %s with %s proof", wh.ID, addr, poolCfg.ID, requestIdentity(c), proof)
+	return c.JSON(&WebhookRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: dbWebhookToAPIWithSecret(wh),
+	})
+}
+
+// @Summary Delete a webhook
+// @Description Delete a webhook of a specific miner from a specific pool incl. its deliveries.
+// @Description The ownership of the address is proven the same way as for settings updates, it isn't required for api keys with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Param pool_id path string true "ID of the pool"
+// @Param miner_addr path string true "Address of the miner"
+// @Param webhook_id path int true "ID of the webhook"
+// @Param proof body api.OwnershipProof false "Proof of ownership"
+// @Success 200 {object} api.Meta
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/pools/{pool_id}/miners/{miner_addr}/webhooks/{webhook_id} [delete]
+func (s *Server) deleteMinerWebhookHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	poolCfg := getPoolCfgByID(c.Params("id"), s.pools)
+	if poolCfg == nil {
+		return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+	}
+	addr := getMinerAddressParam(c, poolCfg)
+	if addr == "" {
+		return handleAPIError(c, fiber.StatusBadRequest, utils.ErrInvalidMinerAddress)
+	}
+	id, err := strconv.ParseInt(c.Params("webhook_id"), 10, 64)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, errInvalidWebhookID)
+	}
+
+	proof := "admin"
+	if key := requestAPIKey(c); key == nil || !key.HasScope(apikey.ScopeAdmin) {
+		var req OwnershipProof
+		if err := c.BodyParser(&req); err != nil {
+			return handleAPIError(c, fiber.StatusBadRequest, err)
+		}
+		method, _, code, err := s.verifyOwnership(c.UserContext(), poolCfg, addr, &req)
+		if err != nil {
+			return handleAPIError(c, code, err)
+		}
+		proof = method
+	}
+
+	if err := s.db.DeleteWebhook(c.UserContext(), poolCfg.ID, addr, id); err != nil {
+		if errors.Is(err, database.ErrWebhookNotFound) {
+			return handleAPIError(c, fiber.StatusNotFound, err)
+		}
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] webhook %d of miner %s on pool %s deleted by %s with %s proof", id, addr, poolCfg.ID, requestIdentity(c), proof)
+	return c.JSON(&Meta{
+		Success: true,
+	})
+}
+
+// @Summary Get all webhooks
+// @Description Get the webhooks of all pools and miners, requires an api key with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {object} api.WebhooksRes
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/webhooks [get]
+func (s *Server) getWebhooksHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	webhooks, err := s.db.GetWebhooks(c.UserContext())
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	res := make([]*Webhook, len(webhooks))
+	for i, wh := range webhooks {
+		res[i] = dbWebhookToAPI(wh, true)
+	}
+	return c.JSON(&WebhooksRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: res,
+	})
+}
+
+// @Summary Create a webhook for any pool and miner
+// @Description Create a webhook which receives the events of a pool or of all pools, optionally filtered by the address of a miner.
+// @Description Requires an api key with the admin scope. The secret of the signatures is only returned in this response.
+// @Tags Webhooks
+// @Produce json
+// @Param webhook body api.AdminWebhookReq true "Pool, address, url and events"
+// @Success 200 {object} api.WebhookRes
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/webhooks [post]
+func (s *Server) postWebhookHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	var req AdminWebhookReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	wh, err := s.newWebhook(req.URL, req.Events)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, err)
+	}
+	if req.PoolID != "" {
+		poolCfg := getPoolCfgByID(req.PoolID, s.pools)
+		if poolCfg == nil {
+			return handleAPIError(c, fiber.StatusNotFound, utils.ErrPoolNotFound)
+		}
+		wh.PoolID = poolCfg.ID
+	}
+	wh.Address = strings.TrimSpace(req.Address)
+
+	wh.ID, err = s.db.CreateWebhook(c.UserContext(), *wh)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] webhook %d created by %s", wh.ID, requestIdentity(c))
+	return c.JSON(&WebhookRes{
+		Meta: &Meta{
+			Success: true,
+		},
+		Result: dbWebhookToAPIWithSecret(wh),
+	})
+}
+
+// @Summary Delete any webhook
+// @Description Delete a webhook incl. its deliveries, requires an api key with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Param webhook_id path int true "ID of the webhook"
+// @Success 200 {object} api.Meta
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/webhooks/{webhook_id} [delete]
+func (s *Server) deleteWebhookHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	id, err := strconv.ParseInt(c.Params("webhook_id"), 10, 64)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, errInvalidWebhookID)
+	}
+	if err := s.db.DeleteWebhook(c.UserContext(), "", "", id); err != nil {
+		if errors.Is(err, database.ErrWebhookNotFound) {
+			return handleAPIError(c, fiber.StatusNotFound, err)
+		}
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] webhook %d deleted by %s", id, requestIdentity(c))
+	return c.JSON(&Meta{
+		Success: true,
+	})
+}
+
+// @Summary Get the deliveries of a webhook
+// @Description Get the delivery log of a webhook, requires an api key with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Param webhook_id path int true "ID of the webhook"
+// @Param status query string false "Status of the deliveries (pending, delivered, failed)"
+// @Param page query int false "Page (default=0)"
+// @Param pageSize query int false "PageSize (default=15)"
+// @Success 200 {object} api.WebhookDeliveriesRes
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/webhooks/{webhook_id}/deliveries [get]
+func (s *Server) getWebhookDeliveriesHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	id, err := strconv.ParseInt(c.Params("webhook_id"), 10, 64)
+	if err != nil || id <= 0 {
+		return handleAPIError(c, fiber.StatusBadRequest, errInvalidWebhookID)
+	}
+	status := c.Query("status")
+	switch status {
+	case "", database.DeliveryStatusPending, database.DeliveryStatusDelivered, database.DeliveryStatusFailed:
+	default:
+		return handleAPIError(c, fiber.StatusBadRequest, errInvalidDeliveryStatus)
+	}
+	return s.sendWebhookDeliveries(c, id, status)
+}
+
+// @Summary Get the dead letters
+// @Description Get the deliveries of all webhooks which failed after all attempts, requires an api key with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Param page query int false "Page (default=0)"
+// @Param pageSize query int false "PageSize (default=15)"
+// @Success 200 {object} api.WebhookDeliveriesRes
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/webhooks/deadletters [get]
+func (s *Server) getWebhookDeadLettersHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	return s.sendWebhookDeliveries(c, 0, database.DeliveryStatusFailed)
+}
+
+// @Summary Retry a dead letter
+// @Description Queue a delivery which failed after all attempts again, requires an api key with the admin scope.
+// @Tags Webhooks
+// @Produce json
+// @Param delivery_id path int true "ID of the delivery"
+// @Success 200 {object} api.Meta
+// @Failure 400 {object} utils.APIError
+// @Failure 401 {object} utils.APIError
+// @Failure 403 {object} utils.APIError
+// @Failure 404 {object} utils.APIError
+// @Router /api/v1/webhooks/deliveries/{delivery_id}/retry [post]
+func (s *Server) postWebhookDeliveryRetryHandler(c *fiber.Ctx) error {
+	if s.webhooks == nil {
+		return handleAPIError(c, fiber.StatusNotFound, errWebhooksDisabled)
+	}
+	id, err := strconv.ParseInt(c.Params("delivery_id"), 10, 64)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusBadRequest, errInvalidDeliveryID)
+	}
+	if err := s.db.RetryWebhookDelivery(c.UserContext(), id, time.Now().UTC()); err != nil {
+		if errors.Is(err, database.ErrWebhookDeliveryNotFound) {
+			return handleAPIError(c, fiber.StatusNotFound, err)
+		}
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	log.Printf("[audit] webhook delivery %d retried by %s", id, requestIdentity(c))
+	return c.JSON(&Meta{
+		Success: true,
+	})
+}
+
+func (s *Server) sendWebhookDeliveries(c *fiber.Ctx, webhookID int64, status string) error {
+	pageCount, err := s.db.GetWebhookDeliveriesCount(c.UserContext(), webhookID, status)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+
+	page, pageSize := getPageQueries(c)
+	pageCount = uint(math.Floor(float64(pageCount) / float64(pageSize)))
+
+	deliveries, err := s.db.PageWebhookDeliveries(c.UserContext(), webhookID, status, page, pageSize)
+	if err != nil {
+		return handleAPIError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(&WebhookDeliveriesRes{
+		Meta: &Meta{
+			Success:   true,
+			PageCount: pageCount,
+		},
+		Result: dbWebhookDeliveriesToAPI(deliveries),
+	})
+}
+
+// newWebhook validates the url and the events and generates the secret of a new webhook.
+func (s *Server) newWebhook(target string, events []string) (*database.Webhook, error) {
+	target = strings.TrimSpace(target)
+	if err := s.webhooks.ValidateURL(target); err != nil {
+		return nil, err
+	}
+	list, err := webhook.ParseEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &database.Webhook{
+		URL:     target,
+		Secret:  secret,
+		Events:  list,
+		Created: time.Now().UTC(),
+	}, nil
+}
+
+// dbWebhookToAPI converts a webhook without its secret, the url is masked unless private is set.
+func dbWebhookToAPI(wh *database.Webhook, private bool) *Webhook {
+	target := wh.URL
+	if !private {
+		target = maskTarget(target)
+	}
+	return &Webhook{
+		ID:      wh.ID,
+		PoolID:  wh.PoolID,
+		Address: wh.Address,
+		URL:     target,
+		Events:  strings.Split(wh.Events, ","),
+		Created: wh.Created,
+	}
+}
+
+// dbWebhookToAPIWithSecret converts a newly created webhook incl. its secret.
+func dbWebhookToAPIWithSecret(wh *database.Webhook) *Webhook {
+	res := dbWebhookToAPI(wh, true)
+	res.Secret = wh.Secret
+	return res
+}
+
+func dbWebhookDeliveriesToAPI(deliveries []*database.WebhookDelivery) []*WebhookDelivery {
+	res := make([]*WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		res[i] = &WebhookDelivery{
+			ID:             d.ID,
+			WebhookID:      d.WebhookID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Payload:        []byte(d.Payload),
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastAttempt:    d.LastAttempt,
+			ResponseStatus: d.ResponseStatus,
+			LastError:      d.LastError,
+			Created:        d.Created,
+		}
+		if d.Status == database.DeliveryStatusPending {
+			next := d.NextAttempt
+			res[i].NextAttempt = &next
+		}
+	}
+	return res
+}
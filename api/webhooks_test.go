@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/webhook"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhook(t *testing.T) {
+	s := &Server{webhooks: webhook.New(nil)}
+	defer s.webhooks.Close()
+
+	wh, err := s.newWebhook(" https://example.com/hook ", []string{"payment.sent", "block.found"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", wh.URL)
+	assert.Equal(t, "block.found,payment.sent", wh.Events)
+	assert.Len(t, wh.Secret, 64)
+
+	_, err = s.newWebhook("http://127.0.0.1/hook", []string{"block.found"})
+	assert.ErrorIs(t, err, webhook.ErrPrivateTarget)
+	_, err = s.newWebhook("https://example.com/hook", nil)
+	assert.ErrorIs(t, err, webhook.ErrInvalidEvents)
+}
+
+func TestWebhooksToAPI(t *testing.T) {
+	wh := &database.Webhook{
+		ID:      1,
+		PoolID:  "eth1",
+		Address: "0xabc",
+		URL:     "https://example.com/hook",
+		Secret:  "secret",
+		Events:  "block.found,payment.sent",
+		Created: time.Now(),
+	}
+	public := dbWebhookToAPI(wh, false)
+	assert.Equal(t, "htt***ook", public.URL)
+	assert.Equal(t, []string{"block.found", "payment.sent"}, public.Events)
+	assert.Empty(t, public.Secret)
+	assert.Equal(t, "https://example.com/hook", dbWebhookToAPI(wh, true).URL)
+	assert.Equal(t, "secret", dbWebhookToAPIWithSecret(wh).Secret)
+
+	status := 502
+	deliveries := dbWebhookDeliveriesToAPI([]*database.WebhookDelivery{
+		{ID: 1, Payload: `{"id":"payment.sent:eth1:1"}`, Status: database.DeliveryStatusPending, NextAttempt: time.Now()},
+		{ID: 2, Payload: `{}`, Status: database.DeliveryStatusFailed, Attempts: 8, ResponseStatus: &status},
+	})
+	require.Len(t, deliveries, 2)
+	assert.NotNil(t, deliveries[0].NextAttempt)
+	assert.Nil(t, deliveries[1].NextAttempt)
+	b, err := json.Marshal(deliveries[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"payload":{"id":"payment.sent:eth1:1"}`)
+}
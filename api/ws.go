@@ -2,59 +2,191 @@ package api
 
 import (
 	"context"
+	"errors"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/1oopio/phantomias/config"
 	"github.com/gofiber/websocket/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const wsWriteWait = time.Second * 10
+
+var (
+	errWSTooManyConnections      = errors.New("too many websocket connections")
+	errWSTooManyConnectionsPerIP = errors.New("too many websocket connections from this ip")
+	errWSOriginNotAllowed        = errors.New("origin not allowed")
 )
 
 type wsClient struct {
+	conn      *websocket.Conn
 	ip        string
 	isClosing bool
 	mu        sync.Mutex
 }
 
+// write sends a message to the client.
+// It's safe to be called concurrently.
+func (c *wsClient) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosing {
+		return nil
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := c.conn.WriteMessage(messageType, data); err != nil {
+		c.isClosing = true
+		return err
+	}
+	return nil
+}
+
+func (c *wsClient) ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosing {
+		return nil
+	}
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+}
+
 type wsRelay struct {
 	ctx        context.Context
+	cfg        *config.WS
 	clients    map[*websocket.Conn]*wsClient
-	register   chan *websocket.Conn
+	register   chan *wsClient
 	broadcast  chan []byte
 	unregister chan *websocket.Conn
+
+	mu          sync.Mutex
+	connections int
+	connsPerIP  map[string]int
+
+	connectedClients prometheus.Gauge
+	connectedIPs     prometheus.Gauge
+	rejectedClients  *prometheus.CounterVec
 }
 
-func newWSRelay(ctx context.Context) *wsRelay {
+func newWSRelay(ctx context.Context, cfg *config.WS) *wsRelay {
+	if cfg == nil {
+		cfg = &config.WS{}
+	}
 	return &wsRelay{
 		ctx:        ctx,
+		cfg:        cfg,
 		clients:    make(map[*websocket.Conn]*wsClient),
-		register:   make(chan *websocket.Conn),
+		register:   make(chan *wsClient),
 		broadcast:  make(chan []byte),
 		unregister: make(chan *websocket.Conn),
+		connsPerIP: make(map[string]int),
+		connectedClients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "phantomias",
+			Subsystem: "ws",
+			Name:      "connected_clients",
+			Help:      "Number of connected websocket clients.",
+		}),
+		connectedIPs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "phantomias",
+			Subsystem: "ws",
+			Name:      "connected_ips",
+			Help:      "Number of distinct IPs with at least one connected websocket client.",
+		}),
+		rejectedClients: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "phantomias",
+			Subsystem: "ws",
+			Name:      "rejected_clients_total",
+			Help:      "Number of rejected websocket clients by reason.",
+		}, []string{"reason"}),
+	}
+}
+
+// collectors returns the prometheus collectors of the relay.
+func (w *wsRelay) collectors() []prometheus.Collector {
+	return []prometheus.Collector{w.connectedClients, w.connectedIPs, w.rejectedClients}
+}
+
+// allowed checks if a new client from the given ip would currently be accepted.
+func (w *wsRelay) allowed(ip string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.checkLimits(ip)
+}
+
+func (w *wsRelay) checkLimits(ip string) error {
+	if w.cfg.MaxConnections > 0 && w.connections >= w.cfg.MaxConnections {
+		return errWSTooManyConnections
+	}
+	if w.cfg.MaxConnectionsPerIP > 0 && w.connsPerIP[ip] >= w.cfg.MaxConnectionsPerIP {
+		return errWSTooManyConnectionsPerIP
+	}
+	return nil
+}
+
+// acquire reserves a connection slot for the given ip.
+func (w *wsRelay) acquire(ip string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.checkLimits(ip); err != nil {
+		return err
+	}
+	w.connections++
+	w.connsPerIP[ip]++
+	w.connectedClients.Set(float64(w.connections))
+	w.connectedIPs.Set(float64(len(w.connsPerIP)))
+	return nil
+}
+
+// release frees a connection slot previously reserved by acquire.
+func (w *wsRelay) release(ip string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.connections--
+	if w.connsPerIP[ip]--; w.connsPerIP[ip] <= 0 {
+		delete(w.connsPerIP, ip)
 	}
+	w.connectedClients.Set(float64(w.connections))
+	w.connectedIPs.Set(float64(len(w.connsPerIP)))
+}
+
+func (w *wsRelay) reject(err error) {
+	reason := "unknown"
+	switch {
+	case errors.Is(err, errWSTooManyConnections):
+		reason = "max_connections"
+	case errors.Is(err, errWSTooManyConnectionsPerIP):
+		reason = "max_connections_per_ip"
+	case errors.Is(err, errWSOriginNotAllowed):
+		reason = "origin"
+	}
+	w.rejectedClients.WithLabelValues(reason).Inc()
+}
+
+func (w *wsRelay) originAllowed(origin string) bool {
+	if len(w.cfg.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, o := range w.cfg.AllowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
 }
 
 func (w *wsRelay) hub() {
 	for {
 		select {
-		case conn := <-w.register:
-			ip := conn.RemoteAddr().String()
-			w.clients[conn] = &wsClient{
-				ip: ip,
-			}
-			log.Printf("client registered with IP: %s", ip)
+		case client := <-w.register:
+			w.clients[client.conn] = client
+			log.Printf("client registered with IP: %s", client.ip)
 
 		case msg := <-w.broadcast:
 			for conn, client := range w.clients {
 				go func(conn *websocket.Conn, client *wsClient) { // send to each client in parallel so we don't block on a slow client
-					client.mu.Lock()
-					defer client.mu.Unlock()
-					if client.isClosing {
-						return
-					}
-
-					if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-						client.isClosing = true
+					if err := client.write(websocket.TextMessage, msg); err != nil {
 						log.Println("write error:", err)
-						conn.WriteMessage(websocket.CloseMessage, []byte{})
 						conn.Close()
 						w.unregister <- conn
 					}
@@ -74,12 +206,53 @@ func (w *wsRelay) hub() {
 }
 
 func (s *Server) wsHandler(c *websocket.Conn) {
+	ip, _ := c.Locals("ip").(string)
+	if err := s.wsRelay.acquire(ip); err != nil {
+		s.wsRelay.reject(err)
+		c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()), time.Now().Add(wsWriteWait))
+		c.Close()
+		return
+	}
+
+	client := &wsClient{
+		conn: c,
+		ip:   ip,
+	}
+	done := make(chan struct{})
 	defer func() {
+		close(done)
+		client.mu.Lock()
+		client.isClosing = true
+		client.mu.Unlock()
 		s.wsRelay.unregister <- c
+		s.wsRelay.release(ip)
 		c.Close()
 	}()
 
-	s.wsRelay.register <- c
+	s.wsRelay.register <- client
+
+	if idle := s.wsRelay.cfg.IdleTimeout; idle > 0 {
+		c.SetReadDeadline(time.Now().Add(idle))
+		c.SetPongHandler(func(string) error {
+			return c.SetReadDeadline(time.Now().Add(idle))
+		})
+	}
+	if interval := s.wsRelay.cfg.PingInterval; interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := client.ping(); err != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
 
 	for {
 		_, _, err := c.ReadMessage()
@@ -89,5 +262,8 @@ func (s *Server) wsHandler(c *websocket.Conn) {
 			}
 			return
 		}
+		if idle := s.wsRelay.cfg.IdleTimeout; idle > 0 {
+			c.SetReadDeadline(time.Now().Add(idle))
+		}
 	}
 }
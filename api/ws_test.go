@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSRelayLimits(t *testing.T) {
+	w := newWSRelay(context.Background(), &config.WS{MaxConnections: 3, MaxConnectionsPerIP: 2})
+
+	require.NoError(t, w.acquire("10.0.0.1"))
+	require.NoError(t, w.acquire("10.0.0.1"))
+	assert.ErrorIs(t, w.allowed("10.0.0.1"), errWSTooManyConnectionsPerIP)
+	assert.ErrorIs(t, w.acquire("10.0.0.1"), errWSTooManyConnectionsPerIP)
+	require.NoError(t, w.acquire("10.0.0.2"))
+	assert.ErrorIs(t, w.acquire("10.0.0.3"), errWSTooManyConnections)
+	assert.Equal(t, 3.0, testutil.ToFloat64(w.connectedClients))
+	assert.Equal(t, 2.0, testutil.ToFloat64(w.connectedIPs))
+
+	w.release("10.0.0.1")
+	assert.NoError(t, w.allowed("10.0.0.1"))
+	w.release("10.0.0.2")
+	assert.NotContains(t, w.connsPerIP, "10.0.0.2")
+	assert.Equal(t, 1.0, testutil.ToFloat64(w.connectedClients))
+	assert.Equal(t, 1.0, testutil.ToFloat64(w.connectedIPs))
+
+	w.reject(errWSTooManyConnections)
+	w.reject(errWSTooManyConnectionsPerIP)
+	w.reject(errWSTooManyConnectionsPerIP)
+	w.reject(errWSOriginNotAllowed)
+	assert.Equal(t, 1.0, testutil.ToFloat64(w.rejectedClients.WithLabelValues("max_connections")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(w.rejectedClients.WithLabelValues("max_connections_per_ip")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(w.rejectedClients.WithLabelValues("origin")))
+
+	// no limits by default
+	w = newWSRelay(context.Background(), nil)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, w.acquire("10.0.0.1"))
+	}
+}
+
+func TestWSOriginAllowed(t *testing.T) {
+	w := newWSRelay(context.Background(), nil)
+	assert.True(t, w.originAllowed("https://example.com"))
+
+	w = newWSRelay(context.Background(), &config.WS{AllowedOrigins: []string{"https://1oop.io"}})
+	assert.True(t, w.originAllowed("https://1oop.io"))
+	assert.False(t, w.originAllowed("https://example.com"))
+	assert.False(t, w.originAllowed(""))
+
+	w = newWSRelay(context.Background(), &config.WS{AllowedOrigins: []string{"*"}})
+	assert.True(t, w.originAllowed("https://example.com"))
+}
+
+// testWSServer serves the websocket route behind a proxy which sets X-Forwarded-For.
+func testWSServer(t *testing.T, cfg *config.WS) (*Server, string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s := &Server{
+		api: fiber.New(fiber.Config{
+			ProxyHeader:        fiber.HeaderXForwardedFor,
+			EnableIPValidation: true,
+		}),
+		wsRelay: newWSRelay(ctx, cfg),
+	}
+	s.wsRoute()
+	go s.wsRelay.hub()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.api.Listener(ln)
+	t.Cleanup(func() { s.api.Shutdown() })
+	return s, "ws://" + ln.Addr().String() + "/v1/ws"
+}
+
+func dialWS(url, ip string) (*websocket.Conn, *http.Response, error) {
+	return websocket.DefaultDialer.Dial(url, http.Header{fiber.HeaderXForwardedFor: []string{ip + ", 10.10.0.1"}})
+}
+
+func wsConnections(s *Server) int {
+	s.wsRelay.mu.Lock()
+	defer s.wsRelay.mu.Unlock()
+	return s.wsRelay.connections
+}
+
+func TestWSRoute(t *testing.T) {
+	s, url := testWSServer(t, &config.WS{MaxConnectionsPerIP: 1})
+
+	// the limit applies to the client IP of the proxy header, not to the proxy
+	c1, _, err := dialWS(url, "10.0.0.1")
+	require.NoError(t, err)
+	defer c1.Close()
+	c2, _, err := dialWS(url, "10.0.0.2")
+	require.NoError(t, err)
+	defer c2.Close()
+	_, res, err := dialWS(url, "10.0.0.1")
+	require.Error(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	// messages are broadcasted to all clients
+	require.Eventually(t, func() bool { return wsConnections(s) == 2 }, time.Second, time.Millisecond*10)
+	s.wsRelay.broadcast <- []byte(`{"type":"blockfound"}`)
+	for _, c := range []*websocket.Conn{c1, c2} {
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		_, msg, err := c.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"blockfound"}`, string(msg))
+	}
+
+	// the slot is released when the client disconnects
+	c1.Close()
+	require.Eventually(t, func() bool { return wsConnections(s) == 1 }, time.Second, time.Millisecond*10)
+	c3, _, err := dialWS(url, "10.0.0.1")
+	require.NoError(t, err)
+	c3.Close()
+}
+
+func TestWSRouteOrigin(t *testing.T) {
+	_, url := testWSServer(t, &config.WS{AllowedOrigins: []string{"https://1oop.io"}})
+
+	_, res, err := websocket.DefaultDialer.Dial(url, http.Header{fiber.HeaderOrigin: []string{"https://example.com"}})
+	require.Error(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	c, _, err := websocket.DefaultDialer.Dial(url, http.Header{fiber.HeaderOrigin: []string{"https://1oop.io"}})
+	require.NoError(t, err)
+	c.Close()
+}
+
+func TestWSIdleTimeout(t *testing.T) {
+	s, url := testWSServer(t, &config.WS{IdleTimeout: time.Millisecond * 200, PingInterval: time.Millisecond * 50})
+
+	// clients which answer the pings stay connected
+	active, _, err := dialWS(url, "10.0.0.1")
+	require.NoError(t, err)
+	defer active.Close()
+	go func() {
+		for {
+			// reading handles the pings
+			if _, _, err := active.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// clients which don't read never answer the pings and are disconnected
+	idle, _, err := dialWS(url, "10.0.0.2")
+	require.NoError(t, err)
+	defer idle.Close()
+
+	require.Eventually(t, func() bool { return wsConnections(s) == 2 }, time.Second, time.Millisecond*10)
+	require.Eventually(t, func() bool { return wsConnections(s) == 1 }, time.Second*2, time.Millisecond*10)
+	// the active client outlived several idle timeouts
+	time.Sleep(time.Millisecond * 500)
+	assert.Equal(t, 1, wsConnections(s))
+}
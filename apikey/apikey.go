@@ -0,0 +1,330 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+)
+
+// Scope grants access to a set of routes.
+type Scope string
+
+const (
+	// ScopeRead grants access to all public data.
+	ScopeRead Scope = "read"
+	// ScopeMinerSettings grants access to update miner settings.
+	ScopeMinerSettings Scope = "miner-settings"
+	// ScopeAdmin grants access to everything.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes are all known scopes.
+var Scopes = []Scope{ScopeRead, ScopeMinerSettings, ScopeAdmin}
+
+const (
+	tokenPrefix      = "phk_"
+	idBytes          = 8
+	secretBytes      = 32
+	defaultCacheTTL  = time.Minute
+	touchTimeout     = time.Second * 5
+	scopesSeparator  = ","
+	tokenIDSeparator = "_"
+)
+
+var (
+	// ErrInvalidKey is returned if a key is malformed, unknown or its secret doesn't match
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrRevokedKey is returned if a key was revoked
+	ErrRevokedKey = errors.New("api key revoked")
+	// ErrUnknownScope is returned if a scope is not known
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrNoScopes is returned if a key is created without scopes
+	ErrNoScopes = errors.New("at least one scope is required")
+)
+
+// DB stores the api keys.
+type DB interface {
+	CreateAPIKey(ctx context.Context, key database.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (*database.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]*database.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	TouchAPIKey(ctx context.Context, id string, lastUsed time.Time) error
+}
+
+// Key is an api key without its secret.
+type Key struct {
+	ID       string
+	Name     string
+	Tier     string
+	Scopes   []Scope
+	Created  time.Time
+	LastUsed *time.Time
+	Revoked  *time.Time
+}
+
+// HasScope returns true if the key has the given scope.
+// Keys with the admin scope have all scopes.
+func (k *Key) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes parses the given scopes and removes duplicates.
+func ParseScopes(scopes ...string) ([]Scope, error) {
+	parsed := make([]Scope, 0, len(scopes))
+	seen := make(map[Scope]struct{}, len(scopes))
+	for _, s := range scopes {
+		scope := Scope(strings.TrimSpace(s))
+		if !known(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, s)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		parsed = append(parsed, scope)
+	}
+	return parsed, nil
+}
+
+func known(scope Scope) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Opts is a function that can be passed to New to configure the manager
+type Opts func(m *Manager)
+
+// WithCacheTTL sets how long verified keys are cached.
+// Revoked keys are rejected by other instances after this duration at the latest.
+func WithCacheTTL(ttl time.Duration) Opts {
+	return func(m *Manager) {
+		m.cacheTTL = ttl
+	}
+}
+
+type cachedKey struct {
+	key     *Key
+	hash    string
+	fetched time.Time
+}
+
+// Manager issues and verifies api keys.
+type Manager struct {
+	db       DB
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cachedKey
+}
+
+// New creates a new manager.
+func New(db DB, opts ...Opts) *Manager {
+	m := &Manager{
+		db:       db,
+		cacheTTL: defaultCacheTTL,
+		cache:    make(map[string]*cachedKey),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Create creates a new key and returns its token.
+// The token is not stored and can't be recovered.
+func (m *Manager) Create(ctx context.Context, name, tier string, scopes []Scope) (string, *Key, error) {
+	if len(scopes) == 0 {
+		return "", nil, ErrNoScopes
+	}
+	id, err := randomHex(idBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &Key{
+		ID:      id,
+		Name:    name,
+		Tier:    tier,
+		Scopes:  scopes,
+		Created: time.Now().UTC(),
+	}
+	err = m.db.CreateAPIKey(ctx, database.APIKey{
+		ID:      key.ID,
+		Name:    key.Name,
+		Hash:    hash(secret),
+		Scopes:  joinScopes(scopes),
+		Tier:    key.Tier,
+		Created: key.Created,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenPrefix + id + tokenIDSeparator + secret, key, nil
+}
+
+// List returns all keys, including the revoked ones.
+func (m *Manager) List(ctx context.Context) ([]*Key, error) {
+	keys, err := m.db.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Key, len(keys))
+	for i, k := range keys {
+		res[i] = dbKeyToKey(k)
+	}
+	return res, nil
+}
+
+// Revoke revokes the key with the given id.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	if err := m.db.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.cache, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// Verify returns the key of the given token.
+func (m *Manager) Verify(ctx context.Context, token string) (*Key, error) {
+	id, secret, ok := parseToken(token)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	cached, err := m.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(cached.hash), []byte(hash(secret))) != 1 {
+		return nil, ErrInvalidKey
+	}
+	if cached.key.Revoked != nil {
+		return nil, ErrRevokedKey
+	}
+	return cached.key, nil
+}
+
+// Cached returns true if the key of the given token is cached and can be verified without the database.
+// The secret of the token isn't checked.
+func (m *Manager) Cached(token string) bool {
+	id, _, ok := parseToken(token)
+	if !ok {
+		return false
+	}
+	m.mu.Lock()
+	cached, ok := m.cache[id]
+	m.mu.Unlock()
+	return ok && time.Since(cached.fetched) < m.cacheTTL
+}
+
+// get returns the key from the cache or loads it from the database.
+func (m *Manager) get(ctx context.Context, id string) (*cachedKey, error) {
+	now := time.Now()
+	m.mu.Lock()
+	cached, ok := m.cache[id]
+	m.mu.Unlock()
+	if ok && now.Sub(cached.fetched) < m.cacheTTL {
+		return cached, nil
+	}
+
+	k, err := m.db.GetAPIKey(ctx, id)
+	if errors.Is(err, database.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	cached = &cachedKey{key: dbKeyToKey(k), hash: k.Hash, fetched: now}
+	m.mu.Lock()
+	m.cache[id] = cached
+	m.mu.Unlock()
+
+	// the key is loaded at most once per cache TTL, which limits the updates of its last usage
+	if k.Revoked == nil {
+		go m.touch(id, now)
+	}
+	return cached, nil
+}
+
+func (m *Manager) touch(id string, t time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), touchTimeout)
+	defer cancel()
+	if err := m.db.TouchAPIKey(ctx, id, t); err != nil {
+		log.Printf("[apikey][err] %s", err)
+	}
+}
+
+func dbKeyToKey(k *database.APIKey) *Key {
+	key := &Key{
+		ID:       k.ID,
+		Name:     k.Name,
+		Tier:     k.Tier,
+		Created:  k.Created,
+		LastUsed: k.LastUsed,
+		Revoked:  k.Revoked,
+	}
+	for _, s := range strings.Split(k.Scopes, scopesSeparator) {
+		if s != "" {
+			key.Scopes = append(key.Scopes, Scope(s))
+		}
+	}
+	return key
+}
+
+func joinScopes(scopes []Scope) string {
+	s := make([]string, len(scopes))
+	for i, scope := range scopes {
+		s[i] = string(scope)
+	}
+	return strings.Join(s, scopesSeparator)
+}
+
+// parseToken splits a token into its id and secret.
+func parseToken(token string) (string, string, bool) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, tokenPrefix), tokenIDSeparator)
+	if !ok || len(id) != idBytes*2 || len(secret) != secretBytes*2 {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
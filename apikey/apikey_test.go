@@ -0,0 +1,148 @@
+package apikey
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDB struct {
+	mu      sync.Mutex
+	keys    map[string]database.APIKey
+	fetches int
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{keys: make(map[string]database.APIKey)}
+}
+
+func (f *fakeDB) CreateAPIKey(_ context.Context, key database.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key.ID] = key
+	return nil
+}
+
+func (f *fakeDB) GetAPIKey(_ context.Context, id string) (*database.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	k, ok := f.keys[id]
+	if !ok {
+		return nil, database.ErrAPIKeyNotFound
+	}
+	return &k, nil
+}
+
+func (f *fakeDB) GetAPIKeys(_ context.Context) ([]*database.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]*database.APIKey, 0, len(f.keys))
+	for _, k := range f.keys {
+		k := k
+		keys = append(keys, &k)
+	}
+	return keys, nil
+}
+
+func (f *fakeDB) RevokeAPIKey(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[id]
+	if !ok || k.Revoked != nil {
+		return database.ErrAPIKeyNotFound
+	}
+	now := time.Now()
+	k.Revoked = &now
+	f.keys[id] = k
+	return nil
+}
+
+func (f *fakeDB) TouchAPIKey(_ context.Context, id string, lastUsed time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := f.keys[id]
+	k.LastUsed = &lastUsed
+	f.keys[id] = k
+	return nil
+}
+
+func TestCreateAndVerify(t *testing.T) {
+	db := newFakeDB()
+	m := New(db)
+	ctx := context.Background()
+
+	token, key, err := m.Create(ctx, "dashboard", "pro", []Scope{ScopeRead})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, tokenPrefix))
+	assert.NotContains(t, db.keys[key.ID].Hash, strings.Split(token, tokenIDSeparator)[2])
+
+	assert.False(t, m.Cached(token))
+	verified, err := m.Verify(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, m.Cached(token))
+	assert.False(t, m.Cached("phk_invalid"))
+	assert.Equal(t, key.ID, verified.ID)
+	assert.Equal(t, "pro", verified.Tier)
+	assert.True(t, verified.HasScope(ScopeRead))
+	assert.False(t, verified.HasScope(ScopeAdmin))
+
+	// verified keys are cached
+	_, err = m.Verify(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, 1, db.fetches)
+
+	_, err = m.Verify(ctx, token[:len(token)-1]+"x")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = m.Verify(ctx, "phk_invalid")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, _, err = m.Create(ctx, "empty", "", nil)
+	assert.ErrorIs(t, err, ErrNoScopes)
+}
+
+func TestRevoke(t *testing.T) {
+	db := newFakeDB()
+	other := New(db, WithCacheTTL(time.Millisecond*20))
+	m := New(db)
+	ctx := context.Background()
+
+	token, key, err := m.Create(ctx, "dashboard", "", []Scope{ScopeAdmin})
+	require.NoError(t, err)
+	_, err = other.Verify(ctx, token)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Revoke(ctx, key.ID))
+	_, err = m.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrRevokedKey)
+	assert.ErrorIs(t, m.Revoke(ctx, key.ID), database.ErrAPIKeyNotFound)
+
+	// other instances reject the key once their cache expired
+	time.Sleep(time.Millisecond * 30)
+	_, err = other.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrRevokedKey)
+
+	keys, err := m.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].Revoked)
+	assert.Equal(t, []Scope{ScopeAdmin}, keys[0].Scopes)
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("read", " miner-settings", "read")
+	assert.NoError(t, err)
+	assert.Equal(t, []Scope{ScopeRead, ScopeMinerSettings}, scopes)
+
+	_, err = ParseScopes("write")
+	assert.ErrorIs(t, err, ErrUnknownScope)
+
+	key := &Key{Scopes: []Scope{ScopeAdmin}}
+	assert.True(t, key.HasScope(ScopeMinerSettings))
+}
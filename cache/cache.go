@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// BackendMemory keeps the cache in the memory of the process.
+	BackendMemory = "memory"
+	// BackendRedis keeps the cache in a redis server, which can be shared by multiple instances.
+	BackendRedis = "redis"
+)
+
+// DefaultMaxBytes is the default size limit of the memory storage.
+const DefaultMaxBytes = 1000000000
+
+var (
+	// ErrUnknownBackend is returned when the configured cache backend is not supported
+	ErrUnknownBackend = errors.New("unknown cache backend")
+	// ErrFull is returned when a value doesn't fit into the size limit of the memory storage
+	ErrFull = errors.New("cache is full")
+)
+
+// Storage is a fiber storage which can delete all keys with a given prefix.
+type Storage interface {
+	fiber.Storage
+
+	// DeletePrefix deletes all keys which start with the given prefix.
+	DeletePrefix(prefix string) error
+	// Take returns the value of the given key and deletes it atomically.
+	// It returns nil if the key doesn't exist.
+	Take(key string) ([]byte, error)
+}
+
+// New creates the storage for the configured backend.
+// A nil config returns a memory storage.
+func New(cfg *config.Cache) (Storage, error) {
+	if cfg == nil {
+		return NewMemory(DefaultMaxBytes), nil
+	}
+	switch cfg.Backend {
+	case "", BackendMemory:
+		maxBytes := cfg.MaxBytes
+		if maxBytes == 0 {
+			maxBytes = DefaultMaxBytes
+		}
+		return NewMemory(maxBytes), nil
+	case BackendRedis:
+		return NewRedis(cfg.RedisURL, cfg.Prefix)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
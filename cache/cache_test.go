@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &Memory{}, s)
+	s.Close()
+
+	_, err = New(&config.Cache{Backend: "memcached"})
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+}
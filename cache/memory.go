@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const memoryGCInterval = time.Minute
+
+type memoryEntry struct {
+	val []byte
+	exp time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.exp.IsZero() && now.After(e.exp)
+}
+
+// Memory is an in-memory storage.
+// It is the local stand-in for redis when only a single instance is running.
+type Memory struct {
+	mu       sync.RWMutex
+	entries  map[string]*memoryEntry
+	size     int
+	maxBytes int
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewMemory creates a new memory storage which holds at most maxBytes of values.
+// New values are not stored and ErrFull is returned once the limit is reached, until older ones expire.
+// A maxBytes <= 0 disables the limit.
+func NewMemory(maxBytes int) *Memory {
+	m := &Memory{
+		entries:  make(map[string]*memoryEntry),
+		maxBytes: maxBytes,
+		done:     make(chan struct{}),
+	}
+	go m.gc()
+	return m
+}
+
+// Get returns the value of the given key or nil if it doesn't exist.
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.RLock()
+	e, ok := m.entries[key]
+	m.mu.RUnlock()
+	if !ok || e.expired(time.Now()) {
+		return nil, nil
+	}
+	return e.val, nil
+}
+
+// Set stores the value for the given key. An exp of 0 means no expiration.
+func (m *Memory) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+	e := &memoryEntry{val: val}
+	if exp > 0 {
+		e.exp = time.Now().Add(exp)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.entries[key]; ok {
+		m.size -= len(old.val)
+	}
+	if m.maxBytes > 0 && m.size+len(val) > m.maxBytes {
+		m.deleteExpired(time.Now())
+		if m.size+len(val) > m.maxBytes {
+			delete(m.entries, key)
+			return ErrFull
+		}
+	}
+	m.entries[key] = e
+	m.size += len(val)
+	return nil
+}
+
+// Delete deletes the given key.
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delete(key)
+	return nil
+}
+
+// Take returns the value of the given key and deletes it atomically.
+// It returns nil if the key doesn't exist.
+func (m *Memory) Take(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	m.delete(key)
+	if e.expired(time.Now()) {
+		return nil, nil
+	}
+	return e.val, nil
+}
+
+// DeletePrefix deletes all keys which start with the given prefix.
+func (m *Memory) DeletePrefix(prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.delete(key)
+		}
+	}
+	return nil
+}
+
+// Reset deletes all keys.
+func (m *Memory) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]*memoryEntry)
+	m.size = 0
+	return nil
+}
+
+// Close stops the garbage collection.
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.done) })
+	return nil
+}
+
+// Size returns the size of all stored values in bytes.
+func (m *Memory) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.size
+}
+
+func (m *Memory) delete(key string) {
+	if e, ok := m.entries[key]; ok {
+		m.size -= len(e.val)
+		delete(m.entries, key)
+	}
+}
+
+func (m *Memory) deleteExpired(now time.Time) {
+	for key, e := range m.entries {
+		if e.expired(now) {
+			m.delete(key)
+		}
+	}
+}
+
+func (m *Memory) gc() {
+	ticker := time.NewTicker(memoryGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			m.deleteExpired(now)
+			m.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(0)
+	defer m.Close()
+
+	assert.NoError(t, m.Set("a", []byte("1"), 0))
+	val, err := m.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+
+	val, err = m.Get("missing")
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+
+	assert.NoError(t, m.Delete("a"))
+	val, _ = m.Get("a")
+	assert.Nil(t, val)
+	assert.Equal(t, 0, m.Size())
+}
+
+func TestMemoryTake(t *testing.T) {
+	m := NewMemory(0)
+	defer m.Close()
+
+	assert.NoError(t, m.Set("a", []byte("1"), 0))
+	val, err := m.Take("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+	val, err = m.Take("a")
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+	assert.Equal(t, 0, m.Size())
+
+	assert.NoError(t, m.Set("b", []byte("2"), time.Millisecond))
+	time.Sleep(time.Millisecond * 5)
+	val, _ = m.Take("b")
+	assert.Nil(t, val)
+}
+
+func TestMemoryExpiration(t *testing.T) {
+	m := NewMemory(0)
+	defer m.Close()
+
+	assert.NoError(t, m.Set("a", []byte("1"), time.Millisecond*20))
+	val, _ := m.Get("a")
+	assert.Equal(t, []byte("1"), val)
+
+	time.Sleep(time.Millisecond * 30)
+	val, _ = m.Get("a")
+	assert.Nil(t, val)
+}
+
+func TestMemoryDeletePrefix(t *testing.T) {
+	m := NewMemory(0)
+	defer m.Close()
+
+	keys := []string{
+		"/api/v1/pools?_GET",
+		"/api/v1/pools/eth1?_GET",
+		"/api/v1/pools/eth1/blocks?page=1_GET",
+		"/api/v1/pools/eth1/blocks?page=1_GET_body",
+		"/api/v1/pools/eth12/blocks?_GET",
+	}
+	for _, k := range keys {
+		assert.NoError(t, m.Set(k, []byte(k), 0))
+	}
+
+	assert.NoError(t, m.DeletePrefix("/api/v1/pools/eth1/blocks?"))
+	for i, k := range keys {
+		val, _ := m.Get(k)
+		if i == 2 || i == 3 {
+			assert.Nil(t, val, k)
+		} else {
+			assert.NotNil(t, val, k)
+		}
+	}
+
+	assert.NoError(t, m.Reset())
+	assert.Equal(t, 0, m.Size())
+}
+
+func TestMemoryMaxBytes(t *testing.T) {
+	m := NewMemory(10)
+	defer m.Close()
+
+	assert.NoError(t, m.Set("a", []byte("12345"), time.Millisecond*20))
+	assert.NoError(t, m.Set("b", []byte("12345"), 0))
+	assert.Equal(t, 10, m.Size())
+
+	// the limit is reached, new values are dropped
+	assert.ErrorIs(t, m.Set("c", []byte("1"), 0), ErrFull)
+	val, _ := m.Get("c")
+	assert.Nil(t, val)
+
+	// overwriting a key replaces its size
+	assert.NoError(t, m.Set("b", []byte("123"), 0))
+	assert.Equal(t, 8, m.Size())
+
+	// expired values make room for new ones
+	time.Sleep(time.Millisecond * 30)
+	assert.NoError(t, m.Set("c", []byte("12345"), 0))
+	val, _ = m.Get("c")
+	assert.Equal(t, []byte("12345"), val)
+	assert.Equal(t, 8, m.Size())
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/config"
+	"github.com/1oopio/phantomias/database"
+	"github.com/spf13/cobra"
+)
+
+var apikeyCmdFlags struct {
+	config string
+	name   string
+	tier   string
+	scopes []string
+}
+
+var apikeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage the api keys",
+}
+
+var apikeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new api key",
+	Args:  cobra.NoArgs,
+	RunE:  apikeyCreate,
+}
+
+var apikeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all api keys",
+	Args:  cobra.NoArgs,
+	RunE:  apikeyList,
+}
+
+var apikeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an api key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  apikeyRevoke,
+}
+
+func init() {
+	rootCmd.AddCommand(apikeyCmd)
+	apikeyCmd.AddCommand(apikeyCreateCmd, apikeyListCmd, apikeyRevokeCmd)
+
+	apikeyCmd.PersistentFlags().StringVarP(&apikeyCmdFlags.config, "config", "c", "", "path to the config file")
+
+	apikeyCreateCmd.Flags().StringVar(&apikeyCmdFlags.name, "name", "", "name of the key, e.g. its owner")
+	apikeyCreateCmd.Flags().StringVar(&apikeyCmdFlags.tier, "tier", "", "rate limit tier of the key")
+	apikeyCreateCmd.Flags().StringSliceVar(&apikeyCmdFlags.scopes, "scopes", []string{string(apikey.ScopeRead)}, "scopes of the key (read, miner-settings, admin)")
+	apikeyCreateCmd.MarkFlagRequired("name")
+}
+
+func apikeyCreate(cmd *cobra.Command, args []string) error {
+	scopes, err := apikey.ParseScopes(apikeyCmdFlags.scopes...)
+	if err != nil {
+		return err
+	}
+	keys, closeDB, err := openAPIKeys(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	token, key, err := keys.Create(cmd.Context(), apikeyCmdFlags.name, apikeyCmdFlags.tier, scopes)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ID: %s\n", key.ID)
+	fmt.Printf("Token: %s\n", token)
+	fmt.Println("The token is not stored and can't be shown again.")
+	return nil
+}
+
+func apikeyList(cmd *cobra.Command, args []string) error {
+	keys, closeDB, err := openAPIKeys(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	list, err := keys.List(cmd.Context())
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTIER\tSCOPES\tCREATED\tLAST USED\tREVOKED")
+	for _, k := range list {
+		scopes := make([]string, len(k.Scopes))
+		for i, s := range k.Scopes {
+			scopes[i] = string(s)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.ID, k.Name, k.Tier, strings.Join(scopes, ","),
+			k.Created.Format(time.RFC3339), formatOptionalTime(k.LastUsed), formatOptionalTime(k.Revoked),
+		)
+	}
+	return w.Flush()
+}
+
+func apikeyRevoke(cmd *cobra.Command, args []string) error {
+	keys, closeDB, err := openAPIKeys(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if err := keys.Revoke(cmd.Context(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Revoked api key %s\n", args[0])
+	return nil
+}
+
+// openAPIKeys connects to the database and returns the api key manager.
+func openAPIKeys(ctx context.Context) (*apikey.Manager, func(), error) {
+	cfg, err := config.Load(apikeyCmdFlags.config)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := database.New(cfg.DB.Host, cfg.DB.Port, cfg.DB.User, cfg.DB.Password, cfg.DB.Dbname, cfg.DB.SSLMode)
+	if err := db.Connect(); err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return apikey.New(db), func() { db.Close() }, nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
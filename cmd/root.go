@@ -10,12 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/1oopio/phantomias/alert"
 	"github.com/1oopio/phantomias/api"
+	"github.com/1oopio/phantomias/apikey"
+	"github.com/1oopio/phantomias/cache"
 	"github.com/1oopio/phantomias/config"
 	"github.com/1oopio/phantomias/database"
 	"github.com/1oopio/phantomias/metrics"
 	"github.com/1oopio/phantomias/price"
+	"github.com/1oopio/phantomias/ratelimit"
+	"github.com/1oopio/phantomias/scheduler"
 	"github.com/1oopio/phantomias/version"
+	"github.com/1oopio/phantomias/webhook"
 	"github.com/1oopio/phantomias/ws"
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/cobra"
@@ -41,21 +47,81 @@ func init() {
 
 	rootCmd.Flags().String("listen", "0.0.0.0:8080", "listening address")
 	rootCmd.Flags().Duration("cache-ttl", time.Second*90, "TTL for the api cache")
+	rootCmd.Flags().String("cache-backend", "memory", "backend for the api cache (memory, redis)")
+	rootCmd.Flags().String("cache-redis-url", "redis://localhost:6379/0", "url of the redis server for the api cache")
+	rootCmd.Flags().String("cache-prefix", "phantomias:", "prefix for all api cache keys")
+	rootCmd.Flags().Int("cache-max-bytes", 1000000000, "max size of the memory api cache in bytes")
+	rootCmd.Flags().Bool("compression-enabled", true, "compress api responses with brotli or gzip")
+	rootCmd.Flags().Int("compression-min-size", 1024, "min size of an api response in bytes to be compressed")
+	rootCmd.Flags().Bool("ratelimit-enabled", true, "enable the api rate limiter")
+	rootCmd.Flags().String("ratelimit-backend", "memory", "backend for the rate limit counters (memory, redis)")
+	rootCmd.Flags().String("ratelimit-redis-url", "redis://localhost:6379/0", "url of the redis server for the rate limit counters")
+	rootCmd.Flags().String("ratelimit-prefix", "phantomias:ratelimit:", "prefix for all rate limit counter keys")
+	rootCmd.Flags().StringArray("ratelimit-exempt", []string{"127.0.0.1"}, "a list of IPs which aren't rate limited")
+	rootCmd.Flags().String("ratelimit-strategy", "sliding", "rate limit windows (sliding, fixed)")
+	rootCmd.Flags().Int("ratelimit-max", 1000, "max requests per window of clients without api key")
+	rootCmd.Flags().Duration("ratelimit-window", time.Second*10, "rate limit window of clients without api key")
 	rootCmd.Flags().String("cert-file", "", "path to the tls certificate")
 	rootCmd.Flags().String("cert-key", "", "path to the tls key")
 	rootCmd.Flags().Bool("trusted-proxy-check", false, "allow requests only from trusted proxies")
 	rootCmd.Flags().StringArray("trusted-proxies", nil, "a list of trusted proxy IPs")
+	rootCmd.Flags().String("proxy-header", "", "header with the client IP set by the proxies, e.g. X-Forwarded-For (only trusted if trusted-proxy-check is enabled)")
+	rootCmd.Flags().String("settings-mode", "miningcore", "read and write the miner settings through miningcore or natively in the database (miningcore, native)")
+	rootCmd.Flags().Duration("workers-window", time.Hour*24, "default window in which the workers of a miner are listed")
+	rootCmd.Flags().Duration("workers-max-window", time.Hour*24*30, "max window in which the workers of a miner can be listed")
+	rootCmd.Flags().Int("performance-max-points", 1000, "max samples of a performance response, larger ranges require a larger interval")
+	rootCmd.Flags().Int("max-parallel-queries", 8, "max concurrent database queries per request")
+	rootCmd.Flags().Int("ws-max-connections", 10000, "max concurrent websocket clients (0 = unlimited)")
+	rootCmd.Flags().Int("ws-max-connections-per-ip", 10, "max concurrent websocket clients per IP (0 = unlimited)")
+	rootCmd.Flags().StringArray("ws-allowed-origins", nil, "a list of allowed websocket origins (empty = all)")
+	rootCmd.Flags().Duration("ws-idle-timeout", time.Second*60, "close websocket clients which are idle for this duration")
+	rootCmd.Flags().Duration("ws-ping-interval", time.Second*25, "interval in which websocket clients are pinged")
 
 	rootCmd.Flags().String("database-sslmode", "require", "database sslmode (pgsql)")
 
 	rootCmd.Flags().String("miningcore-url", "", "url of the miningcore api")
 	rootCmd.Flags().Bool("miningcore-ignore-tls", false, "ignore invalid tls configuration")
 	rootCmd.Flags().String("miningcore-ws", "", "url of the miningcore websocket api")
+	rootCmd.Flags().Duration("miningcore-ws-dedup-window", time.Second*30, "relay identical events from multiple websocket upstreams only once within this window")
 	rootCmd.Flags().Duration("miningcore-timeout", time.Second*5, "timeout for the miningcore api")
 
 	rootCmd.Flags().StringArray("price-coins", nil, "a list of coins to load prices for")
 	rootCmd.Flags().StringArray("price-vscurrencies", nil, "a list of currencies in which to load prices")
 
+	rootCmd.Flags().Bool("scheduler-enabled", true, "precompute expensive aggregates in the background")
+	rootCmd.Flags().Duration("scheduler-interval", time.Minute, "interval in which the aggregates are precomputed")
+	rootCmd.Flags().Duration("scheduler-max-age", 0, "precomputed aggregates older than this are not served (0 = 3 intervals)")
+	rootCmd.Flags().IntSlice("scheduler-topminers-ranges", []int{1, 24}, "ranges in hours for which the top miners are precomputed")
+
+	rootCmd.Flags().Bool("alerts-enabled", false, "watch the workers of subscribed miners and send offline alerts")
+	rootCmd.Flags().Duration("alerts-interval", time.Minute, "interval in which the workers of subscribed miners are checked")
+	rootCmd.Flags().Duration("alerts-lookback", time.Hour*24, "workers without stats within this duration are no longer checked")
+	rootCmd.Flags().Duration("alerts-min-threshold", time.Minute*10, "min duration after which a worker can be reported as offline")
+	rootCmd.Flags().Int("alerts-max-subscriptions", 5, "max alert subscriptions per miner")
+	rootCmd.Flags().Int("alerts-workers", 4, "max concurrent alert sends")
+	rootCmd.Flags().Bool("alerts-webhook-enabled", true, "allow alert subscriptions with webhooks")
+	rootCmd.Flags().Duration("alerts-webhook-timeout", time.Second*10, "timeout of an alert webhook request")
+	rootCmd.Flags().Bool("alerts-webhook-allow-private", false, "allow alert webhooks to private networks")
+	rootCmd.Flags().String("alerts-smtp-addr", "", "address of the mail relay for email alerts, e.g. localhost:25 (empty = disabled)")
+	rootCmd.Flags().String("alerts-smtp-from", "", "sender of email alerts")
+	rootCmd.Flags().String("alerts-telegram-url", "https://api.telegram.org", "url of the telegram bot api")
+	rootCmd.Flags().String("alerts-telegram-token", "", "token of the telegram bot for alerts (empty = disabled)")
+	rootCmd.Flags().Duration("alerts-telegram-timeout", time.Second*10, "timeout of a telegram bot api request")
+	rootCmd.Flags().Bool("webhooks-enabled", false, "send block and payment events to webhooks")
+	rootCmd.Flags().Duration("webhooks-poll-interval", time.Minute, "interval in which new blocks and payments are polled for webhooks")
+	rootCmd.Flags().Duration("webhooks-lookback", time.Hour*24, "block and payment events older than this are not sent to webhooks")
+	rootCmd.Flags().Int("webhooks-max-attempts", 8, "attempts after which a webhook delivery is moved to the dead letters")
+	rootCmd.Flags().Duration("webhooks-min-backoff", time.Second*30, "delay after the first failed webhook delivery, doubled after every attempt")
+	rootCmd.Flags().Duration("webhooks-max-backoff", time.Hour, "max delay between two attempts of a webhook delivery")
+	rootCmd.Flags().Duration("webhooks-timeout", time.Second*10, "timeout of a webhook request")
+	rootCmd.Flags().Int("webhooks-workers", 4, "max concurrent webhook deliveries")
+	rootCmd.Flags().Bool("webhooks-allow-private", false, "allow webhooks to private networks")
+	rootCmd.Flags().Int("webhooks-max-per-miner", 3, "max webhooks per miner")
+	rootCmd.Flags().Bool("share-stats-enabled", false, "count the accepted, rejected and stale shares from share notifications (not sent by stock miningcore)")
+	rootCmd.Flags().Duration("share-stats-interval", time.Minute, "interval in which the share counts are persisted")
+	rootCmd.Flags().Duration("share-stats-window", time.Hour*24, "window of the share counts on the miner and worker endpoints")
+	rootCmd.Flags().Duration("share-stats-retention", time.Hour*24*30, "share counts older than this are deleted (0 = keep forever)")
+
 	rootCmd.Flags().Bool("metrics-enabled", false, "enable prometheus metrics")
 	rootCmd.Flags().String("metrics-listen", "0.0.0.0:8081", "listening address for the metrics server")
 	rootCmd.Flags().String("metrics-endpoint", "/metrics", "the endpoint to fetch metrics from")
@@ -64,17 +130,75 @@ func init() {
 
 	viper.BindPFlag("api.listen", rootCmd.Flags().Lookup("listen"))
 	viper.BindPFlag("api.cache_ttl", rootCmd.Flags().Lookup("cache-ttl"))
+	viper.BindPFlag("api.cache.backend", rootCmd.Flags().Lookup("cache-backend"))
+	viper.BindPFlag("api.cache.redis_url", rootCmd.Flags().Lookup("cache-redis-url"))
+	viper.BindPFlag("api.cache.prefix", rootCmd.Flags().Lookup("cache-prefix"))
+	viper.BindPFlag("api.cache.max_bytes", rootCmd.Flags().Lookup("cache-max-bytes"))
+	viper.BindPFlag("api.compression.enabled", rootCmd.Flags().Lookup("compression-enabled"))
+	viper.BindPFlag("api.compression.min_size", rootCmd.Flags().Lookup("compression-min-size"))
+	viper.BindPFlag("api.ratelimit.enabled", rootCmd.Flags().Lookup("ratelimit-enabled"))
+	viper.BindPFlag("api.ratelimit.backend", rootCmd.Flags().Lookup("ratelimit-backend"))
+	viper.BindPFlag("api.ratelimit.redis_url", rootCmd.Flags().Lookup("ratelimit-redis-url"))
+	viper.BindPFlag("api.ratelimit.prefix", rootCmd.Flags().Lookup("ratelimit-prefix"))
+	viper.BindPFlag("api.ratelimit.exempt", rootCmd.Flags().Lookup("ratelimit-exempt"))
+	viper.BindPFlag("api.ratelimit.strategy", rootCmd.Flags().Lookup("ratelimit-strategy"))
+	viper.BindPFlag("api.ratelimit.max", rootCmd.Flags().Lookup("ratelimit-max"))
+	viper.BindPFlag("api.ratelimit.window", rootCmd.Flags().Lookup("ratelimit-window"))
 	viper.BindPFlag("api.cert_file", rootCmd.Flags().Lookup("cert-file"))
 	viper.BindPFlag("api.cert_key", rootCmd.Flags().Lookup("cert-key"))
 	viper.BindPFlag("api.trusted_proxy_check", rootCmd.Flags().Lookup("trusted-proxy-check"))
 	viper.BindPFlag("api.trusted_proxies", rootCmd.Flags().Lookup("trusted-proxies"))
+	viper.BindPFlag("api.proxy_header", rootCmd.Flags().Lookup("proxy-header"))
+	viper.BindPFlag("api.settings.mode", rootCmd.Flags().Lookup("settings-mode"))
+	viper.BindPFlag("api.workers.window", rootCmd.Flags().Lookup("workers-window"))
+	viper.BindPFlag("api.workers.max_window", rootCmd.Flags().Lookup("workers-max-window"))
+	viper.BindPFlag("api.performance_max_points", rootCmd.Flags().Lookup("performance-max-points"))
+	viper.BindPFlag("api.max_parallel_queries", rootCmd.Flags().Lookup("max-parallel-queries"))
+	viper.BindPFlag("api.ws.max_connections", rootCmd.Flags().Lookup("ws-max-connections"))
+	viper.BindPFlag("api.ws.max_connections_per_ip", rootCmd.Flags().Lookup("ws-max-connections-per-ip"))
+	viper.BindPFlag("api.ws.allowed_origins", rootCmd.Flags().Lookup("ws-allowed-origins"))
+	viper.BindPFlag("api.ws.idle_timeout", rootCmd.Flags().Lookup("ws-idle-timeout"))
+	viper.BindPFlag("api.ws.ping_interval", rootCmd.Flags().Lookup("ws-ping-interval"))
 	viper.BindPFlag("database.sslmode", rootCmd.Flags().Lookup("database-sslmode"))
 	viper.BindPFlag("miningcore.url", rootCmd.Flags().Lookup("miningcore-url"))
 	viper.BindPFlag("miningcore.ignore_tls", rootCmd.Flags().Lookup("miningcore-ignore-tls"))
 	viper.BindPFlag("miningcore.ws", rootCmd.Flags().Lookup("miningcore-ws"))
+	viper.BindPFlag("miningcore.ws_dedup_window", rootCmd.Flags().Lookup("miningcore-ws-dedup-window"))
 	viper.BindPFlag("miningcore.timeout", rootCmd.Flags().Lookup("miningcore-timeout"))
 	viper.BindPFlag("price.coins", rootCmd.Flags().Lookup("price-coins"))
 	viper.BindPFlag("price.vs_currencies", rootCmd.Flags().Lookup("price-vscurrencies"))
+	viper.BindPFlag("scheduler.enabled", rootCmd.Flags().Lookup("scheduler-enabled"))
+	viper.BindPFlag("scheduler.interval", rootCmd.Flags().Lookup("scheduler-interval"))
+	viper.BindPFlag("scheduler.max_age", rootCmd.Flags().Lookup("scheduler-max-age"))
+	viper.BindPFlag("scheduler.topminers_ranges", rootCmd.Flags().Lookup("scheduler-topminers-ranges"))
+	viper.BindPFlag("alerts.enabled", rootCmd.Flags().Lookup("alerts-enabled"))
+	viper.BindPFlag("alerts.interval", rootCmd.Flags().Lookup("alerts-interval"))
+	viper.BindPFlag("alerts.lookback", rootCmd.Flags().Lookup("alerts-lookback"))
+	viper.BindPFlag("alerts.min_threshold", rootCmd.Flags().Lookup("alerts-min-threshold"))
+	viper.BindPFlag("alerts.max_subscriptions", rootCmd.Flags().Lookup("alerts-max-subscriptions"))
+	viper.BindPFlag("alerts.workers", rootCmd.Flags().Lookup("alerts-workers"))
+	viper.BindPFlag("alerts.webhook.enabled", rootCmd.Flags().Lookup("alerts-webhook-enabled"))
+	viper.BindPFlag("alerts.webhook.timeout", rootCmd.Flags().Lookup("alerts-webhook-timeout"))
+	viper.BindPFlag("alerts.webhook.allow_private", rootCmd.Flags().Lookup("alerts-webhook-allow-private"))
+	viper.BindPFlag("alerts.smtp.addr", rootCmd.Flags().Lookup("alerts-smtp-addr"))
+	viper.BindPFlag("alerts.smtp.from", rootCmd.Flags().Lookup("alerts-smtp-from"))
+	viper.BindPFlag("alerts.telegram.url", rootCmd.Flags().Lookup("alerts-telegram-url"))
+	viper.BindPFlag("alerts.telegram.token", rootCmd.Flags().Lookup("alerts-telegram-token"))
+	viper.BindPFlag("alerts.telegram.timeout", rootCmd.Flags().Lookup("alerts-telegram-timeout"))
+	viper.BindPFlag("webhooks.enabled", rootCmd.Flags().Lookup("webhooks-enabled"))
+	viper.BindPFlag("webhooks.poll_interval", rootCmd.Flags().Lookup("webhooks-poll-interval"))
+	viper.BindPFlag("webhooks.lookback", rootCmd.Flags().Lookup("webhooks-lookback"))
+	viper.BindPFlag("webhooks.max_attempts", rootCmd.Flags().Lookup("webhooks-max-attempts"))
+	viper.BindPFlag("webhooks.min_backoff", rootCmd.Flags().Lookup("webhooks-min-backoff"))
+	viper.BindPFlag("webhooks.max_backoff", rootCmd.Flags().Lookup("webhooks-max-backoff"))
+	viper.BindPFlag("webhooks.timeout", rootCmd.Flags().Lookup("webhooks-timeout"))
+	viper.BindPFlag("webhooks.workers", rootCmd.Flags().Lookup("webhooks-workers"))
+	viper.BindPFlag("webhooks.allow_private", rootCmd.Flags().Lookup("webhooks-allow-private"))
+	viper.BindPFlag("webhooks.max_per_miner", rootCmd.Flags().Lookup("webhooks-max-per-miner"))
+	viper.BindPFlag("share_stats.enabled", rootCmd.Flags().Lookup("share-stats-enabled"))
+	viper.BindPFlag("share_stats.interval", rootCmd.Flags().Lookup("share-stats-interval"))
+	viper.BindPFlag("share_stats.window", rootCmd.Flags().Lookup("share-stats-window"))
+	viper.BindPFlag("share_stats.retention", rootCmd.Flags().Lookup("share-stats-retention"))
 	viper.BindPFlag("metrics.listen", rootCmd.Flags().Lookup("metrics-listen"))
 	viper.BindPFlag("metrics.endpoint", rootCmd.Flags().Lookup("metrics-endpoint"))
 	viper.BindPFlag("metrics.enabled", rootCmd.Flags().Lookup("metrics-enabled"))
@@ -105,11 +229,17 @@ func root(cmd *cobra.Command, args []string) {
 	}
 	defer db.Close()
 	log.Println("Connected to database")
+	if err := db.Migrate(cmd.Context()); err != nil {
+		log.Fatalln(err)
+	}
 
 	// metrics
-	var metricsMiddleware fiber.Handler
+	var (
+		metricsServer     *metrics.Server
+		metricsMiddleware fiber.Handler
+	)
 	if cfg.Metrics.Enabled {
-		metricsServer := metrics.New(cfg.Metrics, metrics.WithContext(cmd.Context()))
+		metricsServer = metrics.New(cfg.Metrics, metrics.WithContext(cmd.Context()))
 		defer metricsServer.Close()
 
 		go func() {
@@ -146,9 +276,112 @@ func root(cmd *cobra.Command, args []string) {
 		mcOpts...,
 	)
 
+	// precompute expensive aggregates
+	var sched *scheduler.Scheduler
+	if cfg.Scheduler.Enabled {
+		poolIDs := make([]string, 0, len(cfg.Pools))
+		for _, p := range cfg.Pools {
+			if p.Enabled {
+				poolIDs = append(poolIDs, p.ID)
+			}
+		}
+		sched = scheduler.New(db,
+			scheduler.WithContext(cmd.Context()),
+			scheduler.WithInterval(cfg.Scheduler.Interval),
+			scheduler.WithMaxAge(cfg.Scheduler.MaxAge),
+			scheduler.WithPools(poolIDs...),
+			scheduler.WithTopMinersRanges(cfg.Scheduler.TopMinersRanges...),
+		)
+		defer sched.Close()
+		go sched.Start()
+	}
+
+	// watch the workers of miners which subscribed to offline alerts
+	var alerts *alert.Watcher
+	if cfg.Alerts != nil && cfg.Alerts.Enabled {
+		alerts = alert.New(db, alertOpts(cmd.Context(), cfg.Alerts)...)
+		defer alerts.Close()
+		go alerts.Start()
+	}
+
+	// create the websocket relay
+	wsRelay := ws.NewRelay(cfg.Miningcore.WSDedupWindow)
+	for _, u := range cfg.Miningcore.WSSources() {
+		wsRelay.AddUpstream(u.Name, u.URL)
+	}
+
+	// count the accepted, rejected and stale shares of the workers
+	var shareStatsWindow time.Duration
+	if cfg.ShareStats != nil && cfg.ShareStats.Enabled {
+		shareStats := ws.NewShareStats(db,
+			ws.WithShareStatsContext(cmd.Context()),
+			ws.WithShareStatsInterval(cfg.ShareStats.Interval),
+			ws.WithShareStatsRetention(cfg.ShareStats.Retention),
+		)
+		defer shareStats.Close()
+		wsRelay.CountShares(shareStats)
+		go shareStats.Start()
+		shareStatsWindow = cfg.ShareStats.Window
+	}
+
+	// send block and payment events to webhooks
+	var webhooks *webhook.Dispatcher
+	if cfg.Webhooks != nil && cfg.Webhooks.Enabled {
+		webhooks = webhook.New(db,
+			webhook.WithContext(cmd.Context()),
+			webhook.WithPollInterval(cfg.Webhooks.PollInterval),
+			webhook.WithLookback(cfg.Webhooks.Lookback),
+			webhook.WithMaxAttempts(cfg.Webhooks.MaxAttempts),
+			webhook.WithBackoff(cfg.Webhooks.MinBackoff, cfg.Webhooks.MaxBackoff),
+			webhook.WithTimeout(cfg.Webhooks.Timeout),
+			webhook.WithWorkers(cfg.Webhooks.Workers),
+			webhook.WithAllowPrivate(cfg.Webhooks.AllowPrivate),
+			webhook.WithMaxPerMiner(cfg.Webhooks.MaxPerMiner),
+		)
+		defer webhooks.Close()
+		wsRelay.OnMessage(webhooks.OnMessage)
+		go webhooks.Start()
+	}
+
+	// create the api cache
+	cacheStorage, err := cache.New(cfg.API.Cache)
+	if err != nil {
+		log.Fatalln(fmt.Errorf("failed to create the api cache: %w", err))
+	}
+	defer cacheStorage.Close()
+	// the settings nonces are shared by all instances if the cache is shared,
+	// otherwise they are kept apart from the cached responses in memory
+	var nonceStorage cache.Storage
+	if cfg.API.Cache != nil && cfg.API.Cache.Backend == cache.BackendRedis {
+		nonceStorage = cacheStorage
+	}
+
+	// create the rate limit counters
+	rateLimitStore, err := ratelimit.New(cfg.API.RateLimit)
+	if err != nil {
+		log.Fatalln(fmt.Errorf("failed to create the rate limit store: %w", err))
+	}
+	defer rateLimitStore.Close()
+
 	// start the api server
-	api := api.New(context.Background(), cfg.API, cfg.Pools, mc, db, priceClient, metricsMiddleware)
+	api := api.New(context.Background(), cfg.API, cfg.Pools, mc, db, priceClient, metricsMiddleware,
+		api.WithUpstreamStatus(wsRelay),
+		api.WithCacheStorage(cacheStorage),
+		api.WithNonceStorage(nonceStorage),
+		api.WithRateLimitStore(rateLimitStore),
+		api.WithAPIKeys(apikey.New(db)),
+		api.WithScheduler(sched),
+		api.WithAlerts(alerts),
+		api.WithWebhooks(webhooks),
+		api.WithShareStats(shareStatsWindow),
+	)
 	defer api.Close()
+	wsRelay.OnMessage(api.InvalidateCache)
+	if metricsServer != nil {
+		if err := metricsServer.Register(api.Collectors()...); err != nil {
+			log.Fatalln(fmt.Errorf("failed to register api metrics: %w", err))
+		}
+	}
 
 	go func() {
 		if err := api.Start(); err != nil {
@@ -157,24 +390,32 @@ func root(cmd *cobra.Command, args []string) {
 	}()
 
 	// start the websocket relay
-	wsc := ws.New(cfg.Miningcore.WS, api.BroadcastChan())
-	defer wsc.Close()
-
-	go func() {
-		for {
-			select {
-			case <-done:
-				return
-			default:
-				if err := wsc.Listen(done); err != nil {
-					//log.Fatalln(err)
-					log.Println("[err] failed to start the websocket relay, will try again in 30 seconds...")
-				}
-				time.Sleep(time.Second * 30)
-			}
-		}
-	}()
+	wsCtx, wsCancel := context.WithCancel(cmd.Context())
+	defer wsCancel()
+	go wsRelay.Start(wsCtx, api.BroadcastChan())
 
 	<-done
 	log.Println("shutting down...")
 }
+
+// alertOpts returns the options of the alert watcher incl. the configured notifiers.
+func alertOpts(ctx context.Context, cfg *config.Alerts) []alert.Opts {
+	opts := []alert.Opts{
+		alert.WithContext(ctx),
+		alert.WithInterval(cfg.Interval),
+		alert.WithLookback(cfg.Lookback),
+		alert.WithMinThreshold(cfg.MinThreshold),
+		alert.WithMaxSubscriptions(cfg.MaxSubscriptions),
+		alert.WithWorkers(cfg.Workers),
+	}
+	if cfg.Webhook != nil && cfg.Webhook.Enabled {
+		opts = append(opts, alert.WithNotifier(alert.ChannelWebhook, alert.NewWebhook(cfg.Webhook.Timeout, cfg.Webhook.AllowPrivate)))
+	}
+	if cfg.SMTP != nil && cfg.SMTP.Addr != "" {
+		opts = append(opts, alert.WithNotifier(alert.ChannelEmail, alert.NewSMTP(cfg.SMTP.Addr, cfg.SMTP.From)))
+	}
+	if cfg.Telegram != nil && cfg.Telegram.Token != "" {
+		opts = append(opts, alert.WithNotifier(alert.ChannelTelegram, alert.NewTelegram(cfg.Telegram.URL, cfg.Telegram.Token, cfg.Telegram.Timeout)))
+	}
+	return opts
+}
@@ -20,9 +20,42 @@ func TestLoadConfig(t *testing.T) {
 
 	assert.Equal(t, "0.0.0.0:3000", cfg.API.Listen)
 	assert.Equal(t, time.Duration(time.Minute), cfg.API.CacheTTL)
+	assert.Equal(t, "X-Forwarded-For", cfg.API.ProxyHeader)
+	assert.NotNil(t, cfg.API.WS)
+	assert.Equal(t, 500, cfg.API.WS.MaxConnections)
+	assert.Equal(t, 5, cfg.API.WS.MaxConnectionsPerIP)
+	assert.Equal(t, []string{"https://1oop.io"}, cfg.API.WS.AllowedOrigins)
+	assert.Equal(t, time.Minute, cfg.API.WS.IdleTimeout)
+	assert.Equal(t, time.Second*20, cfg.API.WS.PingInterval)
+	assert.NotNil(t, cfg.API.Cache)
+	assert.Equal(t, "redis", cfg.API.Cache.Backend)
+	assert.Equal(t, "redis://localhost:6379/0", cfg.API.Cache.RedisURL)
+	assert.Equal(t, "phantomias:", cfg.API.Cache.Prefix)
+	assert.Equal(t, 1000, cfg.API.Cache.MaxBytes)
+	assert.Equal(t, time.Second*30, cfg.API.RouteCacheTTL("pools"))
+	assert.Equal(t, time.Duration(0), cfg.API.RouteCacheTTL("blocks"))
+	assert.Equal(t, time.Minute, cfg.API.RouteCacheTTL("topminers"))
+	assert.NotNil(t, cfg.API.RateLimit)
+	assert.Equal(t, true, cfg.API.RateLimit.Enabled)
+	assert.Equal(t, "redis", cfg.API.RateLimit.Backend)
+	assert.Equal(t, []string{"127.0.0.1", "10.0.0.1"}, cfg.API.RateLimit.Exempt)
+	assert.Equal(t, config.Limit{Max: 100, Window: time.Second * 10}, cfg.API.RateLimit.Limit("", "default"))
+	assert.Equal(t, config.Limit{Max: 10, Window: time.Minute}, cfg.API.RateLimit.Limit("", "csv"))
+	assert.Equal(t, config.Limit{Max: 1000, Window: time.Second * 10}, cfg.API.RateLimit.Limit("pro", "default"))
+	assert.Equal(t, config.Limit{Max: 10, Window: time.Minute}, cfg.API.RateLimit.Limit("pro", "csv"))
+	assert.Equal(t, config.Limit{Max: 50, Window: time.Minute}, cfg.API.RateLimit.Limit("pro", "ws"))
+	assert.Equal(t, config.Limit{Max: 100, Window: time.Second * 10}, cfg.API.RateLimit.Limit("unknown", "ws"))
+	assert.NotNil(t, cfg.API.Settings)
+	assert.Equal(t, config.SettingsModeNative, cfg.API.Settings.Mode)
 
 	assert.Equal(t, "http://localhost:5000", cfg.Miningcore.URL)
 	assert.Equal(t, "ws://localhost:5000/notifications", cfg.Miningcore.WS)
+	assert.Equal(t, time.Minute, cfg.Miningcore.WSDedupWindow)
+	assert.Equal(t, []*config.WSUpstream{
+		{Name: "default", URL: "ws://localhost:5000/notifications"},
+		{Name: "eu", URL: "ws://eu.localhost:5000/notifications"},
+		{Name: "ws://us.localhost:5000/notifications", URL: "ws://us.localhost:5000/notifications"},
+	}, cfg.Miningcore.WSSources())
 
 	assert.Equal(t, []string{"ethereum", "ergo"}, cfg.Price.Coins)
 	assert.Equal(t, []string{"usd", "eur", "chf"}, cfg.Price.VSCurrencies)
@@ -33,6 +66,12 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "metrics", cfg.Metrics.User)
 	assert.Equal(t, "metricspasswd", cfg.Metrics.Password)
 
+	assert.NotNil(t, cfg.Scheduler)
+	assert.Equal(t, true, cfg.Scheduler.Enabled)
+	assert.Equal(t, time.Minute*2, cfg.Scheduler.Interval)
+	assert.Equal(t, time.Minute*10, cfg.Scheduler.MaxAge)
+	assert.Equal(t, []int{1, 6, 24}, cfg.Scheduler.TopMinersRanges)
+
 	assert.Equal(t, "postgreshost", cfg.DB.Host)
 	assert.Equal(t, 5432, cfg.DB.Port)
 	assert.Equal(t, "postgresuser", cfg.DB.User)
@@ -61,6 +100,7 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "deroxyz", cfg.Pools[0].Address)
 	assert.Equal(t, 0.2, cfg.Pools[0].MinPayout)
 	assert.Equal(t, float64(10), cfg.Pools[0].ShareMultiplier)
+	assert.True(t, cfg.Pools[0].MaskAddresses)
 
 }
 
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrAlertSubscriptionNotFound is returned if a subscription doesn't exist or belongs to another miner
+	ErrAlertSubscriptionNotFound = errors.New("alert subscription not found")
+)
+
+// AlertSubscription is a subscription to the offline alerts of the workers of a miner.
+type AlertSubscription struct {
+	ID        int64
+	PoolID    string
+	Address   string
+	Channel   string // notifier which sends the alerts, e.g. webhook
+	Target    string // recipient of the alerts, e.g. the url of the webhook
+	Threshold int64  // seconds after which a worker without stats is offline
+	Created   time.Time
+}
+
+// AlertState is a worker which has been reported as offline to a subscription.
+type AlertState struct {
+	SubscriptionID int64
+	Worker         string
+	LastSeen       time.Time
+	Notified       time.Time
+}
+
+// WorkerLastSeen is the last time a worker had a hashrate.
+type WorkerLastSeen struct {
+	Worker   string
+	LastSeen time.Time
+}
+
+func (d *DB) CreateAlertSubscription(ctx context.Context, sub AlertSubscription) (int64, error) {
+	var id int64
+	err := d.sql.GetContext(ctx, &id, `
+		INSERT INTO phantomias_alert_subscriptions(poolid, address, channel, target, threshold, created)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id
+	`, sub.PoolID, sub.Address, sub.Channel, sub.Target, sub.Threshold, sub.Created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create alert subscription: %w", err)
+	}
+	return id, nil
+}
+
+func (d *DB) GetAlertSubscriptions(ctx context.Context) ([]*AlertSubscription, error) {
+	var subs []*AlertSubscription
+	err := d.sql.SelectContext(ctx, &subs, `
+		SELECT id, poolid, address, channel, target, threshold, created
+		FROM phantomias_alert_subscriptions
+		ORDER BY poolid, address, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+func (d *DB) GetMinerAlertSubscriptions(ctx context.Context, poolID, address string) ([]*AlertSubscription, error) {
+	var subs []*AlertSubscription
+	err := d.sql.SelectContext(ctx, &subs, `
+		SELECT id, poolid, address, channel, target, threshold, created
+		FROM phantomias_alert_subscriptions
+		WHERE poolid = $1 AND address = $2
+		ORDER BY id
+	`, poolID, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert subscriptions of miner: %w", err)
+	}
+	return subs, nil
+}
+
+func (d *DB) DeleteAlertSubscription(ctx context.Context, poolID, address string, id int64) error {
+	res, err := d.sql.ExecContext(ctx, `
+		DELETE FROM phantomias_alert_subscriptions
+		WHERE id = $1 AND poolid = $2 AND address = $3
+	`, id, poolID, address)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert subscription: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAlertSubscriptionNotFound
+	}
+	return nil
+}
+
+func (d *DB) GetAlertStates(ctx context.Context) ([]*AlertState, error) {
+	var states []*AlertState
+	err := d.sql.SelectContext(ctx, &states, `
+		SELECT subscriptionid, worker, lastseen, notified
+		FROM phantomias_alert_states
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert states: %w", err)
+	}
+	return states, nil
+}
+
+func (d *DB) InsertAlertState(ctx context.Context, state AlertState) error {
+	_, err := d.sql.ExecContext(ctx, `
+		INSERT INTO phantomias_alert_states(subscriptionid, worker, lastseen, notified)
+			VALUES($1, $2, $3, $4)
+			ON CONFLICT (subscriptionid, worker) DO UPDATE
+			SET lastseen = $3, notified = $4
+	`, state.SubscriptionID, state.Worker, state.LastSeen, state.Notified)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert state: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) DeleteAlertState(ctx context.Context, subscriptionID int64, worker string) error {
+	_, err := d.sql.ExecContext(ctx, `
+		DELETE FROM phantomias_alert_states
+		WHERE subscriptionid = $1 AND worker = $2
+	`, subscriptionID, worker)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert state: %w", err)
+	}
+	return nil
+}
+
+// GetWorkersLastSeen returns the last time the workers of the miner had a hashrate since the given time.
+func (d *DB) GetWorkersLastSeen(ctx context.Context, poolID, miner string, since time.Time) ([]*WorkerLastSeen, error) {
+	var workers []*WorkerLastSeen
+	err := d.sql.SelectContext(ctx, &workers, `
+		SELECT worker, MAX(created) AS lastseen
+		FROM minerstats
+		WHERE
+			poolid = $1 AND
+			miner = $2 AND
+			created >= $3 AND
+			hashrate > 0
+		GROUP BY worker
+	`, poolID, miner, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last seen of workers: %w", err)
+	}
+	return workers, nil
+}
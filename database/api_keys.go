@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrAPIKeyNotFound is returned if an api key doesn't exist or is already revoked
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// APIKey is an api key, only the hash of its secret is stored.
+type APIKey struct {
+	ID       string
+	Name     string
+	Hash     string
+	Scopes   string // comma separated list of scopes
+	Tier     string
+	Created  time.Time
+	LastUsed *time.Time
+	Revoked  *time.Time
+}
+
+func (d *DB) CreateAPIKey(ctx context.Context, key APIKey) error {
+	_, err := d.sql.ExecContext(ctx, `
+		INSERT INTO phantomias_api_keys(id, name, hash, scopes, tier, created)
+			VALUES($1, $2, $3, $4, $5, $6)
+	`, key.ID, key.Name, key.Hash, key.Scopes, key.Tier, key.Created)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) GetAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	err := d.sql.GetContext(ctx, &key, `
+		SELECT id, name, hash, scopes, tier, created, lastused, revoked
+		FROM phantomias_api_keys
+		WHERE id = $1
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+func (d *DB) GetAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	var keys []*APIKey
+	err := d.sql.SelectContext(ctx, &keys, `
+		SELECT id, name, hash, scopes, tier, created, lastused, revoked
+		FROM phantomias_api_keys
+		ORDER BY created
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (d *DB) RevokeAPIKey(ctx context.Context, id string) error {
+	res, err := d.sql.ExecContext(ctx, `
+		UPDATE phantomias_api_keys SET revoked = now()
+		WHERE id = $1 AND revoked IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (d *DB) TouchAPIKey(ctx context.Context, id string, lastUsed time.Time) error {
+	_, err := d.sql.ExecContext(ctx, "UPDATE phantomias_api_keys SET lastused = $2 WHERE id = $1", id, lastUsed)
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
@@ -79,3 +79,22 @@ func (d *DB) GetPoolEffort(ctx context.Context, poolID string, blocksCount int)
 	}
 	return effort, nil
 }
+
+// GetBlocksSince returns the blocks of all pools which were found since the given time
+// and the older blocks which are tracked as pending by the webhooks, see UpdateWebhookPendingBlocks.
+func (d *DB) GetBlocksSince(ctx context.Context, since time.Time) ([]*Block, error) {
+	var blocks []*Block
+	err := d.sql.SelectContext(ctx, &blocks, `
+		SELECT id, poolid, blockheight, networkdifficulty, status, type, confirmationprogress, effort, transactionconfirmationdata, miner, reward, source, hash, created
+		FROM blocks b
+		WHERE created >= $1 OR EXISTS (
+			SELECT 1 FROM phantomias_webhook_pending_blocks p
+			WHERE p.poolid = b.poolid AND p.blockid = b.id
+		)
+		ORDER BY created
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocks since: %w", err)
+	}
+	return blocks, nil
+}
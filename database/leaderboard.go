@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LeaderboardCriterion is the value by which the miners are ranked on the leaderboard.
+type LeaderboardCriterion string
+
+const (
+	LeaderboardHashrate  LeaderboardCriterion = "hashrate" // average hashrate within the window
+	LeaderboardBlocks    LeaderboardCriterion = "blocks"   // blocks found within the window
+	LeaderboardTotalPaid LeaderboardCriterion = "paid"     // payments within the window
+	LeaderboardUptime    LeaderboardCriterion = "uptime"   // share of the stats samples within the window with a hashrate
+)
+
+// column returns the column of the leaderboard by which the miners are ranked.
+func (c LeaderboardCriterion) column() string {
+	switch c {
+	case LeaderboardBlocks:
+		return "blocks"
+	case LeaderboardTotalPaid:
+		return "totalpaid"
+	case LeaderboardUptime:
+		return "uptime"
+	default:
+		return "hashrate"
+	}
+}
+
+type LeaderboardEntry struct {
+	Miner     string
+	Hashrate  float64 // average hashrate, samples without a hashrate count as zero
+	Workers   int     // max workers online at the same time
+	Blocks    int
+	TotalPaid float64
+	Uptime    float64 // 0-1
+}
+
+// leaderboardQuery returns the leaderboard of a pool in the board CTE.
+// Miners are on the leaderboard if they had a hashrate, found a block or were paid within the window
+// and didn't opt out of the leaderboards.
+const leaderboardQuery = `
+	WITH
+	samples AS (
+		SELECT COUNT(DISTINCT created) AS total
+		FROM minerstats
+		WHERE
+			poolid = $1 AND
+			created >= $2
+	),
+	mis AS (
+		SELECT
+			ms.miner,
+			SUM(ms.hashrate) AS hashrate,
+			MAX(ms.workers) AS workers,
+			COUNT(*) FILTER (WHERE ms.hashrate > 0) AS online
+		FROM (
+			SELECT
+				miner,
+				SUM(hashrate) AS hashrate,
+				COUNT(DISTINCT worker) AS workers
+			FROM minerstats
+			WHERE
+				poolid = $1 AND
+				created >= $2
+			GROUP BY miner, created
+		) ms
+		GROUP BY ms.miner
+	),
+	blks AS (
+		SELECT
+			miner,
+			COUNT(*) AS blocks
+		FROM blocks
+		WHERE
+			poolid = $1 AND
+			created >= $2 AND
+			status <> 'orphaned'
+		GROUP BY miner
+	),
+	pmts AS (
+		SELECT
+			address AS miner,
+			SUM(amount) AS totalpaid
+		FROM payments
+		WHERE
+			poolid = $1 AND
+			created >= $2
+		GROUP BY address
+	),
+	board AS (
+		SELECT
+			m.miner,
+			coalesce(mis.hashrate / NULLIF(s.total, 0), 0) AS hashrate,
+			coalesce(mis.workers, 0) AS workers,
+			coalesce(b.blocks, 0) AS blocks,
+			coalesce(p.totalpaid, 0) AS totalpaid,
+			coalesce(mis.online::float / NULLIF(s.total, 0), 0) AS uptime
+		FROM (
+			SELECT miner FROM mis
+			UNION SELECT miner FROM blks
+			UNION SELECT miner FROM pmts
+		) m
+		CROSS JOIN samples s
+		LEFT JOIN mis ON mis.miner = m.miner
+		LEFT JOIN blks b ON b.miner = m.miner
+		LEFT JOIN pmts p ON p.miner = m.miner
+		WHERE NOT EXISTS (
+			SELECT 1 FROM phantomias_leaderboard_optouts o
+			WHERE o.poolid = $1 AND o.address = m.miner
+		)
+	)
+`
+
+// GetLeaderboard returns a page of the miners of a pool ranked by the given criterion within the window since from.
+// Miners without a value for the criterion are omitted.
+func (d *DB) GetLeaderboard(ctx context.Context, poolID string, by LeaderboardCriterion, from time.Time, page, pageSize int) ([]*LeaderboardEntry, error) {
+	var entries []*LeaderboardEntry
+	err := d.sql.SelectContext(ctx, &entries, fmt.Sprintf(`%s
+	SELECT miner, hashrate, workers, blocks, totalpaid, uptime
+	FROM board
+	WHERE %[2]s > 0
+	ORDER BY %[2]s DESC, miner
+	OFFSET $3 FETCH NEXT $4 ROWS ONLY;
+	`, leaderboardQuery, by.column()), poolID, from, page*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	return entries, nil
+}
+
+// GetLeaderboardCount returns the number of miners on the leaderboard of a pool.
+func (d *DB) GetLeaderboardCount(ctx context.Context, poolID string, by LeaderboardCriterion, from time.Time) (uint, error) {
+	var count uint
+	err := d.sql.GetContext(ctx, &count, fmt.Sprintf(`%s
+	SELECT COUNT(*) FROM board WHERE %s > 0;
+	`, leaderboardQuery, by.column()), poolID, from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get leaderboard count: %w", err)
+	}
+	return count, nil
+}
+
+// GetLeaderboardOptOut returns true if the miner opted out of the leaderboards of a pool.
+func (d *DB) GetLeaderboardOptOut(ctx context.Context, poolID, address string) (bool, error) {
+	var optOut bool
+	err := d.sql.GetContext(ctx, &optOut, `
+		SELECT EXISTS (
+			SELECT 1 FROM phantomias_leaderboard_optouts
+			WHERE poolid = $1 AND address = $2
+		)
+	`, poolID, address)
+	if err != nil {
+		return false, fmt.Errorf("failed to get leaderboard opt-out: %w", err)
+	}
+	return optOut, nil
+}
+
+// SetLeaderboardOptOut removes the miner from the leaderboards of a pool or adds it back.
+func (d *DB) SetLeaderboardOptOut(ctx context.Context, poolID, address string, optOut bool, created time.Time) error {
+	if !optOut {
+		_, err := d.sql.ExecContext(ctx, `
+			DELETE FROM phantomias_leaderboard_optouts
+			WHERE poolid = $1 AND address = $2
+		`, poolID, address)
+		if err != nil {
+			return fmt.Errorf("failed to delete leaderboard opt-out: %w", err)
+		}
+		return nil
+	}
+	_, err := d.sql.ExecContext(ctx, `
+		INSERT INTO phantomias_leaderboard_optouts(poolid, address, created)
+			VALUES($1, $2, $3)
+			ON CONFLICT (poolid, address) DO NOTHING
+	`, poolID, address, created)
+	if err != nil {
+		return fmt.Errorf("failed to set leaderboard opt-out: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// migrations create the tables which are owned by phantomias.
+// The tables of miningcore are never modified.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS phantomias_api_keys (
+		id TEXT NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		tier TEXT NOT NULL DEFAULT '',
+		created TIMESTAMPTZ NOT NULL,
+		lastused TIMESTAMPTZ,
+		revoked TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_settings_history (
+		id BIGSERIAL NOT NULL PRIMARY KEY,
+		poolid TEXT NOT NULL,
+		address TEXT NOT NULL,
+		oldpaymentthreshold DECIMAL(28,12),
+		newpaymentthreshold DECIMAL(28,12) NOT NULL,
+		ipaddress TEXT NOT NULL,
+		requestedby TEXT NOT NULL,
+		proof TEXT NOT NULL,
+		created TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_phantomias_settings_history_pool_address_created
+		ON phantomias_settings_history(poolid, address, created DESC)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_alert_subscriptions (
+		id BIGSERIAL NOT NULL PRIMARY KEY,
+		poolid TEXT NOT NULL,
+		address TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		target TEXT NOT NULL,
+		threshold BIGINT NOT NULL,
+		created TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_phantomias_alert_subscriptions_pool_address
+		ON phantomias_alert_subscriptions(poolid, address)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_alert_states (
+		subscriptionid BIGINT NOT NULL REFERENCES phantomias_alert_subscriptions(id) ON DELETE CASCADE,
+		worker TEXT NOT NULL,
+		lastseen TIMESTAMPTZ NOT NULL,
+		notified TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (subscriptionid, worker)
+	)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_webhooks (
+		id BIGSERIAL NOT NULL PRIMARY KEY,
+		poolid TEXT NOT NULL,
+		address TEXT NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL,
+		created TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_webhook_deliveries (
+		id BIGSERIAL NOT NULL PRIMARY KEY,
+		webhookid BIGINT NOT NULL REFERENCES phantomias_webhooks(id) ON DELETE CASCADE,
+		eventid TEXT NOT NULL,
+		eventtype TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		nextattempt TIMESTAMPTZ NOT NULL,
+		lastattempt TIMESTAMPTZ,
+		responsestatus INT,
+		lasterror TEXT,
+		created TIMESTAMPTZ NOT NULL,
+		UNIQUE (webhookid, eventid)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_phantomias_webhook_deliveries_status_nextattempt
+		ON phantomias_webhook_deliveries(status, nextattempt)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_webhook_pending_blocks (
+		poolid TEXT NOT NULL,
+		blockid BIGINT NOT NULL,
+		PRIMARY KEY (poolid, blockid)
+	)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_worker_labels (
+		poolid TEXT NOT NULL,
+		address TEXT NOT NULL,
+		worker TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		updated TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (poolid, address, worker)
+	)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_share_stats (
+		poolid TEXT NOT NULL,
+		miner TEXT NOT NULL,
+		worker TEXT NOT NULL,
+		created TIMESTAMPTZ NOT NULL,
+		accepted BIGINT NOT NULL DEFAULT 0,
+		rejected BIGINT NOT NULL DEFAULT 0,
+		stale BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (poolid, miner, worker, created)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_phantomias_share_stats_created
+		ON phantomias_share_stats(created)`,
+	`CREATE TABLE IF NOT EXISTS phantomias_leaderboard_optouts (
+		poolid TEXT NOT NULL,
+		address TEXT NOT NULL,
+		created TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (poolid, address)
+	)`,
+}
+
+// Migrate creates the tables of phantomias if they don't exist.
+func (d *DB) Migrate(ctx context.Context) error {
+	for i, m := range migrations {
+		if _, err := d.sql.ExecContext(ctx, m); err != nil {
+			return fmt.Errorf("failed to run migration %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -37,7 +37,6 @@ type MinerWorkerPerformanceStatsEntity struct {
 }
 
 type PerformanceStatsEntity struct {
-	Partition        int
 	Created          time.Time
 	Hashrate         *float64
 	ReportedHashrate *float64
@@ -229,58 +228,14 @@ func minerWorkerPerformanceStatsToWorkerPerformanceStatsContainer(stats []*Miner
 	return container
 }
 
-func (d *DB) GetMinerPerformanceBetweenTenMinutely(ctx context.Context, poolID, miner string, start, end time.Time) ([]*PerformanceStatsEntity, error) {
+// GetMinerPerformanceBetween returns the performance of a miner, aggregated in buckets of the given interval.
+// The hashrates of the workers are averaged per bucket and summed up.
+func (d *DB) GetMinerPerformanceBetween(ctx context.Context, poolID, miner string, interval SampleInterval, start, end time.Time) ([]*PerformanceStatsEntity, error) {
 	var stats []*PerformanceStatsEntity
-	err := d.sql.SelectContext(ctx, &stats, `
-	SELECT created, partition, SUM(hashrate) AS hashrate, SUM(reportedhashrate) AS reportedhashrate, SUM(sharespersecond) AS sharespersecond, COUNT(DISTINCT worker) as workersonline FROM (
-		SELECT 
-			date_trunc('hour', x.created) AS created,
-			(extract(minute FROM x.created)::int / 10) AS partition,
-			x.worker, 
-			AVG(x.hs) AS hashrate, 
-			AVG(x.rhs) AS reportedhashrate, 
-			AVG(x.sharespersecond) AS sharespersecond
-		FROM (
-			SELECT created, hashrate as hs, null as rhs, sharespersecond, worker 
-			FROM minerstats 
-			WHERE 
-				poolid = $1 AND 
-				miner = $2 AND 
-				created >= $3 AND 
-				created <= $4
-		UNION 
-			SELECT created, null as hs, hashrate as rhs, null as sharespersecond, worker 
-			FROM reported_hashrate 
-			WHERE 
-				poolid = $1 AND 
-				miner = $2 AND 
-				created >= $3 AND 
-				created <= $4
-		) as x
-		GROUP BY 1, 2, worker
-		ORDER BY 1, 2, worker
-	) as res
-	WHERE 
-		res.hashrate IS NOT NULL OR
-		res.reportedhashrate IS NULL
-	GROUP BY 1, 2 
-	ORDER BY 1, 2;
-	`, poolID, miner, start, end)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get miner performance stats: %w", err)
-	}
-	for _, stat := range stats {
-		stat.Created = stat.Created.Add(time.Duration(stat.Partition) * 10 * time.Minute)
-	}
-	return stats, nil
-}
-
-func (d *DB) GetMinerPerformanceBetweenDaily(ctx context.Context, poolID, miner string, start, end time.Time) ([]*PerformanceStatsEntity, error) {
-	var stats []*PerformanceStatsEntity
-	err := d.sql.SelectContext(ctx, &stats, `
+	err := d.sql.SelectContext(ctx, &stats, fmt.Sprintf(`
 	SELECT created, SUM(hashrate) AS hashrate, SUM(reportedhashrate) AS reportedhashrate, SUM(sharespersecond) AS sharespersecond, COUNT(DISTINCT worker) as workersonline FROM (
 		SELECT 
-			date_trunc('day', x.created) AS created,
+			%s AS created,
 			x.worker, 
 			AVG(x.hs) AS hashrate, 
 			AVG(x.rhs) AS reportedhashrate, 
@@ -303,20 +258,20 @@ func (d *DB) GetMinerPerformanceBetweenDaily(ctx context.Context, poolID, miner
 				created <= $4
 		) as x
 		GROUP BY 1, worker
-		ORDER BY 1
-
+		ORDER BY 1, worker
 	) as res
 	WHERE 
 		res.hashrate IS NOT NULL OR
 		res.reportedhashrate IS NULL
 	GROUP BY created
 	ORDER BY created;
-	`, poolID, miner, start, end)
+	`, interval.bucket("x.created")), poolID, miner, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get miner performance stats: %w", err)
 	}
 	return stats, nil
 }
+
 func (d *DB) GetTopMinerStats(ctx context.Context, poolID string, from time.Time, page int, pageSize int) ([]*TopMinerStats, error) {
 	var stats []*TopMinerStats
 	err := d.sql.SelectContext(ctx, &stats, `
@@ -385,6 +340,7 @@ func (d *DB) GetTopMinerStats(ctx context.Context, poolID string, from time.Time
 	/*
 	 * In this final query you JOIN the minerstats with the payments by poolid and miner=address.
 	 * So you get the sum of the hashrates and the shares per seconds from the minerstats and the sum of the payments for each selected miner
+	 * Miners which opted out of the leaderboards are omitted.
 	 */
 	SELECT
 		m.miner,
@@ -396,11 +352,15 @@ func (d *DB) GetTopMinerStats(ctx context.Context, poolID string, from time.Time
 	LEFT JOIN pmts p ON p.poolid = m.poolid AND p.address = m.miner
 	LEFT JOIN blcs b ON b.poolid = m.poolid AND b.address = m.miner
 	WHERE
-		m.rk = 1
+		m.rk = 1 AND
+		NOT EXISTS (
+			SELECT 1 FROM phantomias_leaderboard_optouts o
+			WHERE o.poolid = m.poolid AND o.address = m.miner
+		)
 	ORDER by
 		m.hashrate DESC
 	OFFSET $3 FETCH NEXT $4 ROWS ONLY;
-	`, poolID, from, page, pageSize)
+	`, poolID, from, page*pageSize, pageSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get top miner stats: %w", err)
 	}
@@ -127,3 +127,18 @@ func (d *DB) GetMinerPaymentsByDayBetween(ctx context.Context, poolID, address s
 	`, poolID, address, start, end)
 	return payments, err
 }
+
+// GetPaymentsSince returns the payments of all pools which were sent since the given time.
+func (d *DB) GetPaymentsSince(ctx context.Context, since time.Time) ([]*Payment, error) {
+	var payments []*Payment
+	err := d.sql.SelectContext(ctx, &payments, `
+		SELECT id, poolid, coin, address, amount, transactionconfirmationdata, created
+		FROM payments
+		WHERE created >= $1
+		ORDER BY created
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments since: %w", err)
+	}
+	return payments, nil
+}
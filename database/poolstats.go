@@ -79,10 +79,39 @@ func (d *DB) GetTotalPoolPayments(ctx context.Context, poolID string) (decimal.D
 type SampleInterval string
 
 const (
-	IntervalHour SampleInterval = "hour"
-	IntervalDay  SampleInterval = "day"
+	IntervalTenMinutes SampleInterval = "tenminutes"
+	IntervalHour       SampleInterval = "hour"
+	IntervalDay        SampleInterval = "day"
+	IntervalWeek       SampleInterval = "week"
 )
 
+// Duration returns the length of a bucket of the interval.
+func (i SampleInterval) Duration() time.Duration {
+	switch i {
+	case IntervalTenMinutes:
+		return 10 * time.Minute
+	case IntervalDay:
+		return 24 * time.Hour
+	case IntervalWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+// bucket returns the sql expression which truncates the column to the start of its bucket.
+// Unknown intervals are aggregated hourly.
+func (i SampleInterval) bucket(column string) string {
+	switch i {
+	case IntervalTenMinutes:
+		return fmt.Sprintf("date_trunc('hour', %[1]s) + (extract(minute FROM %[1]s)::int / 10) * INTERVAL '10 minutes'", column)
+	case IntervalDay, IntervalWeek:
+		return fmt.Sprintf("date_trunc('%s', %s)", i, column)
+	default:
+		return fmt.Sprintf("date_trunc('hour', %s)", column)
+	}
+}
+
 type SampleRange string
 
 const (
@@ -92,25 +121,34 @@ const (
 )
 
 var ErrInvalidSampleInterval = fmt.Errorf("invalid sample interval")
+var ErrInvalidSampleRange = fmt.Errorf("invalid sample range")
 
-func (d *DB) GetPoolPerformanceBetween(ctx context.Context, poolID string, interval SampleInterval, start, end time.Time) ([]*AggregatedPoolStats, error) {
-	var trunc string
-	switch i := interval; i {
-	case IntervalHour, IntervalDay:
-		trunc = string(i)
+// Start returns the start of the range which ends at the given time.
+func (r SampleRange) Start(end time.Time) (time.Time, error) {
+	switch r {
+	case RangeHour:
+		return end.Add(-1 * time.Hour), nil
+	case RangeDay:
+		return end.Add(-24 * time.Hour), nil
+	case RangeMonth:
+		return end.Add(-30 * 24 * time.Hour), nil
 	default:
-		trunc = string(IntervalHour)
+		return time.Time{}, ErrInvalidSampleRange
 	}
+}
+
+func (d *DB) GetPoolPerformanceBetween(ctx context.Context, poolID string, interval SampleInterval, start, end time.Time) ([]*AggregatedPoolStats, error) {
+	bucket := interval.bucket("created")
 	var stats []*AggregatedPoolStats
 	err := d.sql.SelectContext(ctx, &stats, fmt.Sprintf(`
-		SELECT date_trunc('%s', created) AS created,
+		SELECT %s AS created,
 		AVG(poolhashrate) AS poolhashrate, AVG(networkhashrate) AS networkhashrate, AVG(networkdifficulty) AS networkdifficulty,
 		CAST(AVG(connectedminers) AS BIGINT) AS connectedminers
 		FROM poolstats
 		WHERE poolid = $1 AND created >= $2 AND created <= $3
-		GROUP BY date_trunc('%s', created)
+		GROUP BY 1
 		ORDER BY created;
-	`, trunc, trunc), poolID, start, end)
+	`, bucket), poolID, start, end)
 	return stats, err
 }
 
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SettingsChange is an entry of the audit log of the miner settings.
+type SettingsChange struct {
+	ID                  int64
+	PoolID              string
+	Address             string
+	OldPaymentThreshold decimal.NullDecimal // not set if the miner had no settings before
+	NewPaymentThreshold decimal.Decimal
+	IPAddress           string // IP of the client which requested the change
+	RequestedBy         string // identity of the client, e.g. the id of its api key
+	Proof               string // method which proved the ownership of the address
+	Created             time.Time
+}
+
+func (d *DB) InsertSettingsChange(ctx context.Context, change SettingsChange) error {
+	_, err := d.sql.ExecContext(ctx, `
+		INSERT INTO phantomias_settings_history(poolid, address, oldpaymentthreshold, newpaymentthreshold, ipaddress, requestedby, proof, created)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	`, change.PoolID, change.Address, change.OldPaymentThreshold, change.NewPaymentThreshold, change.IPAddress, change.RequestedBy, change.Proof, change.Created)
+	if err != nil {
+		return fmt.Errorf("failed to insert settings change: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) PageSettingsChanges(ctx context.Context, poolID, address string, page, pageSize int) ([]*SettingsChange, error) {
+	var changes []*SettingsChange
+	err := d.sql.SelectContext(ctx, &changes, `
+		SELECT id, poolid, address, oldpaymentthreshold, newpaymentthreshold, ipaddress, requestedby, proof, created
+		FROM phantomias_settings_history
+		WHERE poolid = $1 AND address = $2
+		ORDER BY created DESC
+		OFFSET $3 FETCH NEXT $4 ROWS ONLY
+	`, poolID, address, page*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (d *DB) GetSettingsChangesCount(ctx context.Context, poolID, address string) (uint, error) {
+	var count uint
+	err := d.sql.GetContext(ctx, &count, "SELECT COUNT(*) FROM phantomias_settings_history WHERE poolid = $1 AND address = $2", poolID, address)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get settings changes count: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ShareStats are the numbers of shares of a worker by their result within an hour.
+// They are counted from the share notifications of the upstreams (see ws.ShareStats), the shares table only contains accepted shares.
+type ShareStats struct {
+	PoolID   string
+	Miner    string
+	Worker   string
+	Created  time.Time // start of the hour
+	Accepted int64
+	Rejected int64
+	Stale    int64
+}
+
+// AddShareStats adds the counts to the stored share stats.
+func (d *DB) AddShareStats(ctx context.Context, stats []*ShareStats) error {
+	tx, err := d.sql.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add share stats: %w", err)
+	}
+	defer tx.Rollback()
+	for _, s := range stats {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO phantomias_share_stats(poolid, miner, worker, created, accepted, rejected, stale)
+				VALUES($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT (poolid, miner, worker, created) DO UPDATE SET
+					accepted = phantomias_share_stats.accepted + EXCLUDED.accepted,
+					rejected = phantomias_share_stats.rejected + EXCLUDED.rejected,
+					stale = phantomias_share_stats.stale + EXCLUDED.stale
+		`, s.PoolID, s.Miner, s.Worker, s.Created, s.Accepted, s.Rejected, s.Stale)
+		if err != nil {
+			return fmt.Errorf("failed to add share stats: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add share stats: %w", err)
+	}
+	return nil
+}
+
+// DeleteShareStatsBefore deletes the share stats which were counted before the given time.
+func (d *DB) DeleteShareStatsBefore(ctx context.Context, before time.Time) error {
+	_, err := d.sql.ExecContext(ctx, `
+		DELETE FROM phantomias_share_stats
+		WHERE created < $1
+	`, before)
+	if err != nil {
+		return fmt.Errorf("failed to delete share stats: %w", err)
+	}
+	return nil
+}
+
+// GetWorkersShareStats returns the share stats of the workers of a miner since the given time, summed up per worker.
+func (d *DB) GetWorkersShareStats(ctx context.Context, poolID, miner string, since time.Time) ([]*ShareStats, error) {
+	var stats []*ShareStats
+	err := d.sql.SelectContext(ctx, &stats, `
+		SELECT poolid, miner, worker, MIN(created) AS created,
+			SUM(accepted) AS accepted, SUM(rejected) AS rejected, SUM(stale) AS stale
+		FROM phantomias_share_stats
+		WHERE poolid = $1 AND miner = $2 AND created >= $3
+		GROUP BY poolid, miner, worker
+		ORDER BY worker
+	`, poolID, miner, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share stats: %w", err)
+	}
+	return stats, nil
+}
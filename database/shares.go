@@ -19,10 +19,11 @@ type Share struct {
 	Created           time.Time
 }
 
+// GetRecentyUsedIPAddresses returns the IP addresses of the last 100 shares of the miner, the most recently used first.
 func (d *DB) GetRecentyUsedIPAddresses(ctx context.Context, poolID, miner string) ([]string, error) {
 	var ips []string
 	err := d.sql.SelectContext(ctx, &ips, `
-		SELECT DISTINCT s.ipaddress 
+		SELECT s.ipaddress 
 		FROM (
 			SELECT
 				poolid,
@@ -42,7 +43,9 @@ func (d *DB) GetRecentyUsedIPAddresses(ctx context.Context, poolID, miner string
 			ORDER BY 
 				created DESC 
 			LIMIT 100
-		) s;
+		) s
+		GROUP BY s.ipaddress
+		ORDER BY MAX(s.created) DESC;
 	`, poolID, miner)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent ip addresses: %w", err)
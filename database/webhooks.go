@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrWebhookNotFound is returned if a webhook doesn't exist or belongs to another miner
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFound is returned if a delivery doesn't exist or isn't in the dead letters
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// states of a webhook delivery
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed" // dead letter, all attempts failed
+)
+
+// Webhook receives the events of the pools.
+// Events can be filtered by pool and by the address of the miner.
+type Webhook struct {
+	ID      int64
+	PoolID  string // empty for all pools
+	Address string // empty for all miners
+	URL     string
+	Secret  string // key of the hmac signatures
+	Events  string // comma separated list of event types
+	Created time.Time
+}
+
+// WebhookDelivery is an event which is sent to a webhook.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttempt    time.Time
+	LastAttempt    *time.Time
+	ResponseStatus *int
+	LastError      *string
+	Created        time.Time
+}
+
+func (d *DB) CreateWebhook(ctx context.Context, wh Webhook) (int64, error) {
+	var id int64
+	err := d.sql.GetContext(ctx, &id, `
+		INSERT INTO phantomias_webhooks(poolid, address, url, secret, events, created)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id
+	`, wh.PoolID, wh.Address, wh.URL, wh.Secret, wh.Events, wh.Created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return id, nil
+}
+
+func (d *DB) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	err := d.sql.SelectContext(ctx, &webhooks, `
+		SELECT id, poolid, address, url, secret, events, created
+		FROM phantomias_webhooks
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (d *DB) GetMinerWebhooks(ctx context.Context, poolID, address string) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	err := d.sql.SelectContext(ctx, &webhooks, `
+		SELECT id, poolid, address, url, secret, events, created
+		FROM phantomias_webhooks
+		WHERE poolid = $1 AND address = $2
+		ORDER BY id
+	`, poolID, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks of miner: %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook incl. its deliveries.
+// The pool and address have to match the webhook, unless both are empty.
+func (d *DB) DeleteWebhook(ctx context.Context, poolID, address string, id int64) error {
+	query := "DELETE FROM phantomias_webhooks WHERE id = $1"
+	args := []any{id}
+	if poolID != "" || address != "" {
+		query += " AND poolid = $2 AND address = $3"
+		args = append(args, poolID, address)
+	}
+	res, err := d.sql.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// InsertWebhookDelivery queues an event for a webhook.
+// It returns false if the event was already queued for the webhook.
+func (d *DB) InsertWebhookDelivery(ctx context.Context, delivery WebhookDelivery) (bool, error) {
+	res, err := d.sql.ExecContext(ctx, `
+		INSERT INTO phantomias_webhook_deliveries(webhookid, eventid, eventtype, payload, status, nextattempt, created)
+			VALUES($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (webhookid, eventid) DO NOTHING
+	`, delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload, DeliveryStatusPending, delivery.NextAttempt, delivery.Created)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert webhook delivery: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return err == nil && n > 0, nil
+}
+
+// ClaimWebhookDeliveries returns the pending deliveries which are due and postpones them until the given time,
+// so they aren't claimed by another instance while they are sent.
+func (d *DB) ClaimWebhookDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := d.sql.SelectContext(ctx, &deliveries, `
+		UPDATE phantomias_webhook_deliveries SET nextattempt = $2
+		WHERE id IN (
+			SELECT id FROM phantomias_webhook_deliveries
+			WHERE status = $4 AND nextattempt <= $1
+			ORDER BY nextattempt
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, webhookid, eventid, eventtype, payload, status, attempts, nextattempt, lastattempt, responsestatus, lasterror, created
+	`, now, until, limit, DeliveryStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery stores the result of an attempt.
+func (d *DB) UpdateWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE phantomias_webhook_deliveries
+		SET status = $2, attempts = $3, nextattempt = $4, lastattempt = $5, responsestatus = $6, lasterror = $7
+		WHERE id = $1
+	`, delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttempt, delivery.LastAttempt, delivery.ResponseStatus, delivery.LastError)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookDelivery queues a dead letter again.
+func (d *DB) RetryWebhookDelivery(ctx context.Context, id int64, now time.Time) error {
+	res, err := d.sql.ExecContext(ctx, `
+		UPDATE phantomias_webhook_deliveries
+		SET status = $2, attempts = 0, nextattempt = $3
+		WHERE id = $1 AND status = $4
+	`, id, DeliveryStatusPending, now, DeliveryStatusFailed)
+	if err != nil {
+		return fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// PageWebhookDeliveries returns the deliveries of a webhook or the dead letters of all webhooks if status is failed.
+func (d *DB) PageWebhookDeliveries(ctx context.Context, webhookID int64, status string, page, pageSize int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := d.sql.SelectContext(ctx, &deliveries, `
+		SELECT id, webhookid, eventid, eventtype, payload, status, attempts, nextattempt, lastattempt, responsestatus, lasterror, created
+		FROM phantomias_webhook_deliveries
+		WHERE ($1 = 0 OR webhookid = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created DESC, id DESC
+		OFFSET $3 FETCH NEXT $4 ROWS ONLY
+	`, webhookID, status, page*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (d *DB) GetWebhookDeliveriesCount(ctx context.Context, webhookID int64, status string) (uint, error) {
+	var count uint
+	err := d.sql.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM phantomias_webhook_deliveries
+		WHERE ($1 = 0 OR webhookid = $1) AND ($2 = '' OR status = $2)
+	`, webhookID, status)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get webhook deliveries count: %w", err)
+	}
+	return count, nil
+}
+
+// UpdateWebhookPendingBlocks tracks the pending blocks and stops tracking the blocks which are no longer pending.
+// Tracked blocks are returned by GetBlocksSince until their status changed, even if they are older than the lookback.
+func (d *DB) UpdateWebhookPendingBlocks(ctx context.Context, pending, done []*Block) error {
+	tx, err := d.sql.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook pending blocks: %w", err)
+	}
+	defer tx.Rollback()
+	for _, b := range pending {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO phantomias_webhook_pending_blocks(poolid, blockid)
+				VALUES($1, $2)
+				ON CONFLICT (poolid, blockid) DO NOTHING
+		`, b.PoolID, b.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update webhook pending blocks: %w", err)
+		}
+	}
+	for _, b := range done {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM phantomias_webhook_pending_blocks
+			WHERE poolid = $1 AND blockid = $2
+		`, b.PoolID, b.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update webhook pending blocks: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update webhook pending blocks: %w", err)
+	}
+	return nil
+}
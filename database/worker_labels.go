@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WorkerLabels are the labels which a miner attached to a worker.
+type WorkerLabels struct {
+	Worker  string
+	Labels  string // comma separated list of labels
+	Updated time.Time
+}
+
+// GetWorkerLabels returns the labels of the workers of a miner by the name of the worker.
+func (d *DB) GetWorkerLabels(ctx context.Context, poolID, address string) (map[string][]string, error) {
+	var rows []*WorkerLabels
+	err := d.sql.SelectContext(ctx, &rows, `
+		SELECT worker, labels, updated
+		FROM phantomias_worker_labels
+		WHERE poolid = $1 AND address = $2
+	`, poolID, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worker labels: %w", err)
+	}
+	labels := make(map[string][]string, len(rows))
+	for _, r := range rows {
+		labels[r.Worker] = strings.Split(r.Labels, ",")
+	}
+	return labels, nil
+}
+
+// SetWorkerLabels replaces the labels of a worker, no labels remove all labels of the worker.
+func (d *DB) SetWorkerLabels(ctx context.Context, poolID, address, worker string, labels []string, updated time.Time) error {
+	if len(labels) == 0 {
+		_, err := d.sql.ExecContext(ctx, `
+			DELETE FROM phantomias_worker_labels
+			WHERE poolid = $1 AND address = $2 AND worker = $3
+		`, poolID, address, worker)
+		if err != nil {
+			return fmt.Errorf("failed to delete worker labels: %w", err)
+		}
+		return nil
+	}
+	_, err := d.sql.ExecContext(ctx, `
+		INSERT INTO phantomias_worker_labels(poolid, address, worker, labels, updated)
+			VALUES($1, $2, $3, $4, $5)
+			ON CONFLICT (poolid, address, worker) DO UPDATE SET labels = EXCLUDED.labels, updated = EXCLUDED.updated
+	`, poolID, address, worker, strings.Join(labels, ","), updated)
+	if err != nil {
+		return fmt.Errorf("failed to set worker labels: %w", err)
+	}
+	return nil
+}
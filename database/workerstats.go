@@ -13,14 +13,14 @@ type WorkerStats struct {
 	SharesPerSecond *float64
 }
 
-func (d *DB) GetWorkerPerformanceBetweenTenMinutely(ctx context.Context, poolID, miner, worker string, start, end time.Time) ([]*PerformanceStatsEntity, error) {
+// GetWorkerPerformanceBetween returns the performance of a worker, aggregated in buckets of the given interval.
+func (d *DB) GetWorkerPerformanceBetween(ctx context.Context, poolID, miner, worker string, interval SampleInterval, start, end time.Time) ([]*PerformanceStatsEntity, error) {
 	var stats []*PerformanceStatsEntity
-	err := d.sql.SelectContext(ctx, &stats, `
+	err := d.sql.SelectContext(ctx, &stats, fmt.Sprintf(`
 	SELECT * FROM
 	(
 	SELECT 
-		date_trunc('hour', x.created) AS created,
-		(extract(minute FROM x.created)::int / 10) AS partition,
+		%s AS created,
 		AVG(x.hs) AS hashrate, 
 		AVG(x.rhs) AS reportedhashrate, 
 		AVG(x.sharespersecond) AS sharespersecond
@@ -43,19 +43,16 @@ func (d *DB) GetWorkerPerformanceBetweenTenMinutely(ctx context.Context, poolID,
 			created >= $4 AND 
 			created <= $5
 	) as x
-	GROUP BY 1, 2
-	ORDER BY 1, 2
+	GROUP BY 1
+	ORDER BY 1
 	) as res
 	WHERE 
 		res.hashrate IS NOT NULL OR 
 		res.reportedhashrate IS NULL;
-	`, poolID, miner, worker, start, end)
+	`, interval.bucket("x.created")), poolID, miner, worker, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get worker performance stats: %w", err)
 	}
-	for _, stat := range stats {
-		stat.Created = stat.Created.Add(time.Duration(stat.Partition) * 10 * time.Minute)
-	}
 	return stats, nil
 }
 
@@ -79,3 +76,117 @@ func (d *DB) GetWorkerStats(ctx context.Context, poolID, miner, worker string) (
 	}
 	return stats, nil
 }
+
+// WorkerActivity is the latest activity of a worker within a window.
+type WorkerActivity struct {
+	Worker           string
+	Hashrate         *float64 // latest hashrate
+	AverageHashrate  *float64 // average hashrate within the window
+	ReportedHashrate *float64 // latest hashrate reported by the mining software
+	SharesPerSecond  *float64
+	LastSeen         *time.Time // latest share or stats with a hashrate of the worker
+	UserAgent        *string    // user agent of the latest share
+}
+
+// GetWorkersActivity returns all workers of the miner which were seen since the given time.
+func (d *DB) GetWorkersActivity(ctx context.Context, poolID, miner string, since time.Time) ([]*WorkerActivity, error) {
+	var workers []*WorkerActivity
+	err := d.sql.SelectContext(ctx, &workers, `
+	WITH stats AS (
+		SELECT
+			worker,
+			MAX(created) FILTER (WHERE hashrate > 0) AS laststats,
+			AVG(hashrate) AS averagehashrate
+		FROM minerstats
+		WHERE
+			poolid = $1 AND
+			miner = $2 AND
+			created >= $3
+		GROUP BY worker
+	), latest AS (
+		SELECT DISTINCT ON (worker) worker, hashrate, sharespersecond
+		FROM minerstats
+		WHERE
+			poolid = $1 AND
+			miner = $2 AND
+			created >= $3
+		ORDER BY worker, created DESC
+	), shares AS (
+		SELECT DISTINCT ON (worker) worker, created AS lastshare, useragent
+		FROM shares
+		WHERE
+			poolid = $1 AND
+			miner = $2 AND
+			created >= $3
+		ORDER BY worker, created DESC
+	), reported AS (
+		SELECT DISTINCT ON (worker) worker, hashrate AS reportedhashrate
+		FROM reported_hashrate
+		WHERE
+			poolid = $1 AND
+			miner = $2 AND
+			created >= $3
+		ORDER BY worker, created DESC
+	)
+	SELECT
+		COALESCE(stats.worker, shares.worker) AS worker,
+		latest.hashrate,
+		stats.averagehashrate,
+		reported.reportedhashrate,
+		latest.sharespersecond,
+		GREATEST(stats.laststats, shares.lastshare) AS lastseen,
+		shares.useragent
+	FROM stats
+	FULL OUTER JOIN shares ON shares.worker = stats.worker
+	LEFT JOIN latest ON latest.worker = stats.worker
+	LEFT JOIN reported ON reported.worker = COALESCE(stats.worker, shares.worker)
+	ORDER BY worker;
+	`, poolID, miner, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workers activity: %w", err)
+	}
+	return workers, nil
+}
+
+// WorkerPerformanceSample is the effective and the reported hashrate of a worker within a bucket.
+type WorkerPerformanceSample struct {
+	Worker           string
+	Created          time.Time
+	Hashrate         *float64
+	ReportedHashrate *float64
+}
+
+// GetWorkersPerformanceBetween returns the performance of all workers of a miner, aggregated in buckets of the given interval.
+func (d *DB) GetWorkersPerformanceBetween(ctx context.Context, poolID, miner string, interval SampleInterval, start, end time.Time) ([]*WorkerPerformanceSample, error) {
+	var samples []*WorkerPerformanceSample
+	err := d.sql.SelectContext(ctx, &samples, fmt.Sprintf(`
+	SELECT 
+		x.worker,
+		%s AS created,
+		AVG(x.hs) AS hashrate, 
+		AVG(x.rhs) AS reportedhashrate
+	FROM (
+		SELECT created, hashrate as hs, null as rhs, worker 
+		FROM minerstats 
+		WHERE 
+			poolid = $1 AND 
+			miner = $2 AND 
+			created >= $3 AND 
+			created <= $4
+	UNION ALL
+		SELECT created, null as hs, hashrate as rhs, worker 
+		FROM reported_hashrate 
+		WHERE 
+			poolid = $1 AND 
+			miner = $2 AND 
+			created >= $3 AND 
+			created <= $4
+	) as x
+	GROUP BY 1, 2
+	ORDER BY 1, 2;
+	`, interval.bucket("x.created")), poolID, miner, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workers performance stats: %w", err)
+	}
+	return samples, nil
+}
@@ -20,6 +20,32 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/alerts/channels": {
+            "get": {
+                "description": "Get the channels through which alerts can be sent and the limits of subscriptions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Get the alert channels",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AlertChannelsRes"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/pools": {
             "get": {
                 "description": "Get a list of all available pools",
@@ -94,7 +120,9 @@ const docTemplate = `{
             "get": {
                 "description": "Get a list of blocks from a specific pool",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
                     "Pools"
@@ -137,16 +165,18 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners": {
+        "/api/v1/pools/{pool_id}/leaderboard": {
             "get": {
-                "description": "Get a list of all miners from a specific pool",
+                "description": "Get the miners of a specific pool ranked by their average hashrate, found blocks, payments or uptime within a window.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "Miners"
+                    "Pools"
                 ],
-                "summary": "Get a list of all miners",
+                "summary": "Get the leaderboard of a pool",
                 "parameters": [
                     {
                         "type": "string",
@@ -155,6 +185,24 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Ranking criterion, hashrate, blocks, paid or uptime (default=hashrate)",
+                        "name": "by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window of the ranking, 1h, 24h, 7d or 30d (default=24h)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Mask the addresses of the miners, always masked if configured for the pool",
+                        "name": "mask",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Page (default=0)",
@@ -163,7 +211,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "PageSize (default=15)",
+                        "description": "Page size, max 100 (default=15)",
                         "name": "pageSize",
                         "in": "query"
                     }
@@ -172,7 +220,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MinersRes"
+                            "$ref": "#/definitions/api.LeaderboardRes"
                         }
                     },
                     "400": {
@@ -184,16 +232,18 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}": {
+        "/api/v1/pools/{pool_id}/miners": {
             "get": {
-                "description": "Get a specific miner from a specific pool",
+                "description": "Get a list of all miners from a specific pool",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
                     "Miners"
                 ],
-                "summary": "Get a miner",
+                "summary": "Get a list of all miners",
                 "parameters": [
                     {
                         "type": "string",
@@ -203,18 +253,23 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Address of the miner",
-                        "name": "miner_addr",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MinerRes"
+                            "$ref": "#/definitions/api.MinersRes"
                         }
                     },
                     "400": {
@@ -226,16 +281,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/balancechanges": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}": {
             "get": {
-                "description": "Get a list of balance changes from a specific miner from a specific pool",
+                "description": "Get a specific miner from a specific pool",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Miners"
                 ],
-                "summary": "Get balance changes",
+                "summary": "Get a miner",
                 "parameters": [
                     {
                         "type": "string",
@@ -252,15 +307,15 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Page (default=0)",
-                        "name": "page",
+                        "type": "string",
+                        "description": "Group the workers into rigs by the part of their name before this separator, e.g. .",
+                        "name": "group",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "PageSize (default=15)",
-                        "name": "pageSize",
+                        "type": "string",
+                        "description": "Group the workers into rigs by the first group or the match of this regex",
+                        "name": "groupRegex",
                         "in": "query"
                     }
                 ],
@@ -268,7 +323,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.BalanceChangesRes"
+                            "$ref": "#/definitions/api.MinerRes"
                         }
                     },
                     "400": {
@@ -280,16 +335,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/csv": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/alerts": {
             "get": {
-                "description": "Download miner specific data as CSV",
+                "description": "Get the subscriptions to the worker offline alerts from a specific miner from a specific pool.\nThe targets are masked for requests without an api key with the admin scope.",
                 "produces": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "tags": [
-                    "CSV"
+                    "Alerts"
                 ],
-                "summary": "Download data as CSV",
+                "summary": "Get alert subscriptions",
                 "parameters": [
                     {
                         "type": "string",
@@ -304,52 +359,107 @@ const docTemplate = `{
                         "name": "miner_addr",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AlertSubscriptionsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe to alerts which are sent if a worker of a specific miner from a specific pool has no hashrate for longer than the threshold.\nThe ownership of the address is proven the same way as for settings updates.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Subscribe to alerts",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Specify the data type (stats, payouts, earnings)",
-                        "name": "data",
-                        "in": "query",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Start time (RFC3339 format)",
-                        "name": "start",
-                        "in": "query",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "End time (RFC3339 format)",
-                        "name": "end",
-                        "in": "query",
-                        "required": true
+                        "description": "Channel, target and threshold in seconds incl. the proof of ownership",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AlertSubscriptionReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": ""
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AlertSubscriptionRes"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/utils.APIError"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/earnings/daily": {
-            "get": {
-                "description": "Get a list of daily earnings from a specific miner from a specific pool",
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/alerts/{sub_id}": {
+            "delete": {
+                "description": "Delete a subscription to the worker offline alerts from a specific miner from a specific pool.\nThe ownership of the address is proven the same way as for settings updates, it isn't required for api keys with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Miners"
+                    "Alerts"
                 ],
-                "summary": "Get daily earnings",
+                "summary": "Unsubscribe from alerts",
                 "parameters": [
                     {
                         "type": "string",
@@ -367,22 +477,25 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Page (default=0)",
-                        "name": "page",
-                        "in": "query"
+                        "description": "ID of the subscription",
+                        "name": "sub_id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "PageSize (default=15)",
-                        "name": "pageSize",
-                        "in": "query"
+                        "description": "Proof of ownership",
+                        "name": "proof",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.OwnershipProof"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.DailyEarningRes"
+                            "$ref": "#/definitions/api.Meta"
                         }
                     },
                     "400": {
@@ -390,20 +503,38 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.APIError"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/payments": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/balancechanges": {
             "get": {
-                "description": "Get a list of payments from a specific miner from a specific pool",
+                "description": "Get a list of balance changes from a specific miner from a specific pool",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Miners"
                 ],
-                "summary": "Get payments",
+                "summary": "Get balance changes",
                 "parameters": [
                     {
                         "type": "string",
@@ -436,7 +567,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.PaymentsRes"
+                            "$ref": "#/definitions/api.BalanceChangesRes"
                         }
                     },
                     "400": {
@@ -448,16 +579,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/performance": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/csv": {
             "get": {
-                "description": "Get a list of performance samples from a specific miner from a specific pool",
+                "description": "Download miner specific data as CSV",
                 "produces": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "tags": [
-                    "Miners"
+                    "CSV"
                 ],
-                "summary": "Get performance",
+                "summary": "Download data as CSV",
                 "parameters": [
                     {
                         "type": "string",
@@ -475,17 +606,29 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Specify the sample range (default=day",
-                        "name": "perfMode",
-                        "in": "query"
+                        "description": "Specify the data type (stats, payouts, earnings)",
+                        "name": "data",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC3339 format)",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC3339 format)",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.MinerPerformanceRes"
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -496,16 +639,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/settings": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/earnings/daily": {
             "get": {
-                "description": "Get the settings from a specific miner from a specific pool",
+                "description": "Get a list of daily earnings from a specific miner from a specific pool",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Miners"
                 ],
-                "summary": "Get settings",
+                "summary": "Get daily earnings",
                 "parameters": [
                     {
                         "type": "string",
@@ -520,13 +663,25 @@ const docTemplate = `{
                         "name": "miner_addr",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MinerSettingsRes"
+                            "$ref": "#/definitions/api.DailyEarningRes"
                         }
                     },
                     "400": {
@@ -536,16 +691,20 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Update the settings from a specific miner from a specific pool",
+            }
+        },
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/health": {
+            "get": {
+                "description": "Compare the effective and the reported hashrate of all workers of a specific miner from a specific pool within a window.\nWorkers are flagged if most of their samples deviate by more than the threshold, flagged workers with the largest deviation come first.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "Miners"
+                    "Workers"
                 ],
-                "summary": "Update settings",
+                "summary": "Get the hashrate health of the workers of a miner",
                 "parameters": [
                     {
                         "type": "string",
@@ -562,20 +721,23 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Updated settings incl. the IP of the highest worker",
-                        "name": "settings",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.MinerSettingsReq"
-                        }
-                    }
+                        "type": "string",
+                        "description": "Window which is analyzed, e.g. 6h or 168h (default=24h)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Max deviation of the effective from the reported hashrate in percent (default=10)",
+                        "name": "threshold",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MinerSettingsRes"
+                            "$ref": "#/definitions/api.WorkersHealthRes"
                         }
                     },
                     "400": {
@@ -587,16 +749,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/payments": {
             "get": {
-                "description": "Get a specific worker from a specific miner from a specific pool",
+                "description": "Get a list of payments from a specific miner from a specific pool",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Workers"
+                    "Miners"
                 ],
-                "summary": "Get a worker",
+                "summary": "Get payments",
                 "parameters": [
                     {
                         "type": "string",
@@ -613,18 +775,23 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Name of the worker",
-                        "name": "worker_name",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.WorkerRes"
+                            "$ref": "#/definitions/api.PaymentsRes"
                         }
                     },
                     "400": {
@@ -636,16 +803,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}/performance": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/performance": {
             "get": {
-                "description": "Get the performance from a specific worker from a specific miner from a specific pool",
+                "description": "Get a list of performance samples from a specific miner from a specific pool",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Workers"
+                    "Miners"
                 ],
-                "summary": "Get performance stats of a worker",
+                "summary": "Get performance",
                 "parameters": [
                     {
                         "type": "string",
@@ -663,15 +830,26 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Name of the worker",
-                        "name": "worker_name",
-                        "in": "path",
-                        "required": true
+                        "description": "Specify the sample range (default=day",
+                        "name": "perfMode",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Specify the sample range (default=day",
-                        "name": "perfMode",
+                        "description": "Start of the range in RFC3339, overwrites the perfMode",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range in RFC3339, overwrites the perfMode",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interval of the samples (10m, 1h, 1d, 1w), chosen by the length of the range by default",
+                        "name": "interval",
                         "in": "query"
                     }
                 ],
@@ -679,7 +857,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.WorkerPerformanceRes"
+                            "$ref": "#/definitions/api.MinerPerformanceRes"
                         }
                     },
                     "400": {
@@ -691,16 +869,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/payments": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/settings": {
             "get": {
-                "description": "Get a list of payments from a specific pool",
+                "description": "Get the settings from a specific miner from a specific pool",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Pools"
+                    "Miners"
                 ],
-                "summary": "Get a list of payments",
+                "summary": "Get settings",
                 "parameters": [
                     {
                         "type": "string",
@@ -710,23 +888,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Page (default=0)",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "PageSize (default=15)",
-                        "name": "pageSize",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.PaymentsRes"
+                            "$ref": "#/definitions/api.MinerSettingsRes"
                         }
                     },
                     "400": {
@@ -736,18 +909,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/pools/{pool_id}/performance": {
-            "get": {
-                "description": "Get a list of performance samples from a specific pool",
+            },
+            "post": {
+                "description": "Update the settings from a specific miner from a specific pool.\nThe ownership of the address is proven either by the IP of the highest worker\nor by the signature of the message of a nonce, see /settings/nonce.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Pools"
+                    "Miners"
                 ],
-                "summary": "Get a list of performance samples",
+                "summary": "Update settings",
                 "parameters": [
                     {
                         "type": "string",
@@ -758,22 +929,26 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "sample interval (default=Hour)",
-                        "name": "i",
-                        "in": "query"
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "sample range (default=Day)",
-                        "name": "r",
-                        "in": "query"
+                        "description": "Updated settings incl. the IP of the highest worker or the nonce and its signature",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MinerSettingsReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.PoolPerformanceRes"
+                            "$ref": "#/definitions/api.MinerSettingsRes"
                         }
                     },
                     "400": {
@@ -781,20 +956,32 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.APIError"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/pools/{pool_id}/topminers": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/settings/history": {
             "get": {
-                "description": "Get the top miners from a specific pool",
+                "description": "Get the audit log of the settings changes from a specific miner from a specific pool.\nThe IP address and identity of the requester are only included for api keys with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Pools"
+                    "Miners"
                 ],
-                "summary": "Get the top miners from a pool",
+                "summary": "Get settings history",
                 "parameters": [
                     {
                         "type": "string",
@@ -803,10 +990,23 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
-                        "description": "Range in hours to fetch the top miners from (default=1)",
-                        "name": "range",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -814,7 +1014,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.TopMinersRes"
+                            "$ref": "#/definitions/api.SettingsHistoryRes"
                         }
                     },
                     "400": {
@@ -826,30 +1026,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/search": {
-            "get": {
-                "description": "Get stats for all pools",
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/settings/leaderboard": {
+            "put": {
+                "description": "Hide a specific miner from a specific pool from the top miners and the leaderboard, or show it again.\nThe ownership of the address is proven the same way as for settings updates. The payment settings aren't changed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Overall"
+                    "Miners"
                 ],
-                "summary": "Get overall stats",
+                "summary": "Opt out of leaderboards",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Address to search for",
-                        "name": "address",
-                        "in": "query",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Opt-out incl. the proof of ownership",
+                        "name": "optout",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.LeaderboardOptOutReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MinerSearchRes"
+                            "$ref": "#/definitions/api.LeaderboardOptOutRes"
                         }
                     },
                     "400": {
@@ -857,25 +1073,59 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.APIError"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/stats": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/settings/nonce": {
             "get": {
-                "description": "Get stats for all pools",
+                "description": "Get a nonce to prove the ownership of a miner address. The message has to be signed with the key of the address and sent along with the updated settings.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Overall"
+                    "Miners"
+                ],
+                "summary": "Get a nonce to update settings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get overall stats",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.StatsRes"
+                            "$ref": "#/definitions/api.MinerSettingsNonceRes"
                         }
                     },
                     "400": {
@@ -883,61 +1133,1539 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/utils.APIError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
                     }
                 }
             }
         },
-        "/teapot": {
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/webhooks": {
             "get": {
+                "description": "Get the webhooks of a specific miner from a specific pool.\nThe urls are masked for requests without an api key with the admin scope.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Teapot"
+                    "Webhooks"
+                ],
+                "summary": "Get webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Teapot",
                 "responses": {
-                    "418": {
-                        "description": "I'm a teapot",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.WebhooksRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "api.BalanceChange": {
-            "type": "object",
-            "properties": {
-                "address": {
-                    "type": "string"
+            },
+            "post": {
+                "description": "Create a webhook which receives the block and payment events of a specific miner from a specific pool.\nThe ownership of the address is proven the same way as for settings updates.\nThe secret of the signatures is only returned in this response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Create a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Url and events incl. the proof of ownership",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Delete a webhook of a specific miner from a specific pool incl. its deliveries.\nThe ownership of the address is proven the same way as for settings updates, it isn't required for api keys with the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Proof of ownership",
+                        "name": "proof",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.OwnershipProof"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Meta"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/workers": {
+            "get": {
+                "description": "Get all workers which were seen within a window from a specific miner from a specific pool.\nA worker is online if it submitted shares or had a hashrate recently.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Workers"
+                ],
+                "summary": "Get the workers of a miner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window in which the workers were seen, e.g. 1h or 168h (default=24h)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Assign the workers to rigs by the part of their name before this separator, e.g. .",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Assign the workers to rigs by the first group or the match of this regex",
+                        "name": "groupRegex",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkersRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}": {
+            "get": {
+                "description": "Get a specific worker from a specific miner from a specific pool",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workers"
+                ],
+                "summary": "Get a worker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the worker",
+                        "name": "worker_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}/labels": {
+            "put": {
+                "description": "Replace the labels of a specific worker from a specific miner from a specific pool.\nThe labels are returned with the miner and the workers. The ownership of the address is proven the same way as for settings updates.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workers"
+                ],
+                "summary": "Set the labels of a worker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the worker",
+                        "name": "worker_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Labels incl. the proof of ownership",
+                        "name": "labels",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerLabelsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerLabelsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/miners/{miner_addr}/workers/{worker_name}/performance": {
+            "get": {
+                "description": "Get the performance from a specific worker from a specific miner from a specific pool",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workers"
+                ],
+                "summary": "Get performance stats of a worker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address of the miner",
+                        "name": "miner_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the worker",
+                        "name": "worker_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Specify the sample range (default=day",
+                        "name": "perfMode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range in RFC3339, overwrites the perfMode",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range in RFC3339, overwrites the perfMode",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interval of the samples (10m, 1h, 1d, 1w), chosen by the length of the range by default",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerPerformanceRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/payments": {
+            "get": {
+                "description": "Get a list of payments from a specific pool",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Pools"
+                ],
+                "summary": "Get a list of payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PaymentsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/performance": {
+            "get": {
+                "description": "Get a list of performance samples from a specific pool",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pools"
+                ],
+                "summary": "Get a list of performance samples",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "sample interval (default=Hour)",
+                        "name": "i",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sample range (default=Day)",
+                        "name": "r",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range in RFC3339, overwrites the sample range",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range in RFC3339, overwrites the sample range",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Interval of the samples (10m, 1h, 1d, 1w), overwrites the sample interval",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PoolPerformanceRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pools/{pool_id}/topminers": {
+            "get": {
+                "description": "Get the top miners from a specific pool",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pools"
+                ],
+                "summary": "Get the top miners from a pool",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the pool",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Range in hours to fetch the top miners from (default=1)",
+                        "name": "range",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TopMinersRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/search": {
+            "get": {
+                "description": "Get stats for all pools",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Overall"
+                ],
+                "summary": "Get overall stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address to search for",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MinerSearchRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stats": {
+            "get": {
+                "description": "Get stats for all pools",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Overall"
+                ],
+                "summary": "Get overall stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "description": "Get the webhooks of all pools and miners, requires an api key with the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhooksRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a webhook which receives the events of a pool or of all pools, optionally filtered by the address of a miner.\nRequires an api key with the admin scope. The secret of the signatures is only returned in this response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Create a webhook for any pool and miner",
+                "parameters": [
+                    {
+                        "description": "Pool, address, url and events",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AdminWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/deadletters": {
+            "get": {
+                "description": "Get the deliveries of all webhooks which failed after all attempts, requires an api key with the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get the dead letters",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookDeliveriesRes"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/deliveries/{delivery_id}/retry": {
+            "post": {
+                "description": "Queue a delivery which failed after all attempts again, requires an api key with the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Retry a dead letter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the delivery",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Meta"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Delete a webhook incl. its deliveries, requires an api key with the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete any webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Meta"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{webhook_id}/deliveries": {
+            "get": {
+                "description": "Get the delivery log of a webhook, requires an api key with the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get the deliveries of a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the webhook",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status of the deliveries (pending, delivered, failed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page (default=0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "PageSize (default=15)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WebhookDeliveriesRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get the health of the api and its miningcore websocket upstreams",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/teapot": {
+            "get": {
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Teapot"
+                ],
+                "summary": "Teapot",
+                "responses": {
+                    "418": {
+                        "description": "I'm a teapot",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.AdminWebhookReq": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "empty for all miners",
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "poolId": {
+                    "description": "empty for all pools",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.AlertChannels": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "maxSubscriptions": {
+                    "type": "integer"
+                },
+                "minThreshold": {
+                    "description": "seconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.AlertChannelsRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/api.AlertChannels"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.AlertSubscription": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "seconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.AlertSubscriptionReq": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "ipAddress": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "seconds, defaults to the min threshold",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.AlertSubscriptionRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/api.AlertSubscription"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.AlertSubscriptionsRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.AlertSubscription"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.BalanceChange": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "poolId": {
+                    "type": "string"
+                },
+                "usage": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.BalanceChangesRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BalanceChange"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.Block": {
+            "type": "object",
+            "properties": {
+                "blockHeight": {
+                    "type": "integer"
+                },
+                "confirmationProgress": {
+                    "type": "number"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "effort": {
+                    "type": "number"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "infoLink": {
+                    "type": "string"
+                },
+                "miner": {
+                    "type": "string"
+                },
+                "networkDifficulty": {
+                    "type": "number"
+                },
+                "poolId": {
+                    "type": "string"
+                },
+                "reward": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "transactionConfirmationData": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.BlocksRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Block"
+                    }
                 },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.DailyEarning": {
+            "type": "object",
+            "properties": {
                 "amount": {
                     "type": "number"
                 },
-                "created": {
+                "date": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.DailyEarningRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DailyEarning"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.HealthRes": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                },
+                "upstreams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.UpstreamHealth"
+                    }
+                }
+            }
+        },
+        "api.LeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "blocks": {
+                    "type": "integer"
+                },
+                "hashrate": {
+                    "description": "average hashrate within the window",
+                    "type": "number"
+                },
+                "miner": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "totalPaid": {
+                    "type": "number"
+                },
+                "uptime": {
+                    "description": "share of the window with a hashrate, 0-1",
+                    "type": "number"
+                },
+                "workers": {
+                    "description": "max workers online at the same time",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.LeaderboardOptOutReq": {
+            "type": "object",
+            "properties": {
+                "hideFromLeaderboards": {
+                    "description": "hide the miner from the top miners and the leaderboard",
+                    "type": "boolean"
+                },
+                "ipAddress": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.LeaderboardOptOutRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "boolean"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.LeaderboardRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.LeaderboardEntry"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.Meta": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.Miner": {
+            "type": "object",
+            "properties": {
+                "coin": {
+                    "type": "string"
+                },
+                "lastPayment": {
+                    "type": "string"
+                },
+                "lastPaymentLink": {
+                    "type": "string"
+                },
+                "pendingBalance": {
+                    "type": "number"
+                },
+                "pendingShares": {
+                    "type": "number"
+                },
+                "performance": {
+                    "$ref": "#/definitions/api.WorkerPerformanceStatsContainer"
+                },
+                "prices": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.Price"
+                    }
+                },
+                "rigs": {
+                    "description": "only set if the workers are grouped",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Rig"
+                    }
+                },
+                "shares": {
+                    "description": "only set if the shares are counted",
+                    "$ref": "#/definitions/api.ShareCounts"
+                },
+                "todayPaid": {
+                    "type": "number"
+                },
+                "totalPaid": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.MinerPerformanceRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.PerformanceStats"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MinerRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/api.Miner"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MinerSearch": {
+            "type": "object",
+            "properties": {
+                "address": {
                     "type": "string"
                 },
-                "poolId": {
+                "feeType": {
                     "type": "string"
                 },
-                "usage": {
+                "poolId": {
                     "type": "string"
                 }
             }
         },
-        "api.BalanceChangesRes": {
+        "api.MinerSearchRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.BalanceChange"
+                        "$ref": "#/definitions/api.MinerSearch"
                     }
                 },
                 "success": {
@@ -945,60 +2673,110 @@ const docTemplate = `{
                 }
             }
         },
-        "api.Block": {
+        "api.MinerSettings": {
             "type": "object",
             "properties": {
-                "blockHeight": {
-                    "type": "integer"
+                "hideFromLeaderboards": {
+                    "description": "read-only, changed with /settings/leaderboard",
+                    "type": "boolean"
                 },
-                "confirmationProgress": {
+                "paymentThreshold": {
                     "type": "number"
-                },
-                "created": {
+                }
+            }
+        },
+        "api.MinerSettingsNonce": {
+            "type": "object",
+            "properties": {
+                "expires": {
                     "type": "string"
                 },
-                "effort": {
-                    "type": "number"
+                "message": {
+                    "type": "string"
                 },
-                "hash": {
+                "nonce": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MinerSettingsNonceRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "infoLink": {
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/api.MinerSettingsNonce"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MinerSettingsReq": {
+            "type": "object",
+            "properties": {
+                "ipAddress": {
                     "type": "string"
                 },
-                "miner": {
+                "nonce": {
                     "type": "string"
                 },
-                "networkDifficulty": {
-                    "type": "number"
+                "settings": {
+                    "$ref": "#/definitions/api.MinerSettings"
                 },
-                "poolId": {
+                "signature": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MinerSettingsRes": {
+            "type": "object",
+            "properties": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "reward": {
-                    "type": "number"
+                "pageCount": {
+                    "type": "integer"
                 },
-                "source": {
-                    "type": "string"
+                "result": {
+                    "$ref": "#/definitions/api.MinerSettings"
                 },
-                "status": {
-                    "type": "string"
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MinerSimple": {
+            "type": "object",
+            "properties": {
+                "hashrate": {
+                    "type": "number"
                 },
-                "transactionConfirmationData": {
+                "miner": {
                     "type": "string"
+                },
+                "sharesPerSecond": {
+                    "type": "number"
                 }
             }
         },
-        "api.BlocksRes": {
+        "api.MinersRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.Block"
+                        "$ref": "#/definitions/api.MinerSimple"
                     }
                 },
                 "success": {
@@ -1006,27 +2784,59 @@ const docTemplate = `{
                 }
             }
         },
-        "api.DailyEarning": {
+        "api.OwnershipProof": {
+            "type": "object",
+            "properties": {
+                "ipAddress": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Payment": {
             "type": "object",
             "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "addressInfoLink": {
+                    "type": "string"
+                },
                 "amount": {
                     "type": "number"
                 },
-                "date": {
+                "coin": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "transactionConfirmationData": {
+                    "type": "string"
+                },
+                "transactionInfoLink": {
                     "type": "string"
                 }
             }
         },
-        "api.DailyEarningRes": {
+        "api.PaymentsRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.DailyEarning"
+                        "$ref": "#/definitions/api.Payment"
                     }
                 },
                 "success": {
@@ -1034,26 +2844,152 @@ const docTemplate = `{
                 }
             }
         },
-        "api.Miner": {
+        "api.PerformanceStats": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "hashrate": {
+                    "type": "number"
+                },
+                "reportedHashrate": {
+                    "type": "number"
+                },
+                "sharesPerSecond": {
+                    "type": "number"
+                },
+                "workersOnline": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Pool": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "blockHeight": {
+                    "type": "integer"
+                },
+                "coin": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fee": {
+                    "type": "number"
+                },
+                "feeType": {
+                    "type": "string"
+                },
+                "hashrate": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "miners": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "networkDifficulty": {
+                    "type": "number"
+                },
+                "networkHashrate": {
+                    "type": "number"
+                },
+                "prices": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.Price"
+                    }
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.PoolEndpoint": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "type": "number"
+                },
+                "tls": {
+                    "type": "boolean"
+                },
+                "tlsAuto": {
+                    "type": "boolean"
+                },
+                "varDiff": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.PoolExtended": {
             "type": "object",
             "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "algorithm": {
+                    "type": "string"
+                },
+                "averageEffort": {
+                    "type": "number"
+                },
+                "blockHeight": {
+                    "type": "integer"
+                },
                 "coin": {
                     "type": "string"
                 },
-                "lastPayment": {
+                "effort": {
+                    "type": "number"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fee": {
+                    "type": "number"
+                },
+                "feeType": {
+                    "type": "string"
+                },
+                "hashrate": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastBlockFoundTime": {
                     "type": "string"
                 },
-                "lastPaymentLink": {
+                "minPayout": {
+                    "type": "number"
+                },
+                "miners": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
-                "pendingBalance": {
+                "networkDifficulty": {
                     "type": "number"
                 },
-                "pendingShares": {
+                "networkHashrate": {
                     "type": "number"
                 },
-                "performance": {
-                    "$ref": "#/definitions/api.WorkerPerformanceStatsContainer"
+                "ports": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.PoolEndpoint"
+                    }
                 },
                 "prices": {
                     "type": "object",
@@ -1061,69 +2997,70 @@ const docTemplate = `{
                         "$ref": "#/definitions/api.Price"
                     }
                 },
-                "todayPaid": {
-                    "type": "number"
+                "totalBlocksFound": {
+                    "type": "integer"
                 },
-                "totalPaid": {
+                "totalPayments": {
                     "type": "number"
-                }
-            }
-        },
-        "api.MinerPerformanceRes": {
-            "type": "object",
-            "properties": {
-                "pageCount": {
-                    "type": "integer"
                 },
-                "result": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.PerformanceStats"
-                    }
+                "type": {
+                    "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "workers": {
+                    "type": "integer"
                 }
             }
         },
-        "api.MinerRes": {
+        "api.PoolExtendedRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
-                    "$ref": "#/definitions/api.Miner"
+                    "$ref": "#/definitions/api.PoolExtended"
                 },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
-        "api.MinerSearch": {
+        "api.PoolPerformance": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
+                "connectedMiners": {
+                    "type": "integer"
                 },
-                "feeType": {
+                "created": {
                     "type": "string"
                 },
-                "poolId": {
-                    "type": "string"
+                "networkDifficulty": {
+                    "type": "number"
+                },
+                "networkHashrate": {
+                    "type": "number"
+                },
+                "poolHashrate": {
+                    "type": "number"
                 }
             }
         },
-        "api.MinerSearchRes": {
+        "api.PoolPerformanceRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.MinerSearch"
+                        "$ref": "#/definitions/api.PoolPerformance"
                     }
                 },
                 "success": {
@@ -1131,106 +3068,96 @@ const docTemplate = `{
                 }
             }
         },
-        "api.MinerSettings": {
-            "type": "object",
-            "properties": {
-                "paymentThreshold": {
-                    "type": "number"
-                }
-            }
-        },
-        "api.MinerSettingsReq": {
+        "api.PoolsRes": {
             "type": "object",
             "properties": {
-                "ipAddress": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "settings": {
-                    "$ref": "#/definitions/api.MinerSettings"
-                }
-            }
-        },
-        "api.MinerSettingsRes": {
-            "type": "object",
-            "properties": {
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
-                    "$ref": "#/definitions/api.MinerSettings"
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Pool"
+                    }
                 },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
-        "api.MinerSimple": {
+        "api.Price": {
             "type": "object",
             "properties": {
-                "hashrate": {
+                "price": {
                     "type": "number"
                 },
-                "miner": {
-                    "type": "string"
-                },
-                "sharesPerSecond": {
+                "priceChangePercentage24H": {
                     "type": "number"
                 }
             }
         },
-        "api.MinersRes": {
+        "api.Rig": {
             "type": "object",
             "properties": {
-                "pageCount": {
-                    "type": "integer"
+                "hashrate": {
+                    "type": "number"
                 },
-                "result": {
+                "name": {
+                    "type": "string"
+                },
+                "reportedHashrate": {
+                    "type": "number"
+                },
+                "sharesPerSecond": {
+                    "type": "number"
+                },
+                "workers": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.MinerSimple"
+                        "type": "string"
                     }
-                },
-                "success": {
-                    "type": "boolean"
                 }
             }
         },
-        "api.Payment": {
+        "api.SettingsChange": {
             "type": "object",
             "properties": {
-                "address": {
+                "created": {
                     "type": "string"
                 },
-                "addressInfoLink": {
+                "ipAddress": {
                     "type": "string"
                 },
-                "amount": {
+                "newPaymentThreshold": {
                     "type": "number"
                 },
-                "coin": {
-                    "type": "string"
-                },
-                "created": {
-                    "type": "string"
+                "oldPaymentThreshold": {
+                    "type": "number"
                 },
-                "transactionConfirmationData": {
+                "proof": {
                     "type": "string"
                 },
-                "transactionInfoLink": {
+                "requestedBy": {
                     "type": "string"
                 }
             }
         },
-        "api.PaymentsRes": {
+        "api.SettingsHistoryRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.Payment"
+                        "$ref": "#/definitions/api.SettingsChange"
                     }
                 },
                 "success": {
@@ -1238,228 +3165,275 @@ const docTemplate = `{
                 }
             }
         },
-        "api.PerformanceStats": {
+        "api.ShareCounts": {
             "type": "object",
             "properties": {
-                "created": {
-                    "type": "string"
+                "accepted": {
+                    "type": "integer"
                 },
-                "hashrate": {
-                    "type": "number"
+                "rejected": {
+                    "type": "integer"
                 },
-                "reportedHashrate": {
+                "stale": {
+                    "type": "integer"
+                },
+                "staleRatio": {
+                    "description": "ratio of the stale to all shares",
                     "type": "number"
+                }
+            }
+        },
+        "api.Stats": {
+            "type": "object",
+            "properties": {
+                "paymentsToday": {
+                    "type": "integer"
                 },
-                "sharesPerSecond": {
+                "totalMiners": {
+                    "type": "integer"
+                },
+                "totalSharesPerSecond": {
                     "type": "number"
                 },
-                "workersOnline": {
+                "totalWorkers": {
                     "type": "integer"
                 }
             }
         },
-        "api.Pool": {
+        "api.StatsRes": {
             "type": "object",
             "properties": {
-                "algorithm": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "blockHeight": {
+                "pageCount": {
                     "type": "integer"
                 },
-                "coin": {
-                    "type": "string"
-                },
-                "fee": {
-                    "type": "number"
-                },
-                "feeType": {
-                    "type": "string"
+                "result": {
+                    "$ref": "#/definitions/api.Stats"
                 },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.TopMiner": {
+            "type": "object",
+            "properties": {
                 "hashrate": {
                     "type": "number"
                 },
-                "id": {
+                "joined": {
                     "type": "string"
                 },
-                "miners": {
-                    "type": "integer"
-                },
-                "name": {
+                "miner": {
                     "type": "string"
                 },
-                "networkDifficulty": {
-                    "type": "number"
-                },
-                "networkHashrate": {
+                "totalPaid": {
                     "type": "number"
                 },
-                "prices": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/api.Price"
-                    }
-                },
                 "workers": {
                     "type": "integer"
                 }
             }
         },
-        "api.PoolEndpoint": {
+        "api.TopMinersRes": {
             "type": "object",
             "properties": {
-                "difficulty": {
-                    "type": "number"
+                "generatedAt": {
+                    "type": "string"
                 },
-                "tls": {
-                    "type": "boolean"
+                "pageCount": {
+                    "type": "integer"
                 },
-                "tlsAuto": {
-                    "type": "boolean"
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.TopMiner"
+                    }
                 },
-                "varDiff": {
+                "success": {
                     "type": "boolean"
                 }
             }
         },
-        "api.PoolExtended": {
+        "api.UpstreamHealth": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
-                },
-                "algorithm": {
-                    "type": "string"
-                },
-                "averageEffort": {
-                    "type": "number"
-                },
-                "blockHeight": {
-                    "type": "integer"
-                },
-                "coin": {
-                    "type": "string"
-                },
-                "effort": {
-                    "type": "number"
-                },
-                "fee": {
-                    "type": "number"
+                "connected": {
+                    "type": "boolean"
                 },
-                "feeType": {
-                    "type": "string"
+                "duplicates": {
+                    "type": "integer"
                 },
-                "hashrate": {
-                    "type": "number"
+                "lastError": {
+                    "type": "string"
                 },
-                "id": {
+                "lastMessage": {
                     "type": "string"
                 },
-                "lastBlockFoundTime": {
+                "name": {
                     "type": "string"
                 },
-                "minPayout": {
-                    "type": "number"
+                "received": {
+                    "type": "integer"
                 },
-                "miners": {
+                "reconnects": {
                     "type": "integer"
                 },
-                "name": {
+                "since": {
                     "type": "string"
                 },
-                "networkDifficulty": {
-                    "type": "number"
-                },
-                "networkHashrate": {
-                    "type": "number"
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Webhook": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
                 },
-                "ports": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/api.PoolEndpoint"
-                    }
+                "created": {
+                    "type": "string"
                 },
-                "prices": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/api.Price"
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
                 },
-                "totalBlocksFound": {
+                "id": {
                     "type": "integer"
                 },
-                "totalPayments": {
-                    "type": "number"
+                "poolId": {
+                    "type": "string"
                 },
-                "type": {
+                "secret": {
+                    "description": "only returned when the webhook is created",
                     "type": "string"
                 },
-                "workers": {
-                    "type": "integer"
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "api.PoolExtendedRes": {
+        "api.WebhookDeliveriesRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
-                    "$ref": "#/definitions/api.PoolExtended"
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.WebhookDelivery"
+                    }
                 },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
-        "api.PoolPerformance": {
+        "api.WebhookDelivery": {
             "type": "object",
             "properties": {
-                "connectedMiners": {
+                "attempts": {
                     "type": "integer"
                 },
                 "created": {
                     "type": "string"
                 },
-                "networkDifficulty": {
-                    "type": "number"
+                "eventId": {
+                    "type": "string"
                 },
-                "networkHashrate": {
-                    "type": "number"
+                "eventType": {
+                    "type": "string"
                 },
-                "poolHashrate": {
-                    "type": "number"
+                "id": {
+                    "type": "integer"
+                },
+                "lastAttempt": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttempt": {
+                    "description": "only set for pending deliveries",
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "responseStatus": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "webhookId": {
+                    "type": "integer"
                 }
             }
         },
-        "api.PoolPerformanceRes": {
+        "api.WebhookReq": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ipAddress": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.WebhookRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.PoolPerformance"
-                    }
+                    "$ref": "#/definitions/api.Webhook"
                 },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
-        "api.PoolsRes": {
+        "api.WebhooksRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.Pool"
+                        "$ref": "#/definitions/api.Webhook"
                     }
                 },
                 "success": {
@@ -1467,78 +3441,115 @@ const docTemplate = `{
                 }
             }
         },
-        "api.Price": {
+        "api.Worker": {
             "type": "object",
             "properties": {
-                "price": {
+                "hashrate": {
                     "type": "number"
                 },
-                "priceChangePercentage24H": {
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "shares": {
+                    "description": "only set if the shares are counted",
+                    "$ref": "#/definitions/api.ShareCounts"
+                },
+                "sharesPerSecond": {
                     "type": "number"
                 }
             }
         },
-        "api.Stats": {
+        "api.WorkerHealth": {
             "type": "object",
             "properties": {
-                "paymentsToday": {
+                "deviatingSamples": {
+                    "description": "samples which deviate by more than the threshold",
                     "type": "integer"
                 },
-                "totalMiners": {
-                    "type": "integer"
+                "deviation": {
+                    "description": "relative deviation of the effective from the reported hashrate",
+                    "type": "number"
                 },
-                "totalSharesPerSecond": {
+                "flagged": {
+                    "type": "boolean"
+                },
+                "hashrate": {
+                    "description": "average effective hashrate",
                     "type": "number"
                 },
-                "totalWorkers": {
+                "name": {
+                    "type": "string"
+                },
+                "reportedHashrate": {
+                    "description": "average reported hashrate",
+                    "type": "number"
+                },
+                "samples": {
+                    "description": "samples with an effective and a reported hashrate",
                     "type": "integer"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
-        "api.StatsRes": {
+        "api.WorkerLabelsReq": {
             "type": "object",
             "properties": {
-                "pageCount": {
-                    "type": "integer"
+                "ipAddress": {
+                    "type": "string"
                 },
-                "result": {
-                    "$ref": "#/definitions/api.Stats"
+                "labels": {
+                    "description": "replaces all labels of the worker, empty to remove them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "success": {
-                    "type": "boolean"
+                "nonce": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
                 }
             }
         },
-        "api.TopMiner": {
+        "api.WorkerLabelsRes": {
             "type": "object",
             "properties": {
-                "hashrate": {
-                    "type": "number"
-                },
-                "joined": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "miner": {
-                    "type": "string"
+                "pageCount": {
+                    "type": "integer"
                 },
-                "totalPaid": {
-                    "type": "number"
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "workers": {
-                    "type": "integer"
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "api.TopMinersRes": {
+        "api.WorkerPerformanceRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/api.TopMiner"
+                        "$ref": "#/definitions/api.PerformanceStats"
                     }
                 },
                 "success": {
@@ -1546,70 +3557,134 @@ const docTemplate = `{
                 }
             }
         },
-        "api.Worker": {
+        "api.WorkerPerformanceStats": {
             "type": "object",
             "properties": {
                 "hashrate": {
                     "type": "number"
                 },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reportedHashrate": {
+                    "type": "number"
+                },
+                "shares": {
+                    "description": "only set if the shares are counted",
+                    "$ref": "#/definitions/api.ShareCounts"
+                },
                 "sharesPerSecond": {
                     "type": "number"
                 }
             }
         },
-        "api.WorkerPerformanceRes": {
+        "api.WorkerPerformanceStatsContainer": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "workers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.WorkerPerformanceStats"
+                    }
+                }
+            }
+        },
+        "api.WorkerRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.PerformanceStats"
-                    }
+                    "$ref": "#/definitions/api.Worker"
                 },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
-        "api.WorkerPerformanceStats": {
+        "api.WorkerStatus": {
             "type": "object",
             "properties": {
+                "averageHashrate": {
+                    "type": "number"
+                },
                 "hashrate": {
                     "type": "number"
                 },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lastSeen": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "type": "boolean"
+                },
                 "reportedHashrate": {
                     "type": "number"
                 },
+                "rig": {
+                    "description": "only set if the workers are grouped",
+                    "type": "string"
+                },
                 "sharesPerSecond": {
                     "type": "number"
+                },
+                "userAgent": {
+                    "type": "string"
                 }
             }
         },
-        "api.WorkerPerformanceStatsContainer": {
+        "api.WorkersHealthRes": {
             "type": "object",
             "properties": {
-                "created": {
+                "generatedAt": {
                     "type": "string"
                 },
-                "workers": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/api.WorkerPerformanceStats"
+                "pageCount": {
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.WorkerHealth"
                     }
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "api.WorkerRes": {
+        "api.WorkersRes": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "type": "string"
+                },
                 "pageCount": {
                     "type": "integer"
                 },
                 "result": {
-                    "$ref": "#/definitions/api.Worker"
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.WorkerStatus"
+                    }
                 },
                 "success": {
                     "type": "boolean"
@@ -1627,6 +3702,13 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
     }
 }`
 
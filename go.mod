@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/ansrivas/fiberprometheus v0.3.2
 	github.com/caarlos0/duration v0.0.0-20220103233809-8df7c22fe305
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
 	github.com/esenmx/gocko v0.0.0-20220329072259-1b4c5af85101
 	github.com/gocarina/gocsv v0.0.0-20220927221512-ad3251f9fa25
 	github.com/goccy/go-json v0.9.11
@@ -17,12 +18,15 @@ require (
 	github.com/jmoiron/sqlx v1.3.5
 	github.com/jpillora/backoff v1.0.0
 	github.com/prometheus/client_golang v1.13.0
+	github.com/redis/go-redis/v9 v9.0.2
 	github.com/shopspring/decimal v1.3.1
 	github.com/spf13/cobra v1.5.0
 	github.com/spf13/viper v1.13.0
 	github.com/stratumfarm/go-miningcore-client v0.3.4
 	github.com/stretchr/testify v1.8.1
 	github.com/swaggo/swag v1.8.5
+	github.com/valyala/fasthttp v1.39.0
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa
 )
 
 replace github.com/ansrivas/fiberprometheus => ./submodules/fiberprometheus
@@ -33,8 +37,9 @@ require (
 	github.com/PuerkitoBio/urlesc v0.0.0-20170810143723-de5bf2ad4578 // indirect
 	github.com/andybalholm/brotli v1.0.4 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
-	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/fasthttp/websocket v1.5.0 // indirect
 	github.com/fsnotify/fsnotify v1.5.4 // indirect
 	github.com/go-openapi/jsonpointer v0.19.5 // indirect
@@ -71,9 +76,7 @@ require (
 	github.com/subosito/gotenv v1.4.1 // indirect
 	github.com/swaggo/files v0.0.0-20210815190702-a29dd2bc99b2 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	github.com/valyala/fasthttp v1.39.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
-	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa // indirect
 	golang.org/x/net v0.0.0-20220520000938-2e3eb7b945c2 // indirect
 	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
 	golang.org/x/text v0.3.7 // indirect
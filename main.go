@@ -15,6 +15,9 @@ import (
 // @contact.email pool@1oop.io
 // @host 152.228.229.130:3000
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 
 func main() {
 	if err := cmd.Execute(); err != nil {
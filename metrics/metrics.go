@@ -87,3 +87,13 @@ func (s *Server) Close() error {
 func (s *Server) Fiber() fiber.Handler {
 	return s.fiber
 }
+
+// Register registers additional collectors on the metrics registry.
+func (s *Server) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := s.registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
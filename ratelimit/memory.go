@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memoryGCInterval = time.Minute
+
+type window struct {
+	idx      int64
+	hits     int
+	previous int
+	end      time.Time
+	length   time.Duration
+}
+
+// Memory is an in-memory store.
+// The counters are not shared between multiple instances.
+type Memory struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewMemory creates a new memory store.
+func NewMemory() *Memory {
+	m := &Memory{
+		windows: make(map[string]*window),
+		done:    make(chan struct{}),
+	}
+	go m.gc()
+	return m
+}
+
+// Hit increments the hits of the key in its current window.
+func (m *Memory) Hit(_ context.Context, key string, w time.Duration) (Hits, error) {
+	now := time.Now()
+	idx, reset := windowIndex(now, w)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	win, ok := m.windows[key]
+	switch {
+	case !ok || win.length != w || win.idx < idx-1:
+		win = &window{idx: idx}
+		m.windows[key] = win
+	case win.idx == idx-1:
+		win.previous, win.hits, win.idx = win.hits, 0, idx
+	}
+	win.hits++
+	win.end = now.Add(reset)
+	win.length = w
+	return Hits{Current: win.hits, Previous: win.previous, Reset: reset}, nil
+}
+
+// Close stops the garbage collection.
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.done) })
+	return nil
+}
+
+func (m *Memory) gc() {
+	ticker := time.NewTicker(memoryGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for key, win := range m.windows {
+				// keep the window while it is the previous one
+				if !now.Before(win.end.Add(win.length)) {
+					delete(m.windows, key)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		hits, err := m.Hit(ctx, "a", time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, i, hits.Current)
+		assert.LessOrEqual(t, hits.Reset, time.Hour)
+		assert.Greater(t, hits.Reset, time.Duration(0))
+	}
+
+	// keys are counted separately
+	hits, err := m.Hit(ctx, "b", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hits.Current)
+
+	// the hits of the previous window are kept for one window
+	w := time.Millisecond * 50
+	hits, err = m.Hit(ctx, "c", w)
+	assert.NoError(t, err)
+	time.Sleep(hits.Reset + time.Millisecond)
+	hits, err = m.Hit(ctx, "c", w)
+	assert.NoError(t, err)
+	assert.Equal(t, Hits{Current: 1, Previous: 1, Reset: hits.Reset}, hits)
+
+	time.Sleep(w * 3)
+	hits, err = m.Hit(ctx, "c", w)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hits.Current)
+	assert.Equal(t, 0, hits.Previous)
+}
+
+func TestHitsCount(t *testing.T) {
+	hits := Hits{Current: 4, Previous: 10, Reset: time.Second * 3}
+	assert.Equal(t, 7, hits.Count(StrategySliding, time.Second*10))
+	assert.Equal(t, 7, hits.Count("", time.Second*10))
+	assert.Equal(t, 4, hits.Count(StrategyFixed, time.Second*10))
+}
+
+func TestWindowIndex(t *testing.T) {
+	ts := time.Unix(125, 0)
+	idx, reset := windowIndex(ts, time.Second*10)
+	assert.Equal(t, int64(12), idx)
+	assert.Equal(t, time.Second*5, reset)
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &Memory{}, s)
+	s.Close()
+
+	_, err = New(&config.RateLimit{Strategy: "leaky"})
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1oopio/phantomias/config"
+)
+
+const (
+	// BackendMemory keeps the counters in the memory of the process.
+	BackendMemory = "memory"
+	// BackendRedis keeps the counters in a redis server, which can be shared by multiple instances.
+	BackendRedis = "redis"
+)
+
+const (
+	// StrategySliding weights the hits of the previous window by its overlap with a window ending now.
+	StrategySliding = "sliding"
+	// StrategyFixed counts only the hits of the current window.
+	StrategyFixed = "fixed"
+)
+
+var (
+	// ErrUnknownBackend is returned when the configured rate limit backend is not supported
+	ErrUnknownBackend = errors.New("unknown rate limit backend")
+	// ErrUnknownStrategy is returned when the configured rate limit strategy is not supported
+	ErrUnknownStrategy = errors.New("unknown rate limit strategy")
+)
+
+// Hits are the hits of a key in the current and the previous window.
+type Hits struct {
+	Current  int           // hits in the current window including this one
+	Previous int           // hits in the previous window
+	Reset    time.Duration // time until the current window ends
+}
+
+// Count returns the hits which count against the limit with the given strategy.
+func (h Hits) Count(strategy string, window time.Duration) int {
+	if strategy == StrategyFixed || window <= 0 {
+		return h.Current
+	}
+	weight := float64(h.Reset) / float64(window)
+	return h.Current + int(float64(h.Previous)*weight)
+}
+
+// windowIndex returns the index of the window which contains t and the time until it ends.
+// Windows are aligned to the unix epoch, so all instances agree on them.
+func windowIndex(t time.Time, window time.Duration) (int64, time.Duration) {
+	idx := t.UnixNano() / int64(window)
+	return idx, time.Duration((idx+1)*int64(window) - t.UnixNano())
+}
+
+// Store counts the hits of a key in consecutive windows.
+type Store interface {
+	// Hit increments the hits of the key in its current window.
+	Hit(ctx context.Context, key string, window time.Duration) (Hits, error)
+
+	// Close closes the store.
+	Close() error
+}
+
+// New creates the store for the configured backend.
+// A nil config returns a memory store.
+func New(cfg *config.RateLimit) (Store, error) {
+	if cfg == nil {
+		return NewMemory(), nil
+	}
+	switch cfg.Strategy {
+	case "", StrategySliding, StrategyFixed:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, cfg.Strategy)
+	}
+	switch cfg.Backend {
+	case "", BackendMemory:
+		return NewMemory(), nil
+	case BackendRedis:
+		return NewRedis(cfg.RedisURL, cfg.Prefix)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
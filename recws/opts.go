@@ -51,6 +51,18 @@ func WithOnConnectCallback(cb func()) Opts {
 	}
 }
 
+func WithOnStateChange(fn func(StateEvent)) Opts {
+	return func(o *ConnOps) {
+		o.OnStateChange = fn
+	}
+}
+
+func WithSubscribeFn(fn func(Writer) error) Opts {
+	return func(o *ConnOps) {
+		o.SubscribeFn = fn
+	}
+}
+
 func WithKeepAliveTimeout(kat time.Duration) Opts {
 	return func(o *ConnOps) {
 		o.KeepAliveTimeout = kat
@@ -98,6 +110,14 @@ type ConnOps struct {
 	TLSClientConfig *tls.Config
 	// OnConnectCallback fires after the connection successfully establish.
 	OnConnectCallback func()
+	// OnStateChange fires whenever the state of the connection changes.
+	// It must not block.
+	OnStateChange func(StateEvent)
+	// SubscribeFn is called after every successful dial, before the connection
+	// is marked as connected. Use it to replay subscription or auth messages
+	// after a reconnect. If it returns an error, the connection is closed and
+	// the dial counts as failed.
+	SubscribeFn func(Writer) error
 	// KeepAliveTimeout is an interval for sending ping/pong messages
 	// disabled if 0.
 	KeepAliveTimeout time.Duration
@@ -105,6 +125,12 @@ type ConnOps struct {
 	LogFn logFnOptions
 }
 
+// Writer writes messages to the underlying websocket connection.
+type Writer interface {
+	WriteMessage(messageType int, data []byte) error
+	WriteJSON(v interface{}) error
+}
+
 type logFnOptions struct {
 	Debug func(string)
 	Error func(error, string)
@@ -16,10 +16,10 @@ import (
 )
 
 const (
-	closedState = 1 << iota
-	connectingState
-	connectedState
-	closedForeverState
+	closedState        = StateClosed
+	connectingState    = StateConnecting
+	connectedState     = StateConnected
+	closedForeverState = StateClosedForever
 )
 
 var (
@@ -85,12 +85,27 @@ type RecConn interface {
 
 	// IsConnected returns true if the websocket client is connected to the server.
 	IsConnected() bool
+
+	// State returns the current state of the connection.
+	State() State
+
+	// OnMessage registers a handler which is called for every message
+	// read by Listen. Handlers are called sequentially from the read loop.
+	OnMessage(fn MessageHandler)
+
+	// Listen reads messages and passes them to the handlers registered with OnMessage.
+	// It waits for reconnects and blocks until the context is done or
+	// the connection is closed forever.
+	Listen(ctx context.Context) error
 }
 
+// MessageHandler handles a message read from the connection.
+type MessageHandler func(messageType int, data []byte)
+
 // The recConn type represents a Reconnecting WebSocket connection.
 type recConn struct {
 	opts            ConnOps
-	state           int
+	state           State
 	mu              sync.RWMutex
 	url             string
 	reqHeader       http.Header
@@ -98,6 +113,12 @@ type recConn struct {
 	dialer          *websocket.Dialer
 	keepaliveCtx    context.Context
 	keepaliveCancel context.CancelFunc
+	connected       chan struct{}
+	closedForever   chan struct{}
+	closeOnce       sync.Once
+
+	handlersMu sync.RWMutex
+	handlers   []MessageHandler
 
 	*websocket.Conn
 }
@@ -123,6 +144,7 @@ func New(url string, requestHeader http.Header, opts ...Opts) (RecConn, error) {
 	var (
 		defaultProxy                            = http.ProxyFromEnvironment
 		defaultOnConnectionCallback             = func() {}
+		defaultOnStateChange                    = func(StateEvent) {}
 		defaultTLSClientConfig      *tls.Config = nil
 		defaultLogFnDebug                       = func(s string) {}
 		defaultLogFnError                       = func(err error, s string) {}
@@ -137,15 +159,18 @@ func New(url string, requestHeader http.Header, opts ...Opts) (RecConn, error) {
 			Proxy:                   defaultProxy,
 			TLSClientConfig:         defaultTLSClientConfig,
 			OnConnectCallback:       defaultOnConnectionCallback,
+			OnStateChange:           defaultOnStateChange,
 			KeepAliveTimeout:        defaultKeepAliveTimeout,
 			LogFn: logFnOptions{
 				Debug: defaultLogFnDebug,
 				Error: defaultLogFnError,
 			},
 		},
-		url:       url,
-		reqHeader: requestHeader,
-		state:     closedState,
+		url:           url,
+		reqHeader:     requestHeader,
+		state:         closedState,
+		connected:     make(chan struct{}, 1),
+		closedForever: make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -170,33 +195,69 @@ func (rc *recConn) CloseAndReconnect() {
 	}()
 }
 
+// closeAndReconnect reconnects only if conn is still the current connection.
+// Errors of connections which were already replaced are ignored,
+// otherwise a stale reader would close a freshly established connection.
+func (rc *recConn) closeAndReconnect(conn *websocket.Conn) {
+	if !rc.close(conn, false) {
+		return
+	}
+	go func() {
+		if err := rc.reconnect(); err != nil {
+			rc.opts.LogFn.Error(err, "connection error")
+		}
+	}()
+}
+
 func (rc *recConn) Close(forever bool) {
+	rc.close(nil, forever)
+}
+
+// close closes the connection. If conn is not nil, the connection is only
+// closed if conn is the current and established one. It returns false if nothing was closed.
+func (rc *recConn) close(conn *websocket.Conn, forever bool) bool {
 	rc.mu.Lock()
-	defer rc.mu.Unlock()
+
+	if conn != nil && (conn != rc.Conn || rc.state != connectedState) {
+		rc.mu.Unlock()
+		return false
+	}
 
 	if rc.keepaliveCancel != nil {
 		rc.keepaliveCancel()
 	}
 
-	if rc.state&(closedState|closedForeverState) > 0 {
-		return
+	if rc.state&closedForeverState > 0 {
+		rc.mu.Unlock()
+		return false
+	}
+	if rc.state&closedState > 0 && !forever {
+		rc.mu.Unlock()
+		return false
 	}
-	if err := rc.Conn.Close(); err != nil {
-		rc.opts.LogFn.Error(err, "websocket connection closing error")
+	if rc.state&closedState == 0 && rc.Conn != nil {
+		if err := rc.Conn.Close(); err != nil {
+			rc.opts.LogFn.Error(err, "websocket connection closing error")
+		}
 	}
+	var ev *StateEvent
 	if forever {
-		rc.state = closedForeverState
+		ev = rc.transition(closedForeverState, nil)
 	} else {
-		rc.state = closedState
+		ev = rc.transition(closedState, nil)
 	}
+	rc.mu.Unlock()
+	rc.emit(ev)
+	return true
 }
 
 func (rc *recConn) Shutdown(writeWait time.Duration) {
-	if rc.isState(closedState | closedForeverState) {
+	conn, ok := rc.conn()
+	if !ok {
 		return
 	}
 	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
-	err := rc.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+	err := conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
 	if err != nil && err != websocket.ErrCloseSent {
 		rc.opts.LogFn.Error(err, "shutdown error")
 		// If close message could not be sent, then close without the handshake.
@@ -204,18 +265,34 @@ func (rc *recConn) Shutdown(writeWait time.Duration) {
 	}
 }
 
+// conn returns the current connection and whether it is connected.
+func (rc *recConn) conn() (*websocket.Conn, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.Conn, rc.state == connectedState
+}
+
+// handleError closes the connection after a failed read or write and
+// either reconnects or, if the server asked so, closes it forever.
+// It returns nil if the error was a respected server closure.
+func (rc *recConn) handleError(conn *websocket.Conn, err error) error {
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure) && rc.opts.RespectServerClosure {
+		rc.close(conn, true)
+		return nil
+	}
+	rc.closeAndReconnect(conn)
+	return err
+}
+
 func (rc *recConn) ReadMessage() (messageType int, message []byte, err error) {
-	if !rc.IsConnected() {
+	conn, ok := rc.conn()
+	if !ok {
 		return messageType, message, ErrNotConnected
 	}
 
-	messageType, message, err = rc.Conn.ReadMessage()
+	messageType, message, err = conn.ReadMessage()
 	if err != nil {
-		if websocket.IsCloseError(err, websocket.CloseNormalClosure) && rc.opts.RespectServerClosure {
-			rc.Close(true)
-			return messageType, message, nil
-		}
-		rc.CloseAndReconnect()
+		err = rc.handleError(conn, err)
 	}
 
 	return
@@ -227,14 +304,11 @@ func (rc *recConn) WriteMessage(messageType int, data []byte) (err error) {
 	}
 
 	rc.mu.Lock()
-	err = rc.Conn.WriteMessage(messageType, data)
+	conn := rc.Conn
+	err = conn.WriteMessage(messageType, data)
 	rc.mu.Unlock()
 	if err != nil {
-		if websocket.IsCloseError(err, websocket.CloseNormalClosure) && rc.opts.RespectServerClosure {
-			rc.Close(true)
-			return nil
-		}
-		rc.CloseAndReconnect()
+		err = rc.handleError(conn, err)
 	}
 
 	return err
@@ -246,31 +320,25 @@ func (rc *recConn) WriteJSON(v interface{}) (err error) {
 	}
 
 	rc.mu.Lock()
-	err = rc.Conn.WriteJSON(v)
+	conn := rc.Conn
+	err = conn.WriteJSON(v)
 	rc.mu.Unlock()
 	if err != nil {
-		if websocket.IsCloseError(err, websocket.CloseNormalClosure) && rc.opts.RespectServerClosure {
-			rc.Close(true)
-			return nil
-		}
-		rc.CloseAndReconnect()
+		err = rc.handleError(conn, err)
 	}
 
 	return err
 }
 
 func (rc *recConn) ReadJSON(v interface{}) (err error) {
-	if !rc.IsConnected() {
+	conn, ok := rc.conn()
+	if !ok {
 		return ErrNotConnected
 	}
 
-	err = rc.Conn.ReadJSON(v)
+	err = conn.ReadJSON(v)
 	if err != nil {
-		if websocket.IsCloseError(err, websocket.CloseNormalClosure) && rc.opts.RespectServerClosure {
-			rc.Close(true)
-			return nil
-		}
-		rc.CloseAndReconnect()
+		err = rc.handleError(conn, err)
 	}
 
 	return err
@@ -281,7 +349,10 @@ func (rc *recConn) Dial() error {
 }
 
 func (rc *recConn) DialContext(ctx context.Context) error {
-	if !rc.setStateIfNot(connectingState, connectingState|connectedState) {
+	if rc.isState(closedForeverState) {
+		return ErrNotConnected
+	}
+	if !rc.setStateIfNot(connectingState, connectingState|connectedState|closedForeverState) {
 		return nil
 	}
 
@@ -296,23 +367,59 @@ func (rc *recConn) dial(ctx context.Context) error {
 	wsConn, httpResp, err := rc.dialer.DialContext(ctx, rc.url, rc.reqHeader)
 
 	rc.mu.Lock()
-	rc.Conn = wsConn
-	if err == nil {
-		rc.state = connectedState
-	} else {
-		rc.state = closedState
+	if rc.state == closedForeverState {
+		// the connection was closed forever while dialing
+		rc.mu.Unlock()
+		if wsConn != nil {
+			wsConn.Close()
+		}
+		return ErrNotConnected
 	}
+	rc.Conn = wsConn
 	rc.httpResp = httpResp
-	rc.mu.Unlock()
-
 	if err != nil {
+		ev := rc.transition(closedState, err)
+		rc.mu.Unlock()
+		rc.emit(ev)
 		return err
 	}
+	rc.mu.Unlock()
 
-	rc.opts.OnConnectCallback()
+	// the state is still connecting, so nobody else writes to the connection yet
+	if rc.opts.SubscribeFn != nil {
+		if err := rc.opts.SubscribeFn(wsConn); err != nil {
+			wsConn.Close()
+			rc.mu.Lock()
+			ev := rc.transition(closedState, err)
+			rc.mu.Unlock()
+			rc.emit(ev)
+			return fmt.Errorf("subscribe error: %w", err)
+		}
+	}
+
+	// start the keepalive before anyone reads from the connection
 	if rc.IsKeepAliveEnabled() {
-		rc.keepAlive()
+		rc.keepAlive(wsConn)
+	}
+
+	rc.mu.Lock()
+	if rc.state == closedForeverState {
+		if rc.keepaliveCancel != nil {
+			rc.keepaliveCancel()
+		}
+		rc.mu.Unlock()
+		wsConn.Close()
+		return ErrNotConnected
+	}
+	ev := rc.transition(connectedState, nil)
+	rc.mu.Unlock()
+	rc.emit(ev)
+
+	select {
+	case rc.connected <- struct{}{}:
+	default:
 	}
+	rc.opts.OnConnectCallback()
 	rc.opts.LogFn.Debug(fmt.Sprintf("Dial: connection successfully established with %s", rc.url))
 
 	return nil
@@ -327,6 +434,9 @@ func (rc *recConn) reconnect() error {
 	rand.Seed(time.Now().UTC().UnixNano())
 
 	for {
+		if rc.isState(closedForeverState) {
+			return nil
+		}
 		err := rc.dial(context.Background())
 		if err == nil {
 			return nil
@@ -335,6 +445,9 @@ func (rc *recConn) reconnect() error {
 		waitDuration := b.Duration()
 		rc.opts.LogFn.Error(err, fmt.Sprintf("dial error, will try again in %f seconds", waitDuration.Seconds()))
 		time.Sleep(waitDuration)
+
+		// the failed dial closed the connection, mark the next attempt
+		rc.setStateIfNot(connectingState, connectingState|connectedState|closedForeverState)
 	}
 }
 
@@ -353,17 +466,17 @@ func (rc *recConn) makeBackoff() backoff.Backoff {
 	}
 }
 
-func (rc *recConn) writeControlPingMessage() error {
+func (rc *recConn) writeControlPingMessage(conn *websocket.Conn) error {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
-	return rc.Conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second))
+	return conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second))
 }
 
-func (rc *recConn) keepAlive() {
+func (rc *recConn) keepAlive(conn *websocket.Conn) {
 	var keepAliveResponse = new(keepAliveResponse)
 	rc.mu.Lock()
-	rc.Conn.SetPongHandler(func(msg string) error {
+	conn.SetPongHandler(func(msg string) error {
 		keepAliveResponse.setLastResponse()
 		return nil
 	})
@@ -371,22 +484,23 @@ func (rc *recConn) keepAlive() {
 		rc.keepaliveCancel()
 	}
 	rc.keepaliveCtx, rc.keepaliveCancel = context.WithCancel(context.Background())
+	ctx := rc.keepaliveCtx
 	rc.mu.Unlock()
 
 	go func() {
 		var ticker = time.NewTicker(rc.opts.KeepAliveTimeout)
 		defer ticker.Stop()
 
-		if err := rc.writeControlPingMessage(); err != nil {
+		if err := rc.writeControlPingMessage(conn); err != nil {
 			rc.opts.LogFn.Error(err, "error in writing ping message")
 		}
 
 		for {
 			select {
-			case <-rc.keepaliveCtx.Done():
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				if err := rc.writeControlPingMessage(); err != nil {
+				if err := rc.writeControlPingMessage(conn); err != nil {
 					rc.opts.LogFn.Error(err, "error in writing ping message")
 				}
 			}
@@ -399,11 +513,11 @@ func (rc *recConn) keepAlive() {
 
 		for {
 			select {
-			case <-rc.keepaliveCtx.Done():
+			case <-ctx.Done():
 				return
 			case tick := <-ticker.C:
 				if tick.Sub(keepAliveResponse.getLastResponse().Add(time.Millisecond)) > rc.opts.KeepAliveTimeout {
-					go rc.CloseAndReconnect()
+					go rc.closeAndReconnect(conn)
 					return
 				}
 			}
@@ -411,28 +525,91 @@ func (rc *recConn) keepAlive() {
 	}()
 }
 
-func (rc *recConn) setState(state int) {
-	rc.mu.Lock()
-	defer rc.mu.Unlock()
+// transition sets the new state and returns the resulting event.
+// rc.mu must be held by the caller, the event must be passed to emit once it's released.
+func (rc *recConn) transition(state State, err error) *StateEvent {
+	if rc.state == state {
+		return nil
+	}
+	ev := &StateEvent{From: rc.state, To: state, Err: err, Time: time.Now()}
 	rc.state = state
+	if state == closedForeverState {
+		rc.closeOnce.Do(func() { close(rc.closedForever) })
+	}
+	return ev
+}
+
+func (rc *recConn) emit(ev *StateEvent) {
+	if ev != nil {
+		rc.opts.OnStateChange(*ev)
+	}
 }
 
-func (rc *recConn) isState(s int) bool {
+func (rc *recConn) isState(s State) bool {
 	rc.mu.RLock()
 	defer rc.mu.RUnlock()
 	return (rc.state & s) > 0
 }
 
-func (rc *recConn) setStateIfNot(targetState, conditionState int) bool {
+func (rc *recConn) setStateIfNot(targetState, conditionState State) bool {
+	rc.mu.Lock()
+	if rc.state&conditionState != 0 {
+		rc.mu.Unlock()
+		return false
+	}
+	ev := rc.transition(targetState, nil)
+	rc.mu.Unlock()
+	rc.emit(ev)
+	return true
+}
+
+func (rc *recConn) State() State {
 	rc.mu.RLock()
 	defer rc.mu.RUnlock()
+	return rc.state
+}
 
-	if rc.state&conditionState == 0 {
-		rc.state = targetState
-		return true
-	}
+func (rc *recConn) OnMessage(fn MessageHandler) {
+	rc.handlersMu.Lock()
+	defer rc.handlersMu.Unlock()
+	rc.handlers = append(rc.handlers, fn)
+}
 
-	return false
+func (rc *recConn) Listen(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		messageType, data, err := rc.ReadMessage()
+		if err != nil {
+			if !errors.Is(err, ErrNotConnected) {
+				rc.opts.LogFn.Error(err, "read error")
+				continue
+			}
+			if rc.isState(closedForeverState) {
+				return nil
+			}
+			// wait until the connection is reestablished
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-rc.closedForever:
+				return nil
+			case <-rc.connected:
+			}
+			continue
+		}
+		if rc.isState(closedForeverState) {
+			// closed by the server, see RespectServerClosure
+			return nil
+		}
+
+		rc.handlersMu.RLock()
+		for _, fn := range rc.handlers {
+			fn(messageType, data)
+		}
+		rc.handlersMu.RUnlock()
+	}
 }
 
 func (rc *recConn) GetHTTPResponse() *http.Response {
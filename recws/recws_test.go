@@ -0,0 +1,494 @@
+package recws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTimeout  = time.Second * 2
+	testInterval = time.Millisecond * 5
+)
+
+// testServer is an in-process websocket server which can misbehave on demand.
+type testServer struct {
+	*httptest.Server
+	upgrader websocket.Upgrader
+
+	refuse     int32 // refuse handshakes with 503
+	stallPongs int32 // don't answer pings
+	connects   int32
+	refused    int32
+
+	mu       sync.Mutex
+	conns    []*websocket.Conn
+	received [][]byte
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(ts.handle))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) handle(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&ts.refuse) == 1 {
+		atomic.AddInt32(&ts.refused, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	conn, err := ts.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.SetPingHandler(func(data string) error {
+		if atomic.LoadInt32(&ts.stallPongs) == 1 {
+			return nil
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	ts.mu.Lock()
+	ts.conns = append(ts.conns, conn)
+	ts.mu.Unlock()
+	atomic.AddInt32(&ts.connects, 1)
+
+	go func() {
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			ts.mu.Lock()
+			ts.received = append(ts.received, data)
+			ts.mu.Unlock()
+		}
+	}()
+}
+
+func (ts *testServer) url() string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func (ts *testServer) connections() int {
+	return int(atomic.LoadInt32(&ts.connects))
+}
+
+func (ts *testServer) refusals() int {
+	return int(atomic.LoadInt32(&ts.refused))
+}
+
+func (ts *testServer) messages() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	msgs := make([]string, len(ts.received))
+	for i, m := range ts.received {
+		msgs[i] = string(m)
+	}
+	return msgs
+}
+
+// last returns the most recent connection.
+func (ts *testServer) last() *websocket.Conn {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.conns[len(ts.conns)-1]
+}
+
+// drop closes all connections without a close handshake.
+func (ts *testServer) drop() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, c := range ts.conns {
+		c.UnderlyingConn().Close()
+	}
+}
+
+func (ts *testServer) send(msg string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.conns[len(ts.conns)-1].WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+// closeNormal asks the client to close the connection with a normal closure.
+func (ts *testServer) closeNormal() error {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")
+	return ts.last().WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+}
+
+// stateRecorder records all state events of a connection.
+type stateRecorder struct {
+	mu     sync.Mutex
+	events []StateEvent
+}
+
+func (r *stateRecorder) record(ev StateEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func (r *stateRecorder) count(to State) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, ev := range r.events {
+		if ev.To == to {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *stateRecorder) last() StateEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func newTestConn(t *testing.T, url string, opts ...Opts) (*recConn, *stateRecorder) {
+	rec := &stateRecorder{}
+	opts = append([]Opts{
+		WithReconnectIntervalMin(time.Millisecond * 10),
+		WithReconnectIntervalMax(time.Millisecond * 50),
+		WithHandshakeTimeout(time.Second),
+		WithOnStateChange(rec.record),
+	}, opts...)
+	rc, err := New(url, nil, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { rc.Close(true) })
+	return rc.(*recConn), rec
+}
+
+// listen runs Listen in the background and collects all text messages.
+func listen(t *testing.T, rc *recConn) (msgs func() []string, done <-chan error) {
+	var (
+		mu       sync.Mutex
+		received []string
+		errCh    = make(chan error, 1)
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	rc.OnMessage(func(_ int, data []byte) {
+		mu.Lock()
+		received = append(received, string(data))
+		mu.Unlock()
+	})
+	go func() { errCh <- rc.Listen(ctx) }()
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), received...)
+	}, errCh
+}
+
+func TestNewInvalidURL(t *testing.T) {
+	for _, u := range []string{"", "http://localhost", "ws://user:pass@localhost", "://"} {
+		_, err := New(u, nil)
+		assert.Error(t, err, u)
+	}
+}
+
+func TestMakeBackoff(t *testing.T) {
+	rc, _ := newTestConn(t, "ws://localhost",
+		WithReconnectIntervalMin(time.Millisecond*100),
+		WithReconnectIntervalMax(time.Second),
+		WithReconnectIntervalFactor(2),
+	)
+	b := rc.makeBackoff()
+	assert.Equal(t, time.Millisecond*100, b.Min)
+	assert.Equal(t, time.Second, b.Max)
+	assert.Equal(t, float64(2), b.Factor)
+	assert.True(t, b.Jitter)
+
+	for i := 0; i < 20; i++ {
+		d := b.Duration()
+		assert.GreaterOrEqual(t, d, b.Min, "attempt %d", i)
+		assert.LessOrEqual(t, d, b.Max, "attempt %d", i)
+	}
+	// the interval is capped at max after enough attempts
+	assert.Equal(t, time.Second, b.ForAttempt(20))
+
+	// every reconnect loop starts with a fresh backoff
+	fresh := rc.makeBackoff()
+	assert.Equal(t, float64(0), fresh.Attempt())
+}
+
+func TestMakeBackoffDefaults(t *testing.T) {
+	rc, err := New("ws://localhost", nil)
+	require.NoError(t, err)
+	b := rc.(*recConn).makeBackoff()
+	assert.Equal(t, time.Second*2, b.Min)
+	assert.Equal(t, time.Second*30, b.Max)
+	assert.Equal(t, 1.5, b.Factor)
+}
+
+func TestDialAndListen(t *testing.T) {
+	ts := newTestServer(t)
+	rc, rec := newTestConn(t, ts.url())
+
+	require.NoError(t, rc.Dial())
+	assert.True(t, rc.IsConnected())
+	assert.Equal(t, StateConnected, rc.State())
+	assert.Equal(t, http.StatusSwitchingProtocols, rc.GetHTTPResponse().StatusCode)
+	assert.Equal(t, 1, rec.count(StateConnecting))
+	assert.Equal(t, 1, rec.count(StateConnected))
+
+	// dialing an established connection is a no-op
+	require.NoError(t, rc.Dial())
+	assert.Equal(t, 1, ts.connections())
+
+	msgs, _ := listen(t, rc)
+	require.NoError(t, ts.send("hello"))
+	assert.Eventually(t, func() bool { return len(msgs()) == 1 }, testTimeout, testInterval)
+	assert.Equal(t, []string{"hello"}, msgs())
+
+	require.NoError(t, rc.WriteMessage(websocket.TextMessage, []byte("ping")))
+	require.NoError(t, rc.WriteJSON(map[string]string{"type": "ping"}))
+	assert.Eventually(t, func() bool { return len(ts.messages()) == 2 }, testTimeout, testInterval)
+	assert.Equal(t, []string{"ping", `{"type":"ping"}` + "\n"}, ts.messages())
+}
+
+func TestDialRefusedHandshake(t *testing.T) {
+	ts := newTestServer(t)
+	atomic.StoreInt32(&ts.refuse, 1)
+	rc, rec := newTestConn(t, ts.url())
+
+	err := rc.Dial()
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	assert.False(t, rc.IsConnected())
+	assert.Equal(t, StateClosed, rc.State())
+	assert.Equal(t, http.StatusServiceUnavailable, rc.GetHTTPResponse().StatusCode)
+
+	ev := rec.last()
+	assert.Equal(t, StateConnecting, ev.From)
+	assert.Equal(t, StateClosed, ev.To)
+	assert.ErrorIs(t, ev.Err, websocket.ErrBadHandshake)
+
+	_, _, err = rc.ReadMessage()
+	assert.ErrorIs(t, err, ErrNotConnected)
+	assert.ErrorIs(t, rc.WriteMessage(websocket.TextMessage, nil), ErrNotConnected)
+
+	// dialing again works once the server accepts connections
+	atomic.StoreInt32(&ts.refuse, 0)
+	require.NoError(t, rc.Dial())
+	assert.True(t, rc.IsConnected())
+}
+
+func TestReconnectAfterDrop(t *testing.T) {
+	ts := newTestServer(t)
+	var subscribed int32
+	rc, rec := newTestConn(t, ts.url(), WithSubscribeFn(func(w Writer) error {
+		atomic.AddInt32(&subscribed, 1)
+		return w.WriteMessage(websocket.TextMessage, []byte("subscribe"))
+	}))
+	require.NoError(t, rc.Dial())
+	msgs, _ := listen(t, rc)
+	assert.Eventually(t, func() bool { return len(ts.messages()) == 1 }, testTimeout, testInterval)
+
+	ts.drop()
+	assert.Eventually(t, func() bool { return ts.connections() == 2 && rc.IsConnected() }, testTimeout, testInterval)
+	assert.Equal(t, 2, rec.count(StateConnected))
+	assert.GreaterOrEqual(t, rec.count(StateClosed), 1)
+
+	// the subscription is replayed on every connection
+	assert.Equal(t, int32(2), atomic.LoadInt32(&subscribed))
+	assert.Eventually(t, func() bool { return len(ts.messages()) == 2 }, testTimeout, testInterval)
+	assert.Equal(t, []string{"subscribe", "subscribe"}, ts.messages())
+
+	// Listen continues with the new connection
+	require.NoError(t, ts.send("after reconnect"))
+	assert.Eventually(t, func() bool { return len(msgs()) == 1 }, testTimeout, testInterval)
+}
+
+func TestReconnectBacksOffWhileRefused(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url())
+	require.NoError(t, rc.Dial())
+	listen(t, rc)
+
+	atomic.StoreInt32(&ts.refuse, 1)
+	ts.drop()
+	assert.Eventually(t, func() bool { return ts.refusals() >= 3 }, testTimeout, testInterval)
+	assert.False(t, rc.IsConnected())
+
+	atomic.StoreInt32(&ts.refuse, 0)
+	assert.Eventually(t, rc.IsConnected, testTimeout, testInterval)
+	assert.Equal(t, 2, ts.connections())
+}
+
+func TestSubscribeError(t *testing.T) {
+	ts := newTestServer(t)
+	errSubscribe := errors.New("subscribe failed")
+	rc, rec := newTestConn(t, ts.url(), WithSubscribeFn(func(w Writer) error {
+		return errSubscribe
+	}))
+
+	err := rc.Dial()
+	assert.ErrorIs(t, err, errSubscribe)
+	assert.Equal(t, StateClosed, rc.State())
+	assert.Equal(t, 0, rec.count(StateConnected))
+	assert.ErrorIs(t, rec.last().Err, errSubscribe)
+}
+
+func TestKeepAliveTimeout(t *testing.T) {
+	ts := newTestServer(t)
+	atomic.StoreInt32(&ts.stallPongs, 1)
+	rc, rec := newTestConn(t, ts.url(), WithKeepAliveTimeout(time.Millisecond*50))
+	require.NoError(t, rc.Dial())
+	listen(t, rc)
+
+	// no pongs, the connection is considered dead and reestablished
+	assert.Eventually(t, func() bool { return ts.connections() >= 2 }, testTimeout, testInterval)
+	assert.GreaterOrEqual(t, rec.count(StateClosed), 1)
+
+	// once the server answers again, the connection stays up
+	atomic.StoreInt32(&ts.stallPongs, 0)
+	// a connection established while stalling might still time out
+	time.Sleep(time.Millisecond * 150)
+	assert.Eventually(t, rc.IsConnected, testTimeout, testInterval)
+	connects := ts.connections()
+	time.Sleep(time.Millisecond * 300)
+	assert.Equal(t, connects, ts.connections())
+	assert.True(t, rc.IsConnected())
+}
+
+func TestKeepAliveHealthy(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url(), WithKeepAliveTimeout(time.Millisecond*50))
+	require.NoError(t, rc.Dial())
+	listen(t, rc)
+
+	time.Sleep(time.Millisecond * 300)
+	assert.Equal(t, 1, ts.connections())
+	assert.True(t, rc.IsConnected())
+}
+
+func TestCloseForever(t *testing.T) {
+	ts := newTestServer(t)
+	rc, rec := newTestConn(t, ts.url())
+	require.NoError(t, rc.Dial())
+	_, done := listen(t, rc)
+
+	rc.Close(true)
+	assert.Equal(t, StateClosedForever, rc.State())
+	assert.False(t, rc.IsConnected())
+	assert.Equal(t, 1, rec.count(StateClosedForever))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(testTimeout):
+		t.Fatal("Listen did not return after Close(true)")
+	}
+
+	// the connection is neither reconnected nor reusable
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 1, ts.connections())
+	assert.ErrorIs(t, rc.WriteMessage(websocket.TextMessage, nil), ErrNotConnected)
+	assert.ErrorIs(t, rc.Dial(), ErrNotConnected)
+	assert.Equal(t, StateClosedForever, rc.State())
+
+	// closing again is a no-op
+	rc.Close(false)
+	rc.Close(true)
+	assert.Equal(t, StateClosedForever, rc.State())
+	assert.Equal(t, 1, rec.count(StateClosedForever))
+}
+
+func TestCloseNotForever(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url())
+	require.NoError(t, rc.Dial())
+
+	rc.Close(false)
+	assert.Equal(t, StateClosed, rc.State())
+
+	// a closed connection can be dialed again
+	require.NoError(t, rc.Dial())
+	assert.True(t, rc.IsConnected())
+	assert.Equal(t, 2, ts.connections())
+}
+
+func TestCloseForeverStopsReconnecting(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url())
+	require.NoError(t, rc.Dial())
+	listen(t, rc)
+
+	atomic.StoreInt32(&ts.refuse, 1)
+	ts.drop()
+	assert.Eventually(t, func() bool { return ts.refusals() >= 2 }, testTimeout, testInterval)
+
+	rc.Close(true)
+	// a dial might still be in flight
+	refusals := ts.refusals() + 1
+	atomic.StoreInt32(&ts.refuse, 0)
+	time.Sleep(time.Millisecond * 200)
+
+	assert.LessOrEqual(t, ts.refusals(), refusals)
+	assert.Equal(t, 1, ts.connections())
+	assert.Equal(t, StateClosedForever, rc.State())
+}
+
+func TestCloseForeverWithoutConnection(t *testing.T) {
+	rc, rec := newTestConn(t, "ws://localhost")
+	rc.Close(true)
+	rc.Shutdown(time.Second)
+	assert.Equal(t, StateClosedForever, rc.State())
+	assert.Equal(t, 1, rec.count(StateClosedForever))
+}
+
+func TestRespectServerClosure(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url(), WithRespectServerClosure(true))
+	require.NoError(t, rc.Dial())
+	_, done := listen(t, rc)
+
+	require.NoError(t, ts.closeNormal())
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(testTimeout):
+		t.Fatal("Listen did not return after the server closed the connection")
+	}
+	assert.Equal(t, StateClosedForever, rc.State())
+
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 1, ts.connections())
+}
+
+func TestIgnoreServerClosure(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url(), WithRespectServerClosure(false))
+	require.NoError(t, rc.Dial())
+	listen(t, rc)
+
+	require.NoError(t, ts.closeNormal())
+	assert.Eventually(t, func() bool { return ts.connections() == 2 && rc.IsConnected() }, testTimeout, testInterval)
+}
+
+func TestRespectServerClosureOnlyOnNormalClosure(t *testing.T) {
+	ts := newTestServer(t)
+	rc, _ := newTestConn(t, ts.url(), WithRespectServerClosure(true))
+	require.NoError(t, rc.Dial())
+	listen(t, rc)
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "restart")
+	require.NoError(t, ts.last().WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second)))
+	assert.Eventually(t, func() bool { return ts.connections() == 2 && rc.IsConnected() }, testTimeout, testInterval)
+}
@@ -0,0 +1,44 @@
+package recws
+
+import (
+	"fmt"
+	"time"
+)
+
+// State represents the state of a reconnecting websocket connection.
+type State int
+
+const (
+	// StateClosed means the connection is closed but may be reconnected.
+	StateClosed State = 1 << iota
+	// StateConnecting means a connection is being established.
+	StateConnecting
+	// StateConnected means the connection is established.
+	StateConnected
+	// StateClosedForever means the connection is closed and won't be reconnected.
+	StateClosedForever
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosedForever:
+		return "closed forever"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// StateEvent is passed to the OnStateChange handler whenever the state of the connection changes.
+type StateEvent struct {
+	From State
+	To   State
+	// Err is the error which caused the state change, if any.
+	Err  error
+	Time time.Time
+}
@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+)
+
+const (
+	defaultInterval    = time.Minute
+	topMinersPageSize  = 15
+	defaultMaxAgeRatio = 3
+)
+
+var (
+	// PerformanceRanges are the pool performance ranges which are precomputed.
+	PerformanceRanges = []database.SampleRange{database.RangeHour, database.RangeDay, database.RangeMonth}
+	// PerformanceIntervals are the pool performance intervals which are precomputed.
+	PerformanceIntervals = []database.SampleInterval{database.IntervalHour, database.IntervalDay}
+)
+
+// DB contains the queries which are precomputed by the scheduler.
+type DB interface {
+	GetTopMinerStats(ctx context.Context, poolID string, from time.Time, page int, pageSize int) ([]*database.TopMinerStats, error)
+	GetPoolPerformanceBetween(ctx context.Context, poolID string, interval database.SampleInterval, start, end time.Time) ([]*database.AggregatedPoolStats, error)
+	GetOverallPoolStats(ctx context.Context) (database.OverallPoolStats, error)
+}
+
+// Opts is a function that can be passed to New to configure the scheduler
+type Opts func(s *Scheduler)
+
+// WithContext sets the context to use for the scheduler
+func WithContext(ctx context.Context) Opts {
+	return func(s *Scheduler) {
+		s.parentCtx = ctx
+	}
+}
+
+// WithInterval sets the interval in which the aggregates are computed
+func WithInterval(interval time.Duration) Opts {
+	return func(s *Scheduler) {
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+// WithMaxAge sets the age after which a snapshot is no longer served, defaults to 3 intervals
+func WithMaxAge(maxAge time.Duration) Opts {
+	return func(s *Scheduler) {
+		s.maxAge = maxAge
+	}
+}
+
+// WithPools sets the pools for which the aggregates are computed
+func WithPools(pools ...string) Opts {
+	return func(s *Scheduler) {
+		s.pools = pools
+	}
+}
+
+// WithTopMinersRanges sets the ranges in hours for which the top miners are computed
+func WithTopMinersRanges(ranges ...int) Opts {
+	return func(s *Scheduler) {
+		s.topMinersRanges = ranges
+	}
+}
+
+// Snapshot is a precomputed aggregate.
+type Snapshot[T any] struct {
+	Value       T
+	GeneratedAt time.Time
+}
+
+type performanceKey struct {
+	pool     string
+	r        database.SampleRange
+	interval database.SampleInterval
+}
+
+type topMinersKey struct {
+	pool  string
+	hours int
+}
+
+// Scheduler precomputes expensive aggregates in the background
+// so requests can be served from memory.
+type Scheduler struct {
+	parentCtx       context.Context
+	ctx             context.Context
+	cancel          context.CancelFunc
+	db              DB
+	interval        time.Duration
+	maxAge          time.Duration
+	pools           []string
+	topMinersRanges []int
+
+	mu          sync.RWMutex
+	overall     *Snapshot[database.OverallPoolStats]
+	topMiners   map[topMinersKey]*Snapshot[[]*database.TopMinerStats]
+	performance map[performanceKey]*Snapshot[[]*database.AggregatedPoolStats]
+
+	// serializes the computation of a pool
+	runMu sync.Mutex
+}
+
+// New creates a new scheduler.
+func New(db DB, opts ...Opts) *Scheduler {
+	s := &Scheduler{
+		parentCtx:       context.Background(),
+		db:              db,
+		interval:        defaultInterval,
+		topMinersRanges: []int{1},
+		topMiners:       make(map[topMinersKey]*Snapshot[[]*database.TopMinerStats]),
+		performance:     make(map[performanceKey]*Snapshot[[]*database.AggregatedPoolStats]),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxAge <= 0 {
+		s.maxAge = s.interval * defaultMaxAgeRatio
+	}
+	s.ctx, s.cancel = context.WithCancel(s.parentCtx)
+	return s
+}
+
+// Start computes the aggregates at the configured interval until the scheduler is closed.
+func (s *Scheduler) Start() {
+	log.Printf("[scheduler] starting with interval %s", s.interval)
+	s.Run()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Run()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the scheduler.
+func (s *Scheduler) Close() {
+	s.cancel()
+}
+
+// Run computes all aggregates once.
+func (s *Scheduler) Run() {
+	start := time.Now()
+	if err := s.computeOverall(); err != nil {
+		log.Printf("[scheduler][err] %s", err)
+	}
+	for _, pool := range s.pools {
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.RunPool(pool)
+	}
+	log.Printf("[scheduler] computed aggregates in %s", time.Since(start))
+}
+
+// RunPool computes the aggregates of the given pool once.
+func (s *Scheduler) RunPool(poolID string) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	for _, hours := range s.topMinersRanges {
+		if err := s.computeTopMiners(poolID, hours); err != nil {
+			log.Printf("[scheduler][err] %s", err)
+		}
+	}
+	for _, r := range PerformanceRanges {
+		for _, i := range PerformanceIntervals {
+			if err := s.computePerformance(poolID, r, i); err != nil {
+				log.Printf("[scheduler][err] %s", err)
+			}
+		}
+	}
+}
+
+// RefreshPayments recomputes the aggregates which contain payments,
+// the overall stats and the top miners of the given pool.
+func (s *Scheduler) RefreshPayments(poolID string) {
+	if err := s.computeOverall(); err != nil {
+		log.Printf("[scheduler][err] %s", err)
+	}
+	if !s.hasPool(poolID) {
+		return
+	}
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	for _, hours := range s.topMinersRanges {
+		if err := s.computeTopMiners(poolID, hours); err != nil {
+			log.Printf("[scheduler][err] %s", err)
+		}
+	}
+}
+
+func (s *Scheduler) hasPool(poolID string) bool {
+	for _, p := range s.pools {
+		if p == poolID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scheduler) computeOverall() error {
+	stats, err := s.db.GetOverallPoolStats(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to compute overall stats: %w", err)
+	}
+	s.mu.Lock()
+	s.overall = &Snapshot[database.OverallPoolStats]{Value: stats, GeneratedAt: time.Now()}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Scheduler) computeTopMiners(poolID string, hours int) error {
+	now := time.Now()
+	stats, err := s.db.GetTopMinerStats(s.ctx, poolID, now.Add(-time.Duration(hours)*time.Hour), 0, topMinersPageSize)
+	if err != nil {
+		return fmt.Errorf("failed to compute top miners of %s for %dh: %w", poolID, hours, err)
+	}
+	s.mu.Lock()
+	s.topMiners[topMinersKey{pool: poolID, hours: hours}] = &Snapshot[[]*database.TopMinerStats]{Value: stats, GeneratedAt: now}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Scheduler) computePerformance(poolID string, r database.SampleRange, interval database.SampleInterval) error {
+	end := time.Now()
+	start, err := r.Start(end)
+	if err != nil {
+		return err
+	}
+	stats, err := s.db.GetPoolPerformanceBetween(s.ctx, poolID, interval, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to compute pool performance of %s for %s/%s: %w", poolID, r, interval, err)
+	}
+	s.mu.Lock()
+	s.performance[performanceKey{pool: poolID, r: r, interval: interval}] = &Snapshot[[]*database.AggregatedPoolStats]{Value: stats, GeneratedAt: end}
+	s.mu.Unlock()
+	return nil
+}
+
+// OverallStats returns the precomputed overall stats.
+// It returns nil if there is no snapshot or it is outdated.
+func (s *Scheduler) OverallStats() *Snapshot[database.OverallPoolStats] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fresh(s.overall, s.maxAge)
+}
+
+// TopMiners returns the precomputed top miners of the pool for the given range in hours.
+// It returns nil if there is no snapshot or it is outdated.
+func (s *Scheduler) TopMiners(poolID string, hours int) *Snapshot[[]*database.TopMinerStats] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fresh(s.topMiners[topMinersKey{pool: poolID, hours: hours}], s.maxAge)
+}
+
+// PoolPerformance returns the precomputed performance of the pool for the given range and interval.
+// It returns nil if there is no snapshot or it is outdated.
+func (s *Scheduler) PoolPerformance(poolID string, r database.SampleRange, interval database.SampleInterval) *Snapshot[[]*database.AggregatedPoolStats] {
+	if interval != database.IntervalDay {
+		// the database falls back to hourly samples for unknown intervals
+		interval = database.IntervalHour
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fresh(s.performance[performanceKey{pool: poolID, r: r, interval: interval}], s.maxAge)
+}
+
+func fresh[T any](snapshot *Snapshot[T], maxAge time.Duration) *Snapshot[T] {
+	if snapshot == nil || time.Since(snapshot.GeneratedAt) > maxAge {
+		return nil
+	}
+	return snapshot
+}
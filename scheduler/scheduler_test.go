@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDB struct {
+	mu          sync.Mutex
+	fail        bool
+	topMiners   int
+	performance int
+	overall     int
+}
+
+func (f *fakeDB) GetTopMinerStats(ctx context.Context, poolID string, from time.Time, page int, pageSize int) ([]*database.TopMinerStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return nil, errors.New("db down")
+	}
+	f.topMiners++
+	return []*database.TopMinerStats{{Miner: poolID, Workers: f.topMiners}}, nil
+}
+
+func (f *fakeDB) GetPoolPerformanceBetween(ctx context.Context, poolID string, interval database.SampleInterval, start, end time.Time) ([]*database.AggregatedPoolStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return nil, errors.New("db down")
+	}
+	f.performance++
+	return []*database.AggregatedPoolStats{{ConnectedMiners: f.performance, Created: start}}, nil
+}
+
+func (f *fakeDB) GetOverallPoolStats(ctx context.Context) (database.OverallPoolStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return database.OverallPoolStats{}, errors.New("db down")
+	}
+	f.overall++
+	return database.OverallPoolStats{TotalMiners: int32(f.overall)}, nil
+}
+
+func TestRun(t *testing.T) {
+	db := &fakeDB{}
+	s := New(db, WithPools("eth1", "erg1"), WithTopMinersRanges(1, 24))
+	defer s.Close()
+
+	assert.Nil(t, s.OverallStats())
+	assert.Nil(t, s.TopMiners("eth1", 1))
+
+	s.Run()
+	assert.Equal(t, 1, db.overall)
+	assert.Equal(t, 4, db.topMiners)
+	assert.Equal(t, 12, db.performance)
+
+	overall := s.OverallStats()
+	assert.NotNil(t, overall)
+	assert.Equal(t, int32(1), overall.Value.TotalMiners)
+	assert.WithinDuration(t, time.Now(), overall.GeneratedAt, time.Second)
+
+	top := s.TopMiners("eth1", 24)
+	assert.NotNil(t, top)
+	assert.Equal(t, "eth1", top.Value[0].Miner)
+	assert.Nil(t, s.TopMiners("eth1", 6))
+	assert.Nil(t, s.TopMiners("rvn1", 1))
+
+	perf := s.PoolPerformance("erg1", database.RangeMonth, database.IntervalDay)
+	assert.NotNil(t, perf)
+	// unknown intervals fall back to hourly samples like the database does
+	assert.Equal(t, s.PoolPerformance("erg1", database.RangeDay, database.IntervalHour), s.PoolPerformance("erg1", database.RangeDay, "minute"))
+}
+
+func TestSnapshotMaxAge(t *testing.T) {
+	db := &fakeDB{}
+	s := New(db, WithPools("eth1"), WithMaxAge(time.Millisecond*20))
+	defer s.Close()
+
+	s.Run()
+	assert.NotNil(t, s.OverallStats())
+
+	// failed runs keep the previous snapshot until it is outdated
+	db.fail = true
+	s.Run()
+	assert.NotNil(t, s.OverallStats())
+	time.Sleep(time.Millisecond * 30)
+	assert.Nil(t, s.OverallStats())
+	assert.Nil(t, s.TopMiners("eth1", 1))
+}
+
+func TestRefreshPayments(t *testing.T) {
+	db := &fakeDB{}
+	s := New(db, WithPools("eth1"))
+	defer s.Close()
+
+	s.RefreshPayments("eth1")
+	assert.Equal(t, 1, db.overall)
+	assert.Equal(t, 1, db.topMiners)
+	assert.Equal(t, 0, db.performance)
+
+	// unknown pools only refresh the overall stats
+	s.RefreshPayments("rvn1")
+	assert.Equal(t, 2, db.overall)
+	assert.Equal(t, 1, db.topMiners)
+}
+
+func TestStartAndClose(t *testing.T) {
+	db := &fakeDB{}
+	s := New(db, WithPools("eth1"), WithInterval(time.Millisecond*10))
+
+	done := make(chan struct{})
+	go func() {
+		s.Start()
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		return db.overall >= 2
+	}, time.Second, time.Millisecond*5)
+
+	s.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
@@ -0,0 +1,77 @@
+package signature
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+const ethSignatureLength = 65
+
+var ethAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// Ethereum verifies signatures created with personal_sign (EIP-191), e.g. by MetaMask.
+type Ethereum struct{}
+
+// Supports returns true for hex encoded ethereum addresses.
+func (Ethereum) Supports(address string) bool {
+	return ethAddressRegex.MatchString(address)
+}
+
+// Verify recovers the address from the hex encoded signature and compares it to the given address.
+func (Ethereum) Verify(address, message, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != ethSignatureLength {
+		return ErrInvalidSignature
+	}
+	recovered, err := EthereumRecover(message, sig)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(recovered, address) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// EthereumRecover returns the address which created the personal_sign signature of the message.
+// The signature is r || s || v, v can be 0/1 or 27/28.
+func EthereumRecover(message string, sig []byte) (string, error) {
+	if len(sig) != ethSignatureLength {
+		return "", ErrInvalidSignature
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", ErrInvalidSignature
+	}
+
+	// the compact format of secp256k1 is v || r || s with v = 27 + recovery id
+	compact := make([]byte, ethSignatureLength)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact, EthereumMessageHash(message))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	hash := keccak256(pub.SerializeUncompressed()[1:])
+	return "0x" + hex.EncodeToString(hash[12:]), nil
+}
+
+// EthereumMessageHash returns the hash which is signed by personal_sign.
+func EthereumMessageHash(message string) []byte {
+	return keccak256([]byte("\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message)) + message))
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,39 @@
+package signature
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthereumVerify(t *testing.T) {
+	// example of web3.eth.accounts.sign
+	address := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	sig := "0xb91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+
+	assert.True(t, Supported(address))
+	assert.NoError(t, Verify(address, "Some data", sig))
+	assert.ErrorIs(t, Verify(address, "Other data", sig), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("0x0000000000000000000000000000000000000000", "Some data", sig), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(address, "Some data", "0x1234"), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("dero1qy...", "Some data", sig), ErrUnsupportedAddress)
+}
+
+func TestEthereumRecover(t *testing.T) {
+	key, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+	address := "0x" + hex.EncodeToString(keccak256(key.PubKey().SerializeUncompressed()[1:])[12:])
+
+	// convert the compact signature v || r || s to r || s || v with v = 0/1
+	compact := ecdsa.SignCompact(key, EthereumMessageHash("nonce"), false)
+	sig := append(compact[1:], compact[0]-27)
+
+	recovered, err := EthereumRecover("nonce", sig)
+	require.NoError(t, err)
+	assert.Equal(t, address, recovered)
+	assert.NoError(t, Ethereum{}.Verify(address, "nonce", hex.EncodeToString(sig)))
+}
@@ -0,0 +1,57 @@
+package signature
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedAddress is returned if no verifier supports the address
+	ErrUnsupportedAddress = errors.New("signatures are not supported for this address")
+	// ErrInvalidSignature is returned if a signature is malformed or wasn't created by the address
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Verifier verifies that a message was signed by the key of an address.
+type Verifier interface {
+	// Supports returns true if the verifier can verify signatures of the address.
+	Supports(address string) bool
+	// Verify returns nil if the signature of the message was created by the key of the address.
+	Verify(address, message, signature string) error
+}
+
+// verifiers are the supported signature schemes, the first one which supports an address is used.
+var verifiers = []Verifier{
+	Ethereum{},
+}
+
+// Supported returns true if signatures of the address can be verified.
+func Supported(address string) bool {
+	return verifier(address) != nil
+}
+
+// Verify verifies the signature of the message with the verifier which supports the address.
+func Verify(address, message, signature string) error {
+	v := verifier(address)
+	if v == nil {
+		return ErrUnsupportedAddress
+	}
+	if err := v.Verify(address, message, signature); err != nil {
+		if errors.Is(err, ErrInvalidSignature) {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+func verifier(address string) Verifier {
+	address = strings.TrimSpace(address)
+	for _, v := range verifiers {
+		if v.Supports(address) {
+			return v
+		}
+	}
+	return nil
+}
@@ -10,9 +10,11 @@ import (
 var (
 	ErrPoolNotFound        = errors.New("pool not found")
 	ErrInvalidRange        = errors.New("invalid range")
+	ErrInvalidInterval     = errors.New("invalid interval")
 	ErrInvalidMinerAddress = errors.New("Invalid or missing miner address")
 	ErrInvalidWorkerName   = errors.New("Invalid or missing worker name")
 	ErrNoStatsFound        = errors.New("no stats found")
+	ErrStatsUnavailable    = errors.New("stats unavailable")
 )
 
 type APIError struct {
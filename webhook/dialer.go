@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const dialTimeout = time.Second * 10
+
+// NewClient creates a http client which refuses connections to private networks, unless they are allowed.
+// Redirects aren't followed, they could point to private networks.
+func NewClient(timeout time.Duration, allowPrivate bool, maxIdleConns int) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialContext(allowPrivate),
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        maxIdleConns,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ValidateTarget checks the url of a webhook.
+// Private networks are refused early if the host is an IP address or localhost,
+// DNS names are checked when the requests are sent.
+func ValidateTarget(target string, allowPrivate bool) error {
+	if err := ValidateURL(target); err != nil {
+		return err
+	}
+	if allowPrivate {
+		return nil
+	}
+	u, _ := url.Parse(target)
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return ErrPrivateTarget
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivate(ip) {
+		return ErrPrivateTarget
+	}
+	return nil
+}
+
+// dialContext returns a dial function which refuses connections to private networks, unless they are allowed.
+// The address is checked after the host has been resolved, so DNS names pointing to private networks are refused too.
+func dialContext(allowPrivate bool) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+	return d.DialContext
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
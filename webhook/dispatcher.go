@@ -0,0 +1,622 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/1oopio/phantomias/utils"
+	"github.com/1oopio/phantomias/ws"
+	"github.com/goccy/go-json"
+)
+
+const (
+	defaultPollInterval     = time.Minute
+	defaultDeliveryInterval = time.Second * 5
+	defaultLookback         = time.Hour * 24
+	defaultMaxAttempts      = 8
+	defaultMinBackoff       = time.Second * 30
+	defaultMaxBackoff       = time.Hour
+	defaultTimeout          = time.Second * 10
+	defaultWorkers          = 4
+	defaultMaxPerMiner      = 3
+	claimBatchSize          = 100
+	maxErrorLength          = 512
+)
+
+// DB contains the queries which are used by the dispatcher.
+type DB interface {
+	GetWebhooks(ctx context.Context) ([]*database.Webhook, error)
+	GetBlocksSince(ctx context.Context, since time.Time) ([]*database.Block, error)
+	GetPaymentsSince(ctx context.Context, since time.Time) ([]*database.Payment, error)
+	InsertWebhookDelivery(ctx context.Context, delivery database.WebhookDelivery) (bool, error)
+	ClaimWebhookDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*database.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery database.WebhookDelivery) error
+	UpdateWebhookPendingBlocks(ctx context.Context, pending, done []*database.Block) error
+}
+
+// Opts is a function that can be passed to New to configure the dispatcher
+type Opts func(d *Dispatcher)
+
+// WithContext sets the context to use for the dispatcher
+func WithContext(ctx context.Context) Opts {
+	return func(d *Dispatcher) {
+		d.parentCtx = ctx
+	}
+}
+
+// WithPollInterval sets the interval in which the database is polled for new events
+func WithPollInterval(interval time.Duration) Opts {
+	return func(d *Dispatcher) {
+		if interval > 0 {
+			d.pollInterval = interval
+		}
+	}
+}
+
+// WithLookback sets the age after which events are no longer dispatched
+func WithLookback(lookback time.Duration) Opts {
+	return func(d *Dispatcher) {
+		if lookback > 0 {
+			d.lookback = lookback
+		}
+	}
+}
+
+// WithMaxAttempts sets the number of attempts after which a delivery is moved to the dead letters
+func WithMaxAttempts(attempts int) Opts {
+	return func(d *Dispatcher) {
+		if attempts > 0 {
+			d.maxAttempts = attempts
+		}
+	}
+}
+
+// WithBackoff sets the delay after the first failed attempt, which is doubled after every attempt up to max
+func WithBackoff(min, max time.Duration) Opts {
+	return func(d *Dispatcher) {
+		if min > 0 {
+			d.minBackoff = min
+		}
+		if max > 0 {
+			d.maxBackoff = max
+		}
+	}
+}
+
+// WithTimeout sets the timeout of a delivery request
+func WithTimeout(timeout time.Duration) Opts {
+	return func(d *Dispatcher) {
+		if timeout > 0 {
+			d.timeout = timeout
+		}
+	}
+}
+
+// WithWorkers sets the number of concurrent deliveries
+func WithWorkers(workers int) Opts {
+	return func(d *Dispatcher) {
+		if workers > 0 {
+			d.workers = workers
+		}
+	}
+}
+
+// WithAllowPrivate allows webhooks which point to private networks
+func WithAllowPrivate(allow bool) Opts {
+	return func(d *Dispatcher) {
+		d.allowPrivate = allow
+	}
+}
+
+// WithMaxPerMiner sets the max number of webhooks per miner
+func WithMaxPerMiner(max int) Opts {
+	return func(d *Dispatcher) {
+		if max > 0 {
+			d.maxPerMiner = max
+		}
+	}
+}
+
+// WithClock sets the function which returns the current time, used in tests
+func WithClock(now func() time.Time) Opts {
+	return func(d *Dispatcher) {
+		d.now = now
+	}
+}
+
+// Dispatcher sends the events of the pools to webhooks.
+// New blocks and payments are polled from the database, notifications of the websocket relay trigger an immediate poll.
+// Every event is queued once per webhook and retried with an exponential backoff until it is moved to the dead letters.
+type Dispatcher struct {
+	parentCtx    context.Context
+	ctx          context.Context
+	cancel       context.CancelFunc
+	db           DB
+	client       *http.Client
+	pollInterval time.Duration
+	lookback     time.Duration
+	maxAttempts  int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	timeout      time.Duration
+	workers      int
+	allowPrivate bool
+	maxPerMiner  int
+	now          func() time.Time
+	trigger      chan struct{}
+
+	// event ids which are already queued
+	seenMu sync.Mutex
+	seen   map[string]time.Time
+
+	// blocks which are tracked as pending in the database, guarded by pollMu
+	tracked map[string]struct{}
+
+	// serializes the polls and the deliveries
+	pollMu    sync.Mutex
+	deliverMu sync.Mutex
+}
+
+// New creates a new dispatcher.
+func New(db DB, opts ...Opts) *Dispatcher {
+	d := &Dispatcher{
+		parentCtx:    context.Background(),
+		db:           db,
+		pollInterval: defaultPollInterval,
+		lookback:     defaultLookback,
+		maxAttempts:  defaultMaxAttempts,
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		timeout:      defaultTimeout,
+		workers:      defaultWorkers,
+		maxPerMiner:  defaultMaxPerMiner,
+		now:          time.Now,
+		trigger:      make(chan struct{}, 1),
+		seen:         make(map[string]time.Time),
+		tracked:      make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.client = NewClient(d.timeout, d.allowPrivate, d.workers)
+	d.ctx, d.cancel = context.WithCancel(d.parentCtx)
+	return d
+}
+
+// Start polls the events and sends the deliveries until the dispatcher is closed.
+func (d *Dispatcher) Start() {
+	log.Printf("[webhooks] starting with poll interval %s", d.pollInterval)
+	go d.deliverLoop()
+
+	d.Poll()
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.Poll()
+		case <-d.trigger:
+			d.Poll()
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the dispatcher.
+func (d *Dispatcher) Close() {
+	d.cancel()
+}
+
+// AllowPrivate returns true if webhooks may point to private networks.
+func (d *Dispatcher) AllowPrivate() bool {
+	return d.allowPrivate
+}
+
+// ValidateURL checks the url of a new webhook, see ValidateTarget.
+func (d *Dispatcher) ValidateURL(target string) error {
+	return ValidateTarget(target, d.allowPrivate)
+}
+
+// MaxPerMiner returns the max number of webhooks per miner.
+func (d *Dispatcher) MaxPerMiner() int {
+	return d.maxPerMiner
+}
+
+// Trigger polls the events as soon as possible.
+func (d *Dispatcher) Trigger() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// OnMessage triggers a poll for miningcore notifications of blocks and payments.
+// It can be registered as message handler of the websocket relay.
+func (d *Dispatcher) OnMessage(data []byte) {
+	var n struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return
+	}
+	switch strings.ToLower(n.Type) {
+	case ws.EventBlockFound, ws.EventBlockUnlocked, ws.EventPayment:
+		d.Trigger()
+	}
+}
+
+func (d *Dispatcher) deliverLoop() {
+	ticker := time.NewTicker(defaultDeliveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.Deliver()
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// Poll queues the events since the lookback for all matching webhooks.
+// Pending blocks are tracked until their status changed, so they are confirmed or orphaned even after the lookback.
+func (d *Dispatcher) Poll() {
+	d.pollMu.Lock()
+	defer d.pollMu.Unlock()
+
+	webhooks, err := d.db.GetWebhooks(d.ctx)
+	if err != nil {
+		log.Printf("[webhooks][err] %s", err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	now := d.now()
+	since := now.Add(-d.lookback)
+	d.pruneSeen(since)
+
+	var events []*Event
+	blocks, err := d.db.GetBlocksSince(d.ctx, since)
+	if err != nil {
+		log.Printf("[webhooks][err] %s", err)
+	}
+	for _, b := range blocks {
+		for _, ev := range blockEvents(b) {
+			// the found event of a tracked block has been queued before
+			if ev.Type == EventBlockFound && ev.Created.Before(since) {
+				continue
+			}
+			events = append(events, ev)
+		}
+	}
+	payments, err := d.db.GetPaymentsSince(d.ctx, since)
+	if err != nil {
+		log.Printf("[webhooks][err] %s", err)
+	}
+	for _, p := range payments {
+		events = append(events, paymentEvent(p))
+	}
+
+	queued := make(map[string]bool)
+	for _, ev := range events {
+		if d.isSeen(ev.ID) {
+			continue
+		}
+		if err := d.queue(ev, webhooks, now); err != nil {
+			log.Printf("[webhooks][err] %s", err)
+			continue
+		}
+		d.markSeen(ev.ID, ev.Created)
+		queued[ev.ID] = true
+	}
+	d.trackPendingBlocks(blocks, queued)
+}
+
+// trackPendingBlocks tracks the pending blocks in the database and stops tracking the blocks
+// whose confirmed or orphaned event has been queued.
+func (d *Dispatcher) trackPendingBlocks(blocks []*database.Block, queued map[string]bool) {
+	var pending, done []*database.Block
+	for _, b := range blocks {
+		_, tracked := d.tracked[blockKey(b)]
+		switch database.BlockStatus(b.Status) {
+		case database.BlockStatusPending:
+			if !tracked {
+				pending = append(pending, b)
+			}
+		case database.BlockStatusConfirmed, database.BlockStatusOrphaned:
+			// blocks which were tracked before a restart are untracked when their event is queued again
+			evs := blockEvents(b)
+			if id := evs[len(evs)-1].ID; d.isSeen(id) && (tracked || queued[id]) {
+				done = append(done, b)
+			}
+		}
+	}
+	if len(pending) == 0 && len(done) == 0 {
+		return
+	}
+	if err := d.db.UpdateWebhookPendingBlocks(d.ctx, pending, done); err != nil {
+		log.Printf("[webhooks][err] %s", err)
+		return
+	}
+	for _, b := range pending {
+		d.tracked[blockKey(b)] = struct{}{}
+	}
+	for _, b := range done {
+		delete(d.tracked, blockKey(b))
+	}
+}
+
+func blockKey(b *database.Block) string {
+	return fmt.Sprintf("%s:%d", b.PoolID, b.ID)
+}
+
+// queue inserts a delivery of the event for every matching webhook.
+func (d *Dispatcher) queue(ev *Event, webhooks []*database.Webhook, now time.Time) error {
+	var payload []byte
+	for _, wh := range webhooks {
+		if !matches(wh, ev) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(ev); err != nil {
+				return fmt.Errorf("failed to encode event %s: %w", ev.ID, err)
+			}
+		}
+		_, err := d.db.InsertWebhookDelivery(d.ctx, database.WebhookDelivery{
+			WebhookID:   wh.ID,
+			EventID:     ev.ID,
+			EventType:   ev.Type,
+			Payload:     string(payload),
+			NextAttempt: now,
+			Created:     now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver sends the pending deliveries which are due.
+func (d *Dispatcher) Deliver() {
+	d.deliverMu.Lock()
+	defer d.deliverMu.Unlock()
+
+	for d.ctx.Err() == nil {
+		now := d.now()
+		// claimed deliveries are postponed, so they are retried if the instance stops while sending them
+		deliveries, err := d.db.ClaimWebhookDeliveries(d.ctx, now, now.Add(d.timeout*2), claimBatchSize)
+		if err != nil {
+			log.Printf("[webhooks][err] %s", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		list, err := d.db.GetWebhooks(d.ctx)
+		if err != nil {
+			log.Printf("[webhooks][err] %s", err)
+			return
+		}
+		webhooks := make(map[int64]*database.Webhook, len(list))
+		for _, wh := range list {
+			webhooks[wh.ID] = wh
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, d.workers)
+		for _, delivery := range deliveries {
+			wh, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				// the webhook was deleted in the meantime
+				continue
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(wh *database.Webhook, delivery *database.WebhookDelivery) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				d.attempt(wh, delivery)
+			}(wh, delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < claimBatchSize {
+			return
+		}
+	}
+}
+
+// attempt sends a delivery once and stores the result.
+func (d *Dispatcher) attempt(wh *database.Webhook, delivery *database.WebhookDelivery) {
+	now := d.now()
+	status, err := d.send(wh, delivery, now)
+
+	delivery.Attempts++
+	delivery.LastAttempt = &now
+	delivery.ResponseStatus = nil
+	delivery.LastError = nil
+	if status > 0 {
+		delivery.ResponseStatus = &status
+	}
+	switch {
+	case err == nil:
+		delivery.Status = database.DeliveryStatusDelivered
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = database.DeliveryStatusFailed
+		log.Printf("[webhooks][warn] delivery %d to webhook %d failed %d times: %s", delivery.ID, wh.ID, delivery.Attempts, err)
+	default:
+		delivery.NextAttempt = now.Add(d.backoff(delivery.Attempts))
+	}
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		delivery.LastError = &msg
+	}
+
+	// the result is stored even if the dispatcher is closed
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+	if err := d.db.UpdateWebhookDelivery(ctx, *delivery); err != nil {
+		log.Printf("[webhooks][err] %s", err)
+	}
+}
+
+// send posts the signed payload to the webhook and returns the status code of the response.
+func (d *Dispatcher) send(wh *database.Webhook, delivery *database.WebhookDelivery, now time.Time) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, wh.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "phantomias-webhooks")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(wh.Secret, timestamp, payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		// the url isn't stored in the delivery log, it can contain credentials
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.minBackoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.maxBackoff {
+		delay = d.maxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) isSeen(id string) bool {
+	d.seenMu.Lock()
+	defer d.seenMu.Unlock()
+	_, ok := d.seen[id]
+	return ok
+}
+
+func (d *Dispatcher) markSeen(id string, created time.Time) {
+	d.seenMu.Lock()
+	defer d.seenMu.Unlock()
+	d.seen[id] = created
+}
+
+func (d *Dispatcher) pruneSeen(before time.Time) {
+	d.seenMu.Lock()
+	defer d.seenMu.Unlock()
+	for id, created := range d.seen {
+		if created.Before(before) {
+			delete(d.seen, id)
+		}
+	}
+}
+
+// matches returns true if the webhook subscribed the event.
+// Webhooks only receive events of blocks and payments which were created after the webhook.
+func matches(wh *database.Webhook, ev *Event) bool {
+	if ev.Created.Before(wh.Created) {
+		return false
+	}
+	if wh.PoolID != "" && wh.PoolID != ev.PoolID {
+		return false
+	}
+	if wh.Address != "" && !strings.EqualFold(wh.Address, ev.Address) {
+		return false
+	}
+	for _, e := range strings.Split(wh.Events, ",") {
+		if e == ev.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// blockEvents returns the found event of a block and the confirmed or orphaned event depending on its status.
+func blockEvents(b *database.Block) []*Event {
+	block := &Block{
+		Height:                      b.BlockHeight,
+		Hash:                        utils.ValueOrZero(b.Hash),
+		Status:                      b.Status,
+		Type:                        utils.ValueOrZero(b.Type),
+		ConfirmationProgress:        b.ConfirmationProgress,
+		Effort:                      utils.ValueOrZero(b.Effort),
+		TransactionConfirmationData: b.TransactionConfirmationData,
+		Miner:                       b.Miner,
+		Reward:                      b.Reward.InexactFloat64(),
+		Source:                      b.Source,
+		Created:                     b.Created,
+	}
+	types := []string{EventBlockFound}
+	switch database.BlockStatus(b.Status) {
+	case database.BlockStatusConfirmed:
+		types = append(types, EventBlockConfirmed)
+	case database.BlockStatusOrphaned:
+		types = append(types, EventBlockOrphaned)
+	}
+	events := make([]*Event, len(types))
+	for i, t := range types {
+		events[i] = &Event{
+			ID:      fmt.Sprintf("%s:%s:%d", t, b.PoolID, b.ID),
+			Type:    t,
+			PoolID:  b.PoolID,
+			Address: b.Miner,
+			Created: b.Created,
+			Block:   block,
+		}
+	}
+	return events
+}
+
+func paymentEvent(p *database.Payment) *Event {
+	return &Event{
+		ID:      fmt.Sprintf("%s:%s:%d", EventPaymentSent, p.PoolID, p.ID),
+		Type:    EventPaymentSent,
+		PoolID:  p.PoolID,
+		Address: p.Address,
+		Created: p.Created,
+		Payment: &Payment{
+			Coin:                        p.Coin,
+			Address:                     p.Address,
+			Amount:                      p.Amount.InexactFloat64(),
+			TransactionConfirmationData: p.TransactionConfirmationData,
+			Created:                     p.Created,
+		},
+	}
+}
@@ -0,0 +1,290 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/goccy/go-json"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDB struct {
+	mu         sync.Mutex
+	webhooks   []*database.Webhook
+	blocks     []*database.Block
+	payments   []*database.Payment
+	deliveries []*database.WebhookDelivery
+	pending    map[int64]bool
+}
+
+func (f *fakeDB) GetWebhooks(context.Context) ([]*database.Webhook, error) {
+	return f.webhooks, nil
+}
+
+func (f *fakeDB) GetBlocksSince(_ context.Context, since time.Time) ([]*database.Block, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var blocks []*database.Block
+	for _, b := range f.blocks {
+		if !b.Created.Before(since) || f.pending[b.ID] {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+func (f *fakeDB) GetPaymentsSince(context.Context, time.Time) ([]*database.Payment, error) {
+	return f.payments, nil
+}
+
+func (f *fakeDB) InsertWebhookDelivery(_ context.Context, delivery database.WebhookDelivery) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.deliveries {
+		if d.WebhookID == delivery.WebhookID && d.EventID == delivery.EventID {
+			return false, nil
+		}
+	}
+	delivery.ID = int64(len(f.deliveries) + 1)
+	delivery.Status = database.DeliveryStatusPending
+	f.deliveries = append(f.deliveries, &delivery)
+	return true, nil
+}
+
+func (f *fakeDB) ClaimWebhookDeliveries(_ context.Context, now, until time.Time, limit int) ([]*database.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var claimed []*database.WebhookDelivery
+	for _, d := range f.deliveries {
+		if d.Status == database.DeliveryStatusPending && !d.NextAttempt.After(now) && len(claimed) < limit {
+			d.NextAttempt = until
+			c := *d
+			claimed = append(claimed, &c)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeDB) UpdateWebhookDelivery(_ context.Context, delivery database.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	*f.deliveries[delivery.ID-1] = delivery
+	return nil
+}
+
+func (f *fakeDB) UpdateWebhookPendingBlocks(_ context.Context, pending, done []*database.Block) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil {
+		f.pending = make(map[int64]bool)
+	}
+	for _, b := range pending {
+		f.pending[b.ID] = true
+	}
+	for _, b := range done {
+		delete(f.pending, b.ID)
+	}
+	return nil
+}
+
+func (f *fakeDB) delivery(i int) database.WebhookDelivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.deliveries[i]
+}
+
+func TestDispatcher(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var (
+		mu       sync.Mutex
+		fail     bool
+		received []*Event
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if !Verify("secret", r.Header.Get(HeaderSignature), ts, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var ev Event
+		json.Unmarshal(body, &ev)
+		assert.Equal(t, ev.Type, r.Header.Get(HeaderEvent))
+		received = append(received, &ev)
+	}))
+	defer srv.Close()
+
+	db := &fakeDB{
+		webhooks: []*database.Webhook{
+			{ID: 1, URL: srv.URL, Secret: "secret", Events: "block.confirmed,block.found", Created: now.Add(-time.Hour)},
+			{ID: 2, PoolID: "eth1", Address: "0xABC", URL: srv.URL, Secret: "secret", Events: "payment.sent", Created: now.Add(-time.Hour)},
+			{ID: 3, PoolID: "eth2", URL: srv.URL, Secret: "secret", Events: "payment.sent", Created: now.Add(-time.Hour)},
+		},
+		blocks: []*database.Block{
+			{ID: 1, PoolID: "eth1", BlockHeight: 100, Status: "confirmed", Miner: "0xabc", Reward: decimal.NewFromInt(2), Created: now.Add(-time.Minute * 30)},
+			{ID: 2, PoolID: "eth1", BlockHeight: 101, Status: "pending", Miner: "0xdef", Created: now.Add(-time.Minute * 10)},
+			// found before the webhook was created
+			{ID: 3, PoolID: "eth1", BlockHeight: 99, Status: "confirmed", Miner: "0xabc", Created: now.Add(-time.Hour * 2)},
+		},
+		payments: []*database.Payment{
+			{ID: 1, PoolID: "eth1", Coin: "ETH", Address: "0xabc", Amount: decimal.NewFromFloat(0.5), Created: now.Add(-time.Minute)},
+			{ID: 2, PoolID: "eth1", Coin: "ETH", Address: "0xdef", Amount: decimal.NewFromFloat(0.5), Created: now.Add(-time.Minute)},
+		},
+	}
+	d := New(db,
+		WithAllowPrivate(true),
+		WithMaxAttempts(2),
+		WithBackoff(time.Minute, time.Hour),
+		WithClock(func() time.Time { return now }),
+	)
+	defer d.Close()
+
+	d.Poll()
+	require.Len(t, db.deliveries, 4)
+	ids := make([]string, len(db.deliveries))
+	for i, delivery := range db.deliveries {
+		ids[i] = delivery.EventID
+	}
+	assert.ElementsMatch(t, []string{
+		"block.found:eth1:1", "block.confirmed:eth1:1", "block.found:eth1:2", "payment.sent:eth1:1",
+	}, ids)
+
+	// events are only queued once
+	d.Poll()
+	assert.Len(t, db.deliveries, 4)
+
+	d.Deliver()
+	require.Len(t, received, 4)
+	for i := range db.deliveries {
+		delivery := db.delivery(i)
+		assert.Equal(t, database.DeliveryStatusDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		require.NotNil(t, delivery.ResponseStatus)
+		assert.Equal(t, http.StatusOK, *delivery.ResponseStatus)
+	}
+
+	// failed deliveries are retried with backoff and moved to the dead letters
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	db.payments = append(db.payments, &database.Payment{ID: 3, PoolID: "eth1", Address: "0xabc", Created: now})
+	d.Poll()
+	require.Len(t, db.deliveries, 5)
+	d.Deliver()
+	delivery := db.delivery(4)
+	assert.Equal(t, database.DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, now.Add(time.Minute), delivery.NextAttempt)
+	require.NotNil(t, delivery.LastError)
+	assert.Equal(t, "unexpected status code 502", *delivery.LastError)
+
+	now = now.Add(time.Minute)
+	d.Deliver()
+	delivery = db.delivery(4)
+	assert.Equal(t, database.DeliveryStatusFailed, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+}
+
+func TestDispatcherPendingBlocks(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	db := &fakeDB{
+		webhooks: []*database.Webhook{
+			{ID: 1, URL: "https://example.com/hook", Secret: "secret", Events: "block.found,block.confirmed,block.orphaned", Created: now.Add(-time.Hour)},
+		},
+		blocks: []*database.Block{
+			{ID: 1, PoolID: "eth1", BlockHeight: 100, Status: "pending", Miner: "0xabc", Created: now.Add(-time.Minute)},
+			{ID: 2, PoolID: "eth1", BlockHeight: 101, Status: "confirmed", Miner: "0xabc", Created: now.Add(-time.Minute)},
+		},
+	}
+	d := New(db, WithLookback(time.Hour), WithClock(func() time.Time { return now }))
+	defer d.Close()
+
+	d.Poll()
+	require.Len(t, db.deliveries, 3)
+	assert.Equal(t, map[int64]bool{1: true}, db.pending)
+
+	// the block is confirmed after the lookback
+	now = now.Add(time.Hour * 2)
+	db.blocks[0].Status = "confirmed"
+	d.Poll()
+	require.Len(t, db.deliveries, 4)
+	assert.Equal(t, "block.confirmed:eth1:1", db.delivery(3).EventID)
+	assert.Empty(t, db.pending)
+
+	// a restarted dispatcher doesn't know the tracked blocks of the previous run
+	db.blocks = append(db.blocks, &database.Block{ID: 3, PoolID: "eth1", BlockHeight: 102, Status: "pending", Miner: "0xabc", Created: now.Add(-time.Minute)})
+	d.Poll()
+	assert.Equal(t, map[int64]bool{3: true}, db.pending)
+	d = New(db, WithLookback(time.Hour), WithClock(func() time.Time { return now }))
+	defer d.Close()
+	now = now.Add(time.Hour * 2)
+	db.blocks[2].Status = "orphaned"
+	d.Poll()
+	require.Len(t, db.deliveries, 6)
+	assert.Equal(t, "block.orphaned:eth1:3", db.delivery(5).EventID)
+	assert.Empty(t, db.pending)
+}
+
+func TestDispatcherPrivateTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	now := time.Now()
+	db := &fakeDB{
+		webhooks: []*database.Webhook{{ID: 1, URL: srv.URL, Secret: "secret", Events: "payment.sent"}},
+		payments: []*database.Payment{{ID: 1, PoolID: "eth1", Address: "0xabc", Created: now}},
+	}
+	d := New(db, WithClock(func() time.Time { return now }))
+	defer d.Close()
+
+	d.Poll()
+	d.Deliver()
+	delivery := db.delivery(0)
+	assert.Equal(t, database.DeliveryStatusPending, delivery.Status)
+	require.NotNil(t, delivery.LastError)
+	assert.Contains(t, *delivery.LastError, ErrPrivateTarget.Error())
+}
+
+func TestBackoff(t *testing.T) {
+	d := New(nil, WithBackoff(time.Second*30, time.Minute*5))
+	assert.Equal(t, time.Second*30, d.backoff(1))
+	assert.Equal(t, time.Minute, d.backoff(2))
+	assert.Equal(t, time.Minute*4, d.backoff(4))
+	assert.Equal(t, time.Minute*5, d.backoff(5))
+	assert.Equal(t, time.Minute*5, d.backoff(50))
+}
+
+func TestOnMessage(t *testing.T) {
+	d := New(nil)
+	d.OnMessage([]byte(`{"type":"hashratechanged"}`))
+	assert.Len(t, d.trigger, 0)
+	d.OnMessage([]byte(`{"type":"blockfound","poolId":"eth1"}`))
+	d.OnMessage([]byte(`{"type":"payment","poolId":"eth1"}`))
+	assert.Len(t, d.trigger, 1)
+}
+
+func TestDispatcherValidateURL(t *testing.T) {
+	d := New(nil)
+	assert.NoError(t, d.ValidateURL("https://example.com/hook"))
+	assert.ErrorIs(t, d.ValidateURL("http://localhost:8080/hook"), ErrPrivateTarget)
+	assert.ErrorIs(t, d.ValidateURL("http://192.168.1.10/hook"), ErrPrivateTarget)
+	assert.ErrorIs(t, d.ValidateURL("http://[::1]/hook"), ErrPrivateTarget)
+	assert.ErrorIs(t, d.ValidateURL("ftp://example.com"), ErrInvalidURL)
+	assert.NoError(t, New(nil, WithAllowPrivate(true)).ValidateURL("http://localhost:8080/hook"))
+}
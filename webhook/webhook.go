@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// types of the events
+const (
+	EventBlockFound     = "block.found"
+	EventBlockConfirmed = "block.confirmed"
+	EventBlockOrphaned  = "block.orphaned"
+	EventPaymentSent    = "payment.sent"
+)
+
+// Events are all event types which can be subscribed.
+var Events = []string{EventBlockFound, EventBlockConfirmed, EventBlockOrphaned, EventPaymentSent}
+
+// headers of a delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	signaturePrefix = "sha256="
+	secretBytes     = 32
+)
+
+var (
+	// ErrInvalidURL is returned if the url of a webhook isn't an absolute http or https url
+	ErrInvalidURL = errors.New("webhooks require a http or https url")
+	// ErrInvalidEvents is returned if a webhook has no or unknown event types
+	ErrInvalidEvents = errors.New("invalid or missing webhook events")
+	// ErrPrivateTarget is returned if a webhook points to a private network
+	ErrPrivateTarget = errors.New("webhooks to private networks are not allowed")
+)
+
+// Event is a pool event which is sent to webhooks.
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	PoolID  string    `json:"poolId"`
+	Address string    `json:"address"` // miner who found the block or recipient of the payment
+	Created time.Time `json:"created"`
+	Block   *Block    `json:"block,omitempty"`
+	Payment *Payment  `json:"payment,omitempty"`
+}
+
+// Block is the block of a block event.
+type Block struct {
+	Height                      int64     `json:"blockHeight"`
+	Hash                        string    `json:"hash,omitempty"`
+	Status                      string    `json:"status"`
+	Type                        string    `json:"type,omitempty"`
+	ConfirmationProgress        float64   `json:"confirmationProgress"`
+	Effort                      float64   `json:"effort"`
+	TransactionConfirmationData string    `json:"transactionConfirmationData"`
+	Miner                       string    `json:"miner"`
+	Reward                      float64   `json:"reward"`
+	Source                      string    `json:"source,omitempty"`
+	Created                     time.Time `json:"created"`
+}
+
+// Payment is the payment of a payment event.
+type Payment struct {
+	Coin                        string    `json:"coin"`
+	Address                     string    `json:"address"`
+	Amount                      float64   `json:"amount"`
+	TransactionConfirmationData string    `json:"transactionConfirmationData"`
+	Created                     time.Time `json:"created"`
+}
+
+// Sign returns the signature of a payload which was sent at the given unix timestamp.
+// It is the hex encoded HMAC-SHA256 of "<timestamp>.<payload>" with the secret of the webhook.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature of the payload is valid.
+func Verify(secret, signature string, timestamp int64, payload []byte) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
+
+// NewSecret returns a random secret for the signatures of a webhook.
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidateURL checks that the url is an absolute http or https url.
+func ValidateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// ParseEvents validates the event types and returns them as a sorted, comma separated list.
+func ParseEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", ErrInvalidEvents
+	}
+	set := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if !isEvent(e) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidEvents, e)
+		}
+		set[e] = struct{}{}
+	}
+	list := make([]string, 0, len(set))
+	for e := range set {
+		list = append(list, e)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ","), nil
+}
+
+func isEvent(e string) bool {
+	for _, v := range Events {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
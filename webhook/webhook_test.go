@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"id":"block.found:eth1:1"}`)
+	sig := Sign("secret", 1664625600, payload)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.True(t, Verify("secret", sig, 1664625600, payload))
+	assert.False(t, Verify("other", sig, 1664625600, payload))
+	assert.False(t, Verify("secret", sig, 1664625601, payload))
+	assert.False(t, Verify("secret", sig, 1664625600, []byte(`{}`)))
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := ParseEvents([]string{"payment.sent", " Block.Found", "payment.sent"})
+	require.NoError(t, err)
+	assert.Equal(t, "block.found,payment.sent", events)
+
+	_, err = ParseEvents(nil)
+	assert.ErrorIs(t, err, ErrInvalidEvents)
+	_, err = ParseEvents([]string{"block.found", "block.unknown"})
+	assert.ErrorIs(t, err, ErrInvalidEvents)
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://example.com/hook"))
+	assert.NoError(t, ValidateURL("http://example.com:8080"))
+	assert.ErrorIs(t, ValidateURL("ftp://example.com"), ErrInvalidURL)
+	assert.ErrorIs(t, ValidateURL("/hook"), ErrInvalidURL)
+	assert.ErrorIs(t, ValidateURL("://"), ErrInvalidURL)
+}
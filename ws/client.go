@@ -1,74 +1,219 @@
 package ws
 
 import (
+	"context"
 	"log"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/1oopio/phantomias/recws"
 	"github.com/gorilla/websocket"
+	"github.com/jpillora/backoff"
 )
 
+const (
+	keepAliveTimeout    = time.Second * 15
+	dialIntervalMin     = time.Second * 2
+	dialIntervalMax     = time.Second * 30
+	dialIntervalFactor  = 1.5
+	closeGracePeriod    = time.Second
+	stateChangeChanSize = 16
+)
+
+// StateChange is emitted whenever the connection state of an upstream changes.
+type StateChange struct {
+	Upstream string
+	recws.StateEvent
+}
+
 type Client struct {
-	url       string
-	ws        recws.RecConn
-	broadcast chan []byte
+	name     string
+	url      string
+	ws       recws.RecConn
+	messages chan<- *message
+	changes  chan<- StateChange
+
+	mu          sync.RWMutex
+	ctx         context.Context
+	state       recws.State
+	since       time.Time
+	lastError   error
+	lastMessage time.Time
+	received    uint64
+	duplicates  uint64
+	connects    uint64
 }
 
-func New(url string, broadcast chan []byte) *Client {
-	return &Client{
-		url:       url,
-		broadcast: broadcast,
-	}
+// message is a message received from an upstream.
+type message struct {
+	client *Client
+	data   []byte
 }
 
-func (c *Client) Close() {
-	c.ws.Close(true)
+func newClient(name, url string, messages chan<- *message, changes chan<- StateChange) *Client {
+	return &Client{
+		name:     name,
+		url:      url,
+		messages: messages,
+		changes:  changes,
+		state:    recws.StateClosed,
+		since:    time.Now(),
+	}
 }
 
-func (c *Client) Listen(doneC <-chan os.Signal) error {
-	defer func() { recover() }()
+// Run connects to the upstream and relays its messages until the context is done.
+// The initial connection is retried with a backoff, reconnects are handled by recws.
+// An error is only returned if the client can't be set up at all.
+func (c *Client) Run(ctx context.Context) error {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
 
 	ws, err := recws.New(
 		c.url, nil,
-		recws.WithKeepAliveTimeout(time.Second*15),
+		recws.WithKeepAliveTimeout(keepAliveTimeout),
+		recws.WithReconnectIntervalMin(dialIntervalMin),
+		recws.WithReconnectIntervalMax(dialIntervalMax),
+		recws.WithReconnectIntervalFactor(dialIntervalFactor),
+		recws.WithOnStateChange(c.onStateChange),
 		recws.WithDebugLogFn(func(s string) {
-			log.Printf("[recws][debug] %s\n", s)
+			log.Printf("[recws][%s][debug] %s\n", c.name, s)
 		}),
 		recws.WithErrorLogFn(func(err error, s string) {
-			log.Printf("[recws][err] %s: %s\n", s, err)
+			log.Printf("[recws][%s][err] %s: %s\n", c.name, s, err)
 		}),
 	)
 	if err != nil {
 		return err
 	}
+	ws.OnMessage(c.handleMessage)
+	c.mu.Lock()
 	c.ws = ws
-	if err := c.ws.Dial(); err != nil {
+	c.mu.Unlock()
+
+	// unblock pending reads once the context is done
+	go func() {
+		<-ctx.Done()
+		c.ws.Shutdown(closeGracePeriod)
+		c.ws.Close(true)
+	}()
+
+	if err := c.dial(ctx); err != nil {
+		return nil // context is done
+	}
+	if err := c.ws.Listen(ctx); err != nil && ctx.Err() == nil {
 		return err
 	}
-	c.relayMessages(doneC)
 	return nil
 }
 
-func (c *Client) relayMessages(doneC <-chan os.Signal) {
+// dial establishes the initial connection and retries with a backoff until it succeeds.
+func (c *Client) dial(ctx context.Context) error {
+	b := backoff.Backoff{
+		Min:    dialIntervalMin,
+		Max:    dialIntervalMax,
+		Factor: dialIntervalFactor,
+		Jitter: true,
+	}
 	for {
+		err := c.ws.DialContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		wait := b.Duration()
+		log.Printf("[wsclient][%s][err] failed to connect, will try again in %s: %s", c.name, wait, err)
+
 		select {
-		case <-doneC:
-			return
-		default:
-			if !c.ws.IsConnected() {
-				continue
-			}
-			mtype, message, err := c.ws.ReadMessage()
-			if err != nil {
-				log.Printf("[wsclient][err] failed to read message: %s", err)
-				continue
-			}
-			if mtype != websocket.TextMessage {
-				log.Printf("[wsclient][warn] wont relay non-text message: %d", mtype)
-				continue
-			}
-			c.broadcast <- message
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
 		}
 	}
 }
+
+func (c *Client) onStateChange(ev recws.StateEvent) {
+	c.mu.Lock()
+	c.state = ev.To
+	c.since = ev.Time
+	if ev.Err != nil {
+		c.lastError = ev.Err
+	}
+	if ev.To == recws.StateConnected {
+		c.connects++
+	}
+	c.mu.Unlock()
+
+	if c.changes == nil {
+		return
+	}
+	select {
+	case c.changes <- StateChange{Upstream: c.name, StateEvent: ev}:
+	default: // never block the connection on slow consumers
+	}
+}
+
+func (c *Client) handleMessage(mtype int, data []byte) {
+	if mtype != websocket.TextMessage {
+		log.Printf("[wsclient][%s][warn] wont relay non-text message: %d", c.name, mtype)
+		return
+	}
+	c.mu.Lock()
+	c.lastMessage = time.Now()
+	c.received++
+	ctx := c.ctx
+	c.mu.Unlock()
+
+	select {
+	case c.messages <- &message{client: c, data: data}:
+	case <-ctx.Done():
+	}
+}
+
+func (c *Client) duplicate() {
+	c.mu.Lock()
+	c.duplicates++
+	c.mu.Unlock()
+}
+
+// Status returns the current status of the client.
+func (c *Client) Status() *Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s := &Status{
+		Name:       c.name,
+		URL:        c.url,
+		State:      c.state,
+		Connected:  c.state == recws.StateConnected,
+		Since:      c.since,
+		Received:   c.received,
+		Duplicates: c.duplicates,
+	}
+	if c.connects > 1 {
+		s.Reconnects = c.connects - 1
+	}
+	if c.lastError != nil {
+		s.LastError = c.lastError.Error()
+	}
+	if !c.lastMessage.IsZero() {
+		lastMessage := c.lastMessage
+		s.LastMessage = &lastMessage
+	}
+	return s
+}
+
+// Status represents the status of an upstream connection.
+type Status struct {
+	Name        string
+	URL         string
+	State       recws.State
+	Connected   bool
+	Since       time.Time
+	LastMessage *time.Time
+	LastError   string
+	Received    uint64
+	Duplicates  uint64
+	Reconnects  uint64
+}
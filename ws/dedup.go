@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// notification contains the fields of a miningcore notification
+// which identify an event across multiple miningcore instances and the fields of shares.
+type notification struct {
+	Type        string   `json:"type"`
+	PoolID      string   `json:"poolId"`
+	BlockHeight *int64   `json:"blockHeight"`
+	BlockHash   string   `json:"blockHash"`
+	Status      string   `json:"status"`
+	TxIDs       []string `json:"txIds"`
+	Miner       string   `json:"miner"`
+	Worker      string   `json:"worker"`
+	Created     string   `json:"created"`
+}
+
+// decodeNotification decodes the fields which are used by the relay, it returns nil for invalid messages.
+func decodeNotification(data []byte) *notification {
+	var n notification
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil
+	}
+	return &n
+}
+
+// eventKey returns a key which is identical for the same event reported by different upstreams.
+// Events without a stable identity are keyed by their raw content.
+func eventKey(n *notification, data []byte) string {
+	if n != nil {
+		switch strings.ToLower(n.Type) {
+		case EventBlockFound, EventNewChainHeight:
+			if n.BlockHeight != nil {
+				return fmt.Sprintf("%s:%s:%d", n.Type, n.PoolID, *n.BlockHeight)
+			}
+		case EventBlockUnlocked:
+			if n.BlockHeight != nil {
+				return fmt.Sprintf("%s:%s:%d:%s:%s", n.Type, n.PoolID, *n.BlockHeight, n.BlockHash, n.Status)
+			}
+		case EventPayment:
+			if len(n.TxIDs) > 0 {
+				return fmt.Sprintf("%s:%s:%s", n.Type, n.PoolID, strings.Join(n.TxIDs, ","))
+			}
+		case EventShare:
+			if n.Created != "" {
+				return fmt.Sprintf("%s:%s:%s:%s:%s:%s", n.Type, n.PoolID, n.Miner, n.Worker, n.Created, n.Status)
+			}
+		}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// deduplicator remembers events for a given window.
+type deduplicator struct {
+	window    time.Duration
+	seen      map[string]time.Time
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// isDuplicate returns true if the event was already seen within the window.
+func (d *deduplicator) isDuplicate(n *notification, data []byte) bool {
+	if d.window <= 0 {
+		return false
+	}
+	key := eventKey(n, data)
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastPrune) > d.window {
+		for k, t := range d.seen {
+			if now.Sub(t) > d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastPrune = now
+	}
+
+	if t, ok := d.seen[key]; ok && now.Sub(t) <= d.window {
+		return true
+	}
+	d.seen[key] = now
+	return false
+}
@@ -0,0 +1,51 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventKey(t *testing.T) {
+	eventKey := func(data []byte) string {
+		return eventKey(decodeNotification(data), data)
+	}
+	eu := []byte(`{"type":"blockfound","poolId":"eth1","blockHeight":15000000,"miner":"0x1","source":"eu"}`)
+	us := []byte(`{"type":"blockfound","poolId":"eth1","blockHeight":15000000,"miner":"0x1","source":"us"}`)
+	assert.Equal(t, eventKey(eu), eventKey(us))
+
+	other := []byte(`{"type":"blockfound","poolId":"eth1","blockHeight":15000001,"miner":"0x1"}`)
+	assert.NotEqual(t, eventKey(eu), eventKey(other))
+
+	hr1 := []byte(`{"type":"hashrateupdated","poolId":"eth1","hashrate":100,"miner":"0x1","worker":"rig1"}`)
+	hr2 := []byte(`{"type":"hashrateupdated","poolId":"eth1","hashrate":101,"miner":"0x1","worker":"rig1"}`)
+	assert.NotEqual(t, eventKey(hr1), eventKey(hr2))
+
+	// the same share relayed by multiple upstreams
+	share1 := []byte(`{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"accepted","created":"2022-06-01T12:30:00.123Z","source":"eu"}`)
+	share2 := []byte(`{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"accepted","created":"2022-06-01T12:30:00.123Z","source":"us"}`)
+	share3 := []byte(`{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"accepted","created":"2022-06-01T12:30:00.456Z"}`)
+	assert.Equal(t, eventKey(share1), eventKey(share2))
+	assert.NotEqual(t, eventKey(share1), eventKey(share3))
+
+	invalid := []byte(`not json`)
+	assert.Equal(t, eventKey(invalid), eventKey(invalid))
+}
+
+func TestDeduplicator(t *testing.T) {
+	d := newDeduplicator(time.Millisecond * 50)
+	msg := []byte(`{"type":"payment","poolId":"eth1","txIds":["0xabc"]}`)
+	assert.False(t, d.isDuplicate(decodeNotification(msg), msg))
+	assert.True(t, d.isDuplicate(decodeNotification(msg), msg))
+
+	time.Sleep(time.Millisecond * 60)
+	assert.False(t, d.isDuplicate(decodeNotification(msg), msg))
+}
+
+func TestDeduplicatorDisabled(t *testing.T) {
+	d := newDeduplicator(0)
+	msg := []byte(`{"type":"newchainheight","poolId":"eth1","blockHeight":1}`)
+	assert.False(t, d.isDuplicate(decodeNotification(msg), msg))
+	assert.False(t, d.isDuplicate(decodeNotification(msg), msg))
+}
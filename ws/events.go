@@ -0,0 +1,14 @@
+package ws
+
+// Types of the notifications which miningcore sends on its websocket.
+const (
+	EventBlockFound          = "blockfound"
+	EventBlockUnlocked       = "blockunlocked"
+	EventBlockUnlockProgress = "blockunlockprogress"
+	EventNewChainHeight      = "newchainheight"
+	EventPayment             = "payment"
+	EventHashrateUpdated     = "hashrateupdated"
+
+	// EventShare isn't sent by stock miningcore, see ShareStats.
+	EventShare = "share"
+)
@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Relay merges the notifications of multiple miningcore upstreams
+// and forwards them deduplicated to a broadcast channel.
+type Relay struct {
+	clients  []*Client
+	messages chan *message
+	changes  chan StateChange
+	dedup    *deduplicator
+	shares   *ShareStats
+
+	mu              sync.RWMutex
+	stateHandlers   []func(StateChange)
+	messageHandlers []func([]byte)
+}
+
+// NewRelay creates a new relay.
+// Identical events received within the dedupWindow are forwarded once.
+func NewRelay(dedupWindow time.Duration) *Relay {
+	return &Relay{
+		messages: make(chan *message),
+		changes:  make(chan StateChange, stateChangeChanSize),
+		dedup:    newDeduplicator(dedupWindow),
+	}
+}
+
+// AddUpstream adds a miningcore websocket server to the relay.
+// It must be called before Start.
+func (r *Relay) AddUpstream(name, url string) {
+	r.clients = append(r.clients, newClient(name, url, r.messages, r.changes))
+}
+
+// CountShares counts the share notifications of all upstreams instead of relaying them.
+// Shares are counted after the deduplication. It must be called before Start.
+func (r *Relay) CountShares(s *ShareStats) {
+	r.shares = s
+}
+
+// OnStateChange registers a handler which is called whenever the connection state of an upstream changes.
+func (r *Relay) OnStateChange(fn func(StateChange)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stateHandlers = append(r.stateHandlers, fn)
+}
+
+// OnMessage registers a handler which is called for every relayed message, after deduplication.
+// Handlers are called sequentially before the message is broadcasted and must not block.
+func (r *Relay) OnMessage(fn func([]byte)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messageHandlers = append(r.messageHandlers, fn)
+}
+
+// Start connects to all upstreams and relays their messages
+// to the broadcast channel until the context is done.
+func (r *Relay) Start(ctx context.Context, broadcast chan<- []byte) {
+	for _, c := range r.clients {
+		go func(c *Client) {
+			if err := c.Run(ctx); err != nil {
+				log.Printf("[wsrelay][err] failed to start the websocket client for %s: %s", c.name, err)
+			}
+		}(c)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case change := <-r.changes:
+			r.handleStateChange(change)
+
+		case msg := <-r.messages:
+			n := decodeNotification(msg.data)
+			if r.dedup.isDuplicate(n, msg.data) {
+				msg.client.duplicate()
+				continue
+			}
+			// shares are counted instead of relayed
+			if r.shares != nil && r.shares.count(n) {
+				continue
+			}
+			r.handleMessage(msg.data)
+			select {
+			case broadcast <- msg.data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (r *Relay) handleStateChange(change StateChange) {
+	if change.Err != nil {
+		log.Printf("[wsrelay] upstream %s changed from %s to %s: %s", change.Upstream, change.From, change.To, change.Err)
+	} else {
+		log.Printf("[wsrelay] upstream %s changed from %s to %s", change.Upstream, change.From, change.To)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, fn := range r.stateHandlers {
+		fn(change)
+	}
+}
+
+func (r *Relay) handleMessage(data []byte) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, fn := range r.messageHandlers {
+		fn(data)
+	}
+}
+
+// Status returns the status of all upstreams.
+func (r *Relay) Status() []*Status {
+	status := make([]*Status, len(r.clients))
+	for i, c := range r.clients {
+		status[i] = c.Status()
+	}
+	return status
+}
@@ -0,0 +1,207 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+)
+
+const (
+	defaultShareStatsInterval = time.Minute
+	shareStatsPruneInterval   = time.Hour
+)
+
+// status of a share notification
+const (
+	shareAccepted = "accepted"
+	shareRejected = "rejected"
+	shareStale    = "stale"
+)
+
+// ShareStatsDB stores the counted shares.
+type ShareStatsDB interface {
+	AddShareStats(ctx context.Context, stats []*database.ShareStats) error
+	DeleteShareStatsBefore(ctx context.Context, before time.Time) error
+}
+
+// ShareStatsOpts is a function that can be passed to NewShareStats to configure the share counter
+type ShareStatsOpts func(s *ShareStats)
+
+// WithShareStatsContext sets the context to use for the share counter
+func WithShareStatsContext(ctx context.Context) ShareStatsOpts {
+	return func(s *ShareStats) {
+		s.parentCtx = ctx
+	}
+}
+
+// WithShareStatsInterval sets the interval in which the counts are persisted
+func WithShareStatsInterval(interval time.Duration) ShareStatsOpts {
+	return func(s *ShareStats) {
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+// WithShareStatsRetention sets the duration after which the counts are deleted, 0 keeps them forever
+func WithShareStatsRetention(retention time.Duration) ShareStatsOpts {
+	return func(s *ShareStats) {
+		s.retention = retention
+	}
+}
+
+type shareKey struct {
+	pool, miner, worker string
+	hour                time.Time
+}
+
+// ShareStats counts the accepted, rejected and stale shares per worker from the share notifications of the upstreams
+// and adds them to the database in an interval.
+//
+// Stock miningcore doesn't relay shares on its websocket, the upstreams have to publish every submitted share as:
+//
+//	{"type":"share","poolId":"eth1","miner":"0x…","worker":"rig1","status":"accepted","created":"2022-06-01T12:30:00.123Z"}
+//
+// The status is one of accepted, rejected or stale, notifications with another status are ignored.
+// The created time tells the shares of a worker apart, so a share relayed by multiple upstreams is counted once.
+type ShareStats struct {
+	parentCtx context.Context
+	ctx       context.Context
+	cancel    context.CancelFunc
+	db        ShareStatsDB
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+	lastPrune time.Time
+
+	mu      sync.Mutex
+	pending map[shareKey]*database.ShareStats
+
+	flushMu sync.Mutex
+}
+
+// NewShareStats creates a new share counter.
+func NewShareStats(db ShareStatsDB, opts ...ShareStatsOpts) *ShareStats {
+	s := &ShareStats{
+		parentCtx: context.Background(),
+		db:        db,
+		interval:  defaultShareStatsInterval,
+		now:       time.Now,
+		pending:   make(map[shareKey]*database.ShareStats),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.cancel = context.WithCancel(s.parentCtx)
+	return s
+}
+
+// Start persists the counts in the interval until the share counter is closed.
+func (s *ShareStats) Start() {
+	log.Printf("[sharestats] starting with interval %s", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(s.ctx)
+			s.prune(s.ctx)
+		case <-s.ctx.Done():
+			// persist the remaining counts on shutdown
+			ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+			s.Flush(ctx)
+			cancel()
+			return
+		}
+	}
+}
+
+// Close stops the share counter.
+func (s *ShareStats) Close() {
+	s.cancel()
+}
+
+// count counts the notification if it is a share and returns true in that case.
+func (s *ShareStats) count(n *notification) bool {
+	if n == nil || !strings.EqualFold(n.Type, EventShare) {
+		return false
+	}
+	status := strings.ToLower(n.Status)
+	if n.PoolID == "" || n.Miner == "" || (status != shareAccepted && status != shareRejected && status != shareStale) {
+		return true
+	}
+
+	key := shareKey{pool: n.PoolID, miner: n.Miner, worker: n.Worker, hour: s.now().UTC().Truncate(time.Hour)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.pending[key]
+	if !ok {
+		stats = &database.ShareStats{PoolID: n.PoolID, Miner: n.Miner, Worker: n.Worker, Created: key.hour}
+		s.pending[key] = stats
+	}
+	switch status {
+	case shareAccepted:
+		stats.Accepted++
+	case shareRejected:
+		stats.Rejected++
+	case shareStale:
+		stats.Stale++
+	}
+	return true
+}
+
+// Flush adds the pending counts to the database, they are kept for the next attempt if that fails.
+func (s *ShareStats) Flush(ctx context.Context) {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	pending := s.pending
+	s.pending = make(map[shareKey]*database.ShareStats)
+	s.mu.Unlock()
+
+	stats := make([]*database.ShareStats, 0, len(pending))
+	for _, st := range pending {
+		stats = append(stats, st)
+	}
+	if err := s.db.AddShareStats(ctx, stats); err != nil {
+		log.Printf("[sharestats][err] %s", err)
+		s.merge(pending)
+	}
+}
+
+// merge adds counts which couldn't be persisted back to the pending counts.
+func (s *ShareStats) merge(counts map[shareKey]*database.ShareStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, st := range counts {
+		if p, ok := s.pending[key]; ok {
+			p.Accepted += st.Accepted
+			p.Rejected += st.Rejected
+			p.Stale += st.Stale
+			continue
+		}
+		s.pending[key] = st
+	}
+}
+
+// prune deletes the counts which are older than the retention, at most once per prune interval.
+func (s *ShareStats) prune(ctx context.Context) {
+	now := s.now()
+	if s.retention <= 0 || now.Sub(s.lastPrune) < shareStatsPruneInterval {
+		return
+	}
+	if err := s.db.DeleteShareStatsBefore(ctx, now.UTC().Add(-s.retention)); err != nil {
+		log.Printf("[sharestats][err] %s", err)
+		return
+	}
+	s.lastPrune = now
+}
@@ -0,0 +1,142 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1oopio/phantomias/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeShareStatsDB struct {
+	err    error
+	added  []*database.ShareStats
+	before time.Time
+}
+
+func (f *fakeShareStatsDB) AddShareStats(_ context.Context, stats []*database.ShareStats) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.added = append(f.added, stats...)
+	return nil
+}
+
+func (f *fakeShareStatsDB) DeleteShareStatsBefore(_ context.Context, before time.Time) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.before = before
+	return nil
+}
+
+func count(s *ShareStats, data string) bool {
+	return s.count(decodeNotification([]byte(data)))
+}
+
+func TestShareStatsCount(t *testing.T) {
+	db := &fakeShareStatsDB{}
+	s := NewShareStats(db)
+	now := time.Date(2022, 6, 1, 12, 30, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"accepted"}`))
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"Accepted"}`))
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"stale"}`))
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"rejected"}`))
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig2","status":"stale"}`))
+	// shares with an unknown status aren't relayed, but they aren't counted either
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","accepted":false}`))
+	assert.True(t, count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"invalid"}`))
+	assert.False(t, count(s, `{"type":"blockfound","poolId":"eth1","miner":"0x1"}`))
+	assert.False(t, count(s, `not json`))
+
+	s.Flush(context.Background())
+	assert.Len(t, db.added, 2)
+	byWorker := make(map[string]*database.ShareStats)
+	for _, st := range db.added {
+		byWorker[st.Worker] = st
+	}
+	assert.Equal(t, &database.ShareStats{PoolID: "eth1", Miner: "0x1", Worker: "rig1", Created: now.Truncate(time.Hour), Accepted: 2, Rejected: 1, Stale: 1}, byWorker["rig1"])
+	assert.Equal(t, int64(1), byWorker["rig2"].Stale)
+
+	// nothing pending
+	db.added = nil
+	s.Flush(context.Background())
+	assert.Empty(t, db.added)
+}
+
+func TestShareStatsFlushFailed(t *testing.T) {
+	db := &fakeShareStatsDB{err: errors.New("db down")}
+	s := NewShareStats(db)
+	count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"accepted"}`)
+	s.Flush(context.Background())
+
+	// the counts are kept for the next attempt
+	count(s, `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"rejected"}`)
+	db.err = nil
+	s.Flush(context.Background())
+	if assert.Len(t, db.added, 1) {
+		assert.Equal(t, int64(1), db.added[0].Accepted)
+		assert.Equal(t, int64(1), db.added[0].Rejected)
+	}
+}
+
+func TestShareStatsPrune(t *testing.T) {
+	db := &fakeShareStatsDB{}
+	now := time.Date(2022, 6, 1, 12, 30, 0, 0, time.UTC)
+	s := NewShareStats(db, WithShareStatsRetention(time.Hour*24))
+	s.now = func() time.Time { return now }
+
+	s.prune(context.Background())
+	assert.Equal(t, now.Add(-time.Hour*24), db.before)
+
+	// pruned at most once per interval
+	now = now.Add(time.Minute)
+	s.prune(context.Background())
+	assert.Equal(t, now.Add(-time.Minute-time.Hour*24), db.before)
+	now = now.Add(time.Hour)
+	s.prune(context.Background())
+	assert.Equal(t, now.Add(-time.Hour*24), db.before)
+
+	// a retention of 0 keeps the counts
+	db.before = time.Time{}
+	s = NewShareStats(db)
+	s.prune(context.Background())
+	assert.True(t, db.before.IsZero())
+}
+
+func TestRelayCountsSharesAfterDeduplication(t *testing.T) {
+	db := &fakeShareStatsDB{}
+	s := NewShareStats(db)
+	r := NewRelay(time.Minute)
+	r.CountShares(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broadcast := make(chan []byte)
+	go r.Start(ctx, broadcast)
+
+	eu, us := &Client{name: "eu"}, &Client{name: "us"}
+	share := `{"type":"share","poolId":"eth1","miner":"0x1","worker":"rig1","status":"accepted","created":"2022-06-01T12:30:00.123Z"}`
+	r.messages <- &message{client: eu, data: []byte(share)}
+	r.messages <- &message{client: us, data: []byte(share)}
+	block := []byte(`{"type":"blockfound","poolId":"eth1","blockHeight":100}`)
+	r.messages <- &message{client: eu, data: block}
+
+	// shares aren't relayed
+	select {
+	case msg := <-broadcast:
+		assert.Equal(t, block, msg)
+	case <-time.After(time.Second):
+		t.Fatal("block wasn't relayed")
+	}
+	assert.Equal(t, uint64(1), us.Status().Duplicates)
+
+	s.Flush(context.Background())
+	require.Len(t, db.added, 1)
+	assert.Equal(t, int64(1), db.added[0].Accepted)
+}